@@ -3,49 +3,116 @@ package app
 import (
 	"context"
 	"fmt"
-	"log"
+	"slices"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
 	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
 	"github.com/deevus/truenas-tui/views"
 	"github.com/deevus/truenas-tui/widgets"
 )
 
 // Connected is posted when the background connection goroutine succeeds.
+// Server names the profile that connected; empty means the one on screen.
 type Connected struct {
+	Server   string
 	Services *internal.Services
 }
 
 // ConnectFailed is posted when the background connection goroutine fails.
 type ConnectFailed struct {
-	Err error
+	Server string
+	Err    error
 }
 
+// ConnectionLost is posted by a session's health check when the server
+// stops answering.
+type ConnectionLost struct {
+	Server string
+	Err    error
+}
+
+// Reconnecting is posted before each attempt to restore a lost connection.
+type Reconnecting struct {
+	Server  string
+	Attempt int
+}
+
+// ReconnectFailed is posted when an attempt to restore a lost connection
+// fails. The next attempt starts after RetryIn.
+type ReconnectFailed struct {
+	Server  string
+	Err     error
+	RetryIn time.Duration
+}
+
+// Connection supervision defaults.
+const (
+	defaultHealthInterval = 15 * time.Second
+	pingTimeout           = 10 * time.Second
+)
+
+// Tab indexes, in TabBar order.
+const (
+	tabDashboard = iota
+	tabPools
+	tabDatasets
+	tabSnapshots
+	tabAlerts
+	tabDisks
+	tabCount
+)
+
+// tabNames are the tab labels, indexed by tab.
+var tabNames = []string{"Dashboard", "Pools", "Datasets", "Snapshots", "Alerts", "Disks"}
+
 // Params holds configuration for creating an App.
 type Params struct {
+	// ServerName is the profile shown first. When empty and Servers lists
+	// more than one profile, the App starts with the server picker open.
 	ServerName string
-	StaleTTL   time.Duration
-	Services   *internal.Services                                   // immediate (tests)
-	Connect    func(ctx context.Context) (*internal.Services, error) // async (main)
+	// Servers lists every profile offered by the server picker and the
+	// fleet overview.
+	Servers []string
+	// Fleet starts the App on the fleet overview, connected to every server.
+	Fleet    bool
+	StaleTTL time.Duration
+	// HealthInterval is how often connections are checked. Defaults to
+	// 15s; negative disables the check.
+	HealthInterval time.Duration
+	// ReconnectDelay is the base delay between reconnect attempts, doubled
+	// after each failure. Defaults to 1s.
+	ReconnectDelay time.Duration
+	Services       *internal.Services                                                   // immediate (tests)
+	Connect        func(ctx context.Context, server string) (*internal.Services, error) // async (main)
+	// Log is the buffer shown by the console. Defaults to logging.Default().
+	Log *logging.Buffer
 }
 
-// App is the root vxfw widget for truenas-tui.
+// App is the root vxfw widget for truenas-tui. It keeps one session per
+// server the user has opened and shows the current one.
 type App struct {
-	serverName string
-	staleTTL   time.Duration
-	tabBar     *widgets.TabBar
-	services   *internal.Services
-	dashboard  *views.DashboardView
-	pools      *views.PoolsView
-	datasets   *views.DatasetsView
-	snapshots  *views.SnapshotsView
-	postEvent  func(vaxis.Event)
-	connectFn  func(ctx context.Context) (*internal.Services, error)
-	connected  bool
-	connectErr error
+	staleTTL       time.Duration
+	servers        []string
+	sessions       map[string]*session
+	current        *session
+	picker         *widgets.Picker
+	palette        *widgets.Palette
+	help           *views.HelpView    // nil unless open
+	console        *views.ConsoleView // nil unless open
+	log            *logging.Buffer
+	fleet          *views.FleetView // created the first time the overview opens
+	showFleet      bool
+	startFleet     bool
+	healthInterval time.Duration
+	reconnectDelay time.Duration
+	postEvent      func(vaxis.Event)
+	connectFn      func(ctx context.Context, server string) (*internal.Services, error)
 }
 
 // New creates the root App widget.
@@ -54,32 +121,44 @@ type App struct {
 // callback from the Init event in a background goroutine.
 func New(p Params) *App {
 	a := &App{
-		serverName: p.ServerName,
-		staleTTL:   p.StaleTTL,
-		connectFn:  p.Connect,
-		tabBar:     widgets.NewTabBar([]string{"Dashboard", "Pools", "Datasets", "Snapshots"}),
+		staleTTL:       p.StaleTTL,
+		servers:        p.Servers,
+		sessions:       make(map[string]*session),
+		connectFn:      p.Connect,
+		startFleet:     p.Fleet,
+		healthInterval: p.HealthInterval,
+		reconnectDelay: p.ReconnectDelay,
+		log:            p.Log,
+	}
+	if a.log == nil {
+		a.log = logging.Default()
 	}
-	if p.Services != nil {
-		a.initServices(p.Services)
+	if a.healthInterval == 0 {
+		a.healthInterval = defaultHealthInterval
+	}
+	if p.ServerName == "" && len(p.Servers) == 1 {
+		p.ServerName = p.Servers[0]
+	}
+	if p.ServerName != "" {
+		if !slices.Contains(a.servers, p.ServerName) {
+			a.servers = append(a.servers, p.ServerName)
+		}
+		a.current = a.session(p.ServerName)
+		if p.Services != nil {
+			a.current.initServices(p.Services)
+		}
 	}
 	return a
 }
 
-// initServices creates views backed by the given services and marks the app
-// as connected.
-func (a *App) initServices(svc *internal.Services) {
-	a.services = svc
-	a.dashboard = views.NewDashboardView(views.DashboardViewParams{
-		System:     svc.System,
-		Reporting:  svc.Reporting,
-		Interfaces: svc.Interfaces,
-		Apps:       svc.Apps,
-		PostEvent:  a.postEvent,
-	})
-	a.pools = views.NewPoolsView(views.PoolsViewParams{Service: svc.Datasets, StaleTTL: a.staleTTL})
-	a.datasets = views.NewDatasetsView(views.DatasetsViewParams{Service: svc.Datasets, StaleTTL: a.staleTTL})
-	a.snapshots = views.NewSnapshotsView(views.SnapshotsViewParams{Service: svc.Snapshots, StaleTTL: a.staleTTL})
-	a.connected = true
+// session returns the session for the named server, creating it if needed.
+func (a *App) session(name string) *session {
+	s, ok := a.sessions[name]
+	if !ok {
+		s = newSession(name, a.staleTTL, a.post)
+		a.sessions[name] = s
+	}
+	return s
 }
 
 // SetPostEvent sets the function used to post events to the vaxis event loop.
@@ -87,93 +166,249 @@ func (a *App) SetPostEvent(fn func(vaxis.Event)) {
 	a.postEvent = fn
 }
 
+// post forwards ev to the event loop. Views are handed this rather than
+// postEvent directly because they may be created before SetPostEvent runs.
+func (a *App) post(ev vaxis.Event) {
+	if a.postEvent != nil {
+		a.postEvent(ev)
+	}
+}
+
 // ActiveTab returns the current tab index.
 func (a *App) ActiveTab() int {
-	return a.tabBar.Active()
+	if a.current == nil {
+		return 0
+	}
+	return a.current.tabBar.Active()
 }
 
 // SetTab switches to the given tab index.
 func (a *App) SetTab(i int) {
-	a.tabBar.SetActive(i)
+	if a.current != nil {
+		a.current.tabBar.SetActive(i)
+	}
 }
 
-// ServerName returns the connected server profile name.
+// ServerName returns the profile name of the server on screen, or "" while
+// none has been picked.
 func (a *App) ServerName() string {
-	return a.serverName
+	if a.current == nil {
+		return ""
+	}
+	return a.current.name
 }
 
 // Connected reports whether the app has an active connection.
 func (a *App) IsConnected() bool {
-	return a.connected
+	return a.current != nil && a.current.connected
+}
+
+// SwitchServer shows the named server, connecting to it first if this is the
+// first time it is opened or its last connection attempt failed. Sessions of
+// other servers keep running in the background.
+func (a *App) SwitchServer(name string) {
+	s := a.session(name)
+	if !slices.Contains(a.servers, name) {
+		a.servers = append(a.servers, name)
+	}
+	a.current = s
+	a.showFleet = false
+	if !s.connected {
+		s.connect(a.connectFn)
+		a.syncFleet(s)
+		return
+	}
+	a.show(s)
+}
+
+// show brings a connected session up to date as it comes on screen. Sessions
+// connected in the background, e.g. by the fleet overview, load their views
+// the first time they are shown.
+func (a *App) show(s *session) {
+	if !s.loaded {
+		s.LoadAll(context.Background())
+		return
+	}
+	s.refetchIfStale()
+	s.syncAlertBadge()
+}
+
+// FleetShown reports whether the fleet overview is on screen.
+func (a *App) FleetShown() bool {
+	return a.showFleet
+}
+
+// Fleet returns the fleet overview, or nil if it has not been opened.
+func (a *App) Fleet() *views.FleetView {
+	return a.fleet
+}
+
+// OpenFleet shows the fleet overview and connects to every server that is
+// not connected yet. Each connection runs on its own, so one unreachable
+// server does not hold up the others.
+func (a *App) OpenFleet() {
+	if a.fleet == nil {
+		a.fleet = views.NewFleetView(views.FleetViewParams{
+			Servers:   a.servers,
+			PostEvent: a.post,
+			OnSelect: func(server string) (vxfw.Command, error) {
+				a.SwitchServer(server)
+				return vxfw.ConsumeAndRedraw(), nil
+			},
+		})
+	}
+	a.showFleet = true
+	for _, name := range a.servers {
+		s := a.session(name)
+		s.connect(a.connectFn)
+		a.syncFleet(s)
+	}
+}
+
+// closeFleet returns from the fleet overview to the current server.
+func (a *App) closeFleet() {
+	a.showFleet = false
+	if a.current != nil && a.current.connected {
+		a.show(a.current)
+	}
+}
+
+// syncFleet reports a session's connection state to the fleet overview.
+func (a *App) syncFleet(s *session) {
+	if a.fleet == nil {
+		return
+	}
+	switch {
+	case s.lost != nil:
+		a.fleet.Failed(s.name, s.lost)
+	case s.connected:
+		a.fleet.Connected(s.name, s.services)
+	case s.connecting:
+		a.fleet.Connecting(s.name)
+	case s.connectErr != nil:
+		a.fleet.Failed(s.name, s.connectErr)
+	}
 }
 
 // LoadAll loads data for all views in parallel using goroutines.
 // Each view posts a ViewLoaded event when done.
 func (a *App) LoadAll(ctx context.Context) {
-	if !a.connected {
-		return
-	}
-	for tab := 0; tab < 4; tab++ {
-		go func(t int) {
-			var err error
-			switch t {
-			case 0:
-				err = a.dashboard.Load(ctx)
-			case 1:
-				err = a.pools.Load(ctx)
-			case 2:
-				err = a.datasets.Load(ctx)
-			case 3:
-				err = a.snapshots.Load(ctx)
-			}
-			if a.postEvent != nil {
-				a.postEvent(views.ViewLoaded{Tab: t, Err: err})
-			}
-		}(tab)
+	if a.current != nil {
+		a.current.LoadAll(ctx)
 	}
 }
 
 // LoadActiveView fetches data for the currently active view.
 func (a *App) LoadActiveView(ctx context.Context) error {
-	if !a.connected {
+	if !a.IsConnected() {
 		return nil
 	}
-	switch a.tabBar.Active() {
-	case 0:
-		return a.dashboard.Load(ctx)
-	case 1:
-		return a.pools.Load(ctx)
-	case 2:
-		return a.datasets.Load(ctx)
-	case 3:
-		return a.snapshots.Load(ctx)
-	}
-	return nil
+	return a.current.loadTab(ctx, a.current.tabBar.Active())
 }
 
 func (a *App) activeView() vxfw.Widget {
-	if !a.connected {
+	if a.showFleet {
+		return a.fleet
+	}
+	if a.current == nil {
 		return nil
 	}
-	switch a.tabBar.Active() {
-	case 0:
-		return a.dashboard
-	case 1:
-		return a.pools
-	case 2:
-		return a.datasets
-	case 3:
-		return a.snapshots
-	default:
-		return a.dashboard
+	return a.current.activeView()
+}
+
+// openPicker shows the server picker with the cursor on the current server.
+func (a *App) openPicker() {
+	items := make([]string, len(a.servers))
+	cursor := 0
+	for i, name := range a.servers {
+		status := ""
+		if s, ok := a.sessions[name]; ok {
+			switch {
+			case s.connected:
+				status = "connected"
+			case s.connecting:
+				status = "connecting..."
+			case s.connectErr != nil:
+				status = "failed"
+			}
+		}
+		items[i] = fmt.Sprintf("%-20s %s", name, status)
+		if s := a.current; s != nil && s.name == name {
+			cursor = i
+		}
+	}
+	a.picker = &widgets.Picker{
+		Title:  "Server",
+		Items:  items,
+		Cursor: cursor,
+		OnSelect: func(i int) (vxfw.Command, error) {
+			a.picker = nil
+			a.SwitchServer(a.servers[i])
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+		OnCancel: func() (vxfw.Command, error) {
+			a.picker = nil
+			if a.current == nil && !a.showFleet {
+				return vxfw.QuitCmd{}, nil
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		},
 	}
 }
 
+// ConsoleOpen reports whether the log console is shown.
+func (a *App) ConsoleOpen() bool {
+	return a.console != nil
+}
+
+// toggleConsole opens the log console, or closes it if it is open.
+func (a *App) toggleConsole() {
+	if a.console != nil {
+		a.console.Close()
+		a.console = nil
+		return
+	}
+	a.console = views.NewConsoleView(views.ConsoleViewParams{
+		Buffer:    a.log,
+		PostEvent: a.post,
+		OnClose: func() (vxfw.Command, error) {
+			a.console = nil
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+	})
+	a.console.Open()
+}
+
+// HelpOpen reports whether the help overlay is shown.
+func (a *App) HelpOpen() bool {
+	return a.help != nil
+}
+
+// openHelp shows the global bindings followed by the active view's and the
+// dialogs'.
+func (a *App) openHelp() {
+	scopes := []*keys.Scope{keys.GlobalScope}
+	cur := a.current
+	if a.showFleet || cur != nil && cur.connected {
+		if v, ok := a.activeView().(views.KeyScoper); ok {
+			scopes = append(scopes, v.KeyScopes()...)
+		}
+	}
+	scopes = append(scopes, keys.ConfirmScope, keys.PickerScope)
+	a.help = views.NewHelpView(views.HelpViewParams{
+		Scopes: scopes,
+		OnClose: func() (vxfw.Command, error) {
+			a.help = nil
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+	})
+}
+
 // drawMessage renders a single dimmed text message.
 func drawMessage(ctx vxfw.DrawContext, owner vxfw.Widget, text string) (vxfw.Surface, error) {
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, owner)
 	label := richtext.New([]vaxis.Segment{
-		{Text: text, Style: vaxis.Style{Attribute: vaxis.AttrDim}},
+		{Text: text, Style: theme.Dim()},
 	})
 	labelSurf, err := label.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
 	if err != nil {
@@ -183,141 +418,419 @@ func drawMessage(ctx vxfw.DrawContext, owner vxfw.Widget, text string) (vxfw.Sur
 	return s, nil
 }
 
-// Draw renders the tab bar and active view, or a status message if not connected.
+// Draw renders the fleet overview or the current server's tab bar and
+// active view, or a status message if not connected. The current server's
+// name is always shown on the top row. The log console covers the bottom
+// half of the screen while open, and the help, command palette and server
+// picker are drawn over everything.
 func (a *App) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
-	if a.connectErr != nil {
-		return drawMessage(ctx, a, fmt.Sprintf("Connection failed: %v", a.connectErr))
+	var s vxfw.Surface
+	var err error
+	if a.showFleet {
+		s, err = a.fleet.Draw(ctx)
+	} else {
+		s, err = a.drawSession(ctx)
 	}
-	if !a.connected {
-		return drawMessage(ctx, a, fmt.Sprintf("Connecting to %s...", a.serverName))
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	if a.console != nil {
+		height := max(ctx.Max.Height/2, min(ctx.Max.Height, 5))
+		consoleSurf, err := a.console.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: height}))
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, int(ctx.Max.Height-height), consoleSurf)
+	}
+	if a.help != nil {
+		helpSurf, err := a.help.Draw(ctx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, 0, helpSurf)
+	}
+	if a.palette != nil {
+		paletteSurf, err := a.palette.Draw(ctx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		col := (int(ctx.Max.Width) - int(paletteSurf.Size.Width)) / 2
+		s.AddChild(max(col, 0), min(2, max(int(ctx.Max.Height)-int(paletteSurf.Size.Height), 0)), paletteSurf)
+	}
+	if a.picker != nil {
+		pickerSurf, err := a.picker.Draw(ctx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		col := (int(ctx.Max.Width) - int(pickerSurf.Size.Width)) / 2
+		row := (int(ctx.Max.Height) - int(pickerSurf.Size.Height)) / 2
+		s.AddChild(max(col, 0), max(row, 0), pickerSurf)
+	}
+	return s, nil
+}
+
+func (a *App) drawSession(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	cur := a.current
+	switch {
+	case cur == nil:
+		return drawMessage(ctx, a, "Choose a server")
+	case cur.connectErr != nil:
+		msg := fmt.Sprintf("Connection to %s failed: %v (%s to retry)", cur.name, cur.connectErr, keys.Refresh.Short())
+		if len(a.servers) > 1 {
+			msg = fmt.Sprintf("Connection to %s failed: %v (%s to retry, %s for another server)",
+				cur.name, cur.connectErr, keys.Refresh.Short(), keys.SwitchServer.Short())
+		}
+		return drawMessage(ctx, a, msg)
+	case !cur.connected:
+		return drawMessage(ctx, a, fmt.Sprintf("Connecting to %s...", cur.name))
 	}
 
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, a)
 
 	// Tab bar (1 row)
 	tabCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1})
-	tabSurf, err := a.tabBar.Draw(tabCtx)
+	tabSurf, err := cur.tabBar.Draw(tabCtx)
 	if err != nil {
 		return vxfw.Surface{}, err
 	}
 	s.AddChild(0, 0, tabSurf)
 
+	// Server name and connection state, right-aligned on the tab bar row
+	state, stateStyle := connState(cur)
+	name := richtext.New([]vaxis.Segment{
+		{Text: " ● ", Style: stateStyle},
+		{Text: cur.name + " ", Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: state + " ", Style: stateStyle},
+	})
+	nameSurf, err := name.Draw(tabCtx)
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(max(int(ctx.Max.Width)-int(nameSurf.Size.Width), 0), 0, nameSurf)
+	row := 1
+
+	// While the connection is down the views keep their last data; say so
+	if cur.lost != nil && ctx.Max.Height > 2 {
+		banner := richtext.New([]vaxis.Segment{{
+			Text: fmt.Sprintf(" Showing data from %s. Last error: %v (%s to retry now)",
+				cur.lostAt.Format("15:04:05"), cur.lost, keys.Refresh.Short()),
+			Style: stateStyle,
+		}})
+		bannerSurf, err := banner.Draw(tabCtx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, row, bannerSurf)
+		row++
+	}
+
 	// Active view (remaining space)
-	viewCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - 1})
-	viewSurf, err := a.activeView().Draw(viewCtx)
+	viewCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - uint16(row)})
+	viewSurf, err := cur.activeView().Draw(viewCtx)
 	if err != nil {
 		return vxfw.Surface{}, err
 	}
-	s.AddChild(0, 1, viewSurf)
+	s.AddChild(0, row, viewSurf)
 
 	return s, nil
 }
 
-// CaptureEvent handles global keybindings before views process them.
+// connState describes a connected session's link for the tab bar:
+// connected, reconnecting or offline.
+func connState(s *session) (string, vaxis.Style) {
+	switch {
+	case s.offline():
+		return fmt.Sprintf("offline, retrying at %s", s.retryAt.Format("15:04:05")),
+			theme.Critical()
+	case s.lost != nil:
+		text := "reconnecting..."
+		if s.attempt > 1 {
+			text = fmt.Sprintf("reconnecting (attempt %d)...", s.attempt)
+		}
+		return text, theme.Warning()
+	}
+	return "connected", theme.OK()
+}
+
+// CaptureEvent handles global keybindings and mouse routing before views
+// process events.
 func (a *App) CaptureEvent(ev vaxis.Event) (vxfw.Command, error) {
 	switch ev := ev.(type) {
 	case vaxis.Key:
-		if ev.Matches('q') {
+		if a.picker != nil {
+			return a.picker.HandleEvent(ev, vxfw.CapturePhase)
+		}
+		if a.palette != nil {
+			return a.palette.HandleEvent(ev, vxfw.CapturePhase)
+		}
+		if a.help != nil {
+			return a.help.HandleEvent(ev, vxfw.CapturePhase)
+		}
+		if a.console != nil {
+			return a.console.HandleEvent(ev, vxfw.CapturePhase)
+		}
+		if c, ok := a.activeView().(views.InputCapturer); ok && c.CapturingInput() {
+			return nil, nil
+		}
+		if keys.Console.Matches(ev) {
+			a.toggleConsole()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if keys.Help.Matches(ev) {
+			a.openHelp()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if keys.Quit.Matches(ev) {
 			return vxfw.QuitCmd{}, nil
 		}
-		if !a.connected {
+		if keys.SwitchServer.Matches(ev) && len(a.servers) > 0 {
+			a.openPicker()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if keys.Palette.Matches(ev) {
+			a.openPalette()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if keys.Fleet.Matches(ev) && len(a.servers) > 0 {
+			a.toggleFleet()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if keys.Refresh.Matches(ev) && a.canRefresh() {
+			a.refresh()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		cur := a.current
+		if a.showFleet || cur == nil || !cur.connected {
 			return nil, nil
 		}
-		prev := a.tabBar.Active()
+		for tab, b := range keys.Tabs {
+			if b.Matches(ev) {
+				a.selectTab(tab)
+				return vxfw.ConsumeAndRedraw(), nil
+			}
+		}
 		switch {
-		case ev.Matches('r'):
-			a.loadActiveViewAsync()
-			return vxfw.ConsumeAndRedraw(), nil
-		case ev.Matches('1'):
-			a.tabBar.SetActive(0)
-		case ev.Matches('2'):
-			a.tabBar.SetActive(1)
-		case ev.Matches('3'):
-			a.tabBar.SetActive(2)
-		case ev.Matches('4'):
-			a.tabBar.SetActive(3)
-		case ev.Matches(vaxis.KeyTab):
-			a.tabBar.Next()
-		case ev.Matches(vaxis.KeyTab, vaxis.ModShift):
-			a.tabBar.Prev()
+		case keys.NextTab.Matches(ev):
+			a.selectTab((cur.tabBar.Active() + 1) % tabCount)
+		case keys.PrevTab.Matches(ev):
+			a.selectTab((cur.tabBar.Active() + tabCount - 1) % tabCount)
 		default:
 			return nil, nil
 		}
-		if a.tabBar.Active() != prev {
-			a.refetchIfStale()
-		}
 		return vxfw.ConsumeAndRedraw(), nil
+	case vaxis.Mouse:
+		return a.handleMouse(ev)
 	}
 	return nil, nil
 }
 
-// loadActiveViewAsync loads the active view's data in a background goroutine.
-func (a *App) loadActiveViewAsync() {
-	if !a.connected {
-		return
+// handleMouse routes mouse events before the widgets under the pointer see
+// them. Overlays, and views capturing input, take every mouse event so
+// nothing drawn beneath them reacts; they scroll with the wheel. A click on
+// the tab bar switches tabs. Anything else is left to the widgets under the
+// pointer: list rows move the cursor when clicked and lists scroll with the
+// wheel.
+func (a *App) handleMouse(ev vaxis.Mouse) (vxfw.Command, error) {
+	var modal vxfw.EventHandler
+	switch {
+	case a.picker != nil, a.palette != nil:
+		return vxfw.ConsumeEventCmd{}, nil
+	case a.help != nil:
+		modal = a.help
+	case a.console != nil:
+		modal = a.console
+	default:
+		if c, ok := a.activeView().(views.InputCapturer); ok && c.CapturingInput() {
+			modal, _ = c.(vxfw.EventHandler)
+		}
 	}
-	tab := a.tabBar.Active()
-	go func() {
-		err := a.LoadActiveView(context.Background())
-		if a.postEvent != nil {
-			a.postEvent(views.ViewLoaded{Tab: tab, Err: err})
+	if modal != nil {
+		cmd, err := modal.HandleEvent(ev, vxfw.CapturePhase)
+		if cmd == nil && err == nil {
+			return vxfw.ConsumeEventCmd{}, nil
 		}
-	}()
+		return cmd, err
+	}
+
+	cur := a.current
+	if a.showFleet || cur == nil || !cur.connected {
+		return nil, nil
+	}
+	if ev.Row == 0 && ev.Button == vaxis.MouseLeftButton && ev.EventType == vaxis.EventPress {
+		if tab, ok := cur.tabBar.TabAt(uint16(ev.Col)); ok {
+			a.selectTab(tab)
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+	}
+	return nil, nil
 }
 
-// refetchIfStale reloads the active view's data in the background if stale.
-func (a *App) refetchIfStale() {
-	if !a.connected {
+// selectTab shows a tab of the current server, refreshing it if stale.
+func (a *App) selectTab(tab int) {
+	cur := a.current
+	if cur == nil || cur.tabBar.Active() == tab {
 		return
 	}
-	var stale bool
-	switch a.tabBar.Active() {
-	case 0:
-		// Dashboard is streaming, never stale
-		return
-	case 1:
-		stale = a.pools.Stale()
-	case 2:
-		stale = a.datasets.Stale()
-	case 3:
-		stale = a.snapshots.Stale()
+	cur.tabBar.SetActive(tab)
+	cur.refetchIfStale()
+	cur.syncAlertBadge()
+}
+
+// toggleFleet opens the fleet overview, or returns to the current server.
+func (a *App) toggleFleet() {
+	if !a.showFleet {
+		a.OpenFleet()
+	} else if a.current != nil {
+		a.closeFleet()
+	}
+}
+
+// canRefresh reports whether r has anything to do: reload the active tab,
+// retry a failed or lost connection, or refresh the fleet.
+func (a *App) canRefresh() bool {
+	cur := a.current
+	switch {
+	case a.showFleet:
+		return true
+	case cur == nil:
+		return false
+	case !cur.connected:
+		return cur.connectErr != nil && a.connectFn != nil
+	}
+	return true
+}
+
+// refresh reloads what is on screen. In the fleet overview it retries
+// unreachable servers and refreshes the others; on a server it retries the
+// connection if it is down and otherwise reloads the active tab.
+func (a *App) refresh() {
+	cur := a.current
+	switch {
+	case a.showFleet:
+		for _, name := range a.servers {
+			s := a.session(name)
+			s.connect(a.connectFn)
+			a.syncFleet(s)
+		}
+		a.fleet.Refresh()
+	case !cur.connected:
+		cur.connect(a.connectFn)
+	case cur.lost != nil:
+		cur.retry()
+	default:
+		cur.loadTabAsync(cur.tabBar.Active())
 	}
-	if stale {
-		a.loadActiveViewAsync()
+}
+
+// sessionFor returns the named server's session, or the current one when
+// server is empty.
+func (a *App) sessionFor(server string) *session {
+	if server == "" {
+		return a.current
+	}
+	return a.sessions[server]
+}
+
+// owner returns the session whose views include v, or nil.
+func (a *App) owner(v vxfw.Widget) *session {
+	for _, s := range a.sessions {
+		if s.tabOf(v) >= 0 {
+			return s
+		}
 	}
+	return nil
 }
 
-// HandleEvent delegates to the active view, and handles custom events.
+// HandleEvent delegates to the active view, and routes custom events to the
+// session they belong to. Events from background sessions update their
+// views without redrawing the screen.
 func (a *App) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	var s *session
 	switch ev := ev.(type) {
 	case vxfw.Init:
-		if a.connectFn != nil {
-			go func() {
-				svc, err := a.connectFn(context.Background())
-				if err != nil {
-					a.postEvent(ConnectFailed{Err: err})
-				} else {
-					a.postEvent(Connected{Services: svc})
-				}
-			}()
+		if a.startFleet && len(a.servers) > 0 {
+			a.OpenFleet()
+			return vxfw.RedrawCmd{}, nil
+		}
+		if a.current != nil {
+			a.current.connect(a.connectFn)
+		} else if len(a.servers) > 0 {
+			a.openPicker()
+			return vxfw.RedrawCmd{}, nil
 		}
 		return nil, nil
 	case Connected:
-		a.initServices(ev.Services)
-		a.LoadAll(context.Background())
-		return vxfw.RedrawCmd{}, nil
+		if s = a.sessionFor(ev.Server); s == nil {
+			return nil, nil
+		}
+		if s.connected {
+			logging.Infof("%s: reconnected", s.name)
+			s.reconnected(ev.Services)
+		} else {
+			logging.Infof("%s: connected", s.name)
+			s.initServices(ev.Services)
+		}
+		if a.connectFn != nil {
+			s.supervise(a.healthInterval)
+		}
+		a.syncFleet(s)
+		if s == a.current && !a.showFleet {
+			s.LoadAll(context.Background())
+		}
+		return a.redrawIfCurrent(s, vxfw.RedrawCmd{}), nil
 	case ConnectFailed:
-		a.connectErr = ev.Err
-		return vxfw.RedrawCmd{}, nil
+		if s = a.sessionFor(ev.Server); s == nil {
+			return nil, nil
+		}
+		logging.Errorf("%s: connection failed: %v", s.name, ev.Err)
+		s.connecting = false
+		s.connectErr = ev.Err
+		a.syncFleet(s)
+		return a.redrawIfCurrent(s, vxfw.RedrawCmd{}), nil
+	case ConnectionLost:
+		if s = a.sessionFor(ev.Server); s == nil {
+			return nil, nil
+		}
+		logging.Errorf("%s: connection lost: %v", s.name, ev.Err)
+		s.connectionLost(ev.Err, a.connectFn, a.reconnectDelay)
+		a.syncFleet(s)
+		return a.redrawIfCurrent(s, vxfw.RedrawCmd{}), nil
+	case Reconnecting:
+		if s = a.sessionFor(ev.Server); s == nil || s.lost == nil {
+			return nil, nil
+		}
+		logging.Infof("%s: reconnecting (attempt %d)", s.name, ev.Attempt)
+		s.attempt = ev.Attempt
+		s.retryAt = time.Time{}
+		return a.redrawIfCurrent(s, vxfw.RedrawCmd{}), nil
+	case ReconnectFailed:
+		if s = a.sessionFor(ev.Server); s == nil || s.lost == nil {
+			return nil, nil
+		}
+		logging.Warnf("%s: reconnect attempt %d failed: %v", s.name, s.attempt, ev.Err)
+		s.lost = ev.Err
+		s.retryAt = time.Now().Add(ev.RetryIn)
+		a.syncFleet(s)
+		return a.redrawIfCurrent(s, vxfw.RedrawCmd{}), nil
 	case views.ViewLoaded:
-		if ev.Err != nil {
-			log.Printf("error loading tab %d: %v", ev.Tab, ev.Err)
+		s = a.sessionFor(ev.Server)
+	case views.AlertsChanged:
+		s = a.current
+		if ev.View != nil {
+			s = a.owner(ev.View)
 		}
-		// Start dashboard subscriptions once it has loaded
-		if ev.Tab == 0 && ev.Err == nil && a.dashboard != nil {
-			a.dashboard.StartSubscriptions(context.Background())
+	case views.PoolDetailLoaded:
+		s = a.current
+		if ev.View != nil {
+			s = a.owner(ev.View)
 		}
-		return vxfw.RedrawCmd{}, nil
+	case views.DiskDetailLoaded:
+		s = a.current
+		if ev.View != nil {
+			s = a.owner(ev.View)
+		}
+	case views.ActionCompleted:
+		s = a.owner(ev.View)
 	case views.DashboardUpdated:
 		return vxfw.RedrawCmd{}, nil
 	default:
@@ -329,6 +842,20 @@ func (a *App) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command,
 				return h.HandleEvent(ev, phase)
 			}
 		}
+		return nil, nil
 	}
-	return nil, nil
+	if s == nil {
+		return nil, nil
+	}
+	cmd, _ := s.handleEvent(ev)
+	return a.redrawIfCurrent(s, cmd), nil
+}
+
+// redrawIfCurrent returns cmd if s is on screen, nil otherwise. Every
+// server is on screen while the fleet overview is shown.
+func (a *App) redrawIfCurrent(s *session, cmd vxfw.Command) vxfw.Command {
+	if s != a.current && !a.showFleet {
+		return nil
+	}
+	return cmd
 }
@@ -3,6 +3,7 @@ package app_test
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,11 +13,15 @@ import (
 	"github.com/deevus/truenas-go"
 	"github.com/deevus/truenas-tui/app"
 	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
 	"github.com/deevus/truenas-tui/views"
 )
 
 const testStaleTTL = 30 * time.Second
 
+// testTabCount is the number of tabs, each loaded by LoadAll.
+const testTabCount = 6
+
 func testDrawContext(w, h uint16) vxfw.DrawContext {
 	return vxfw.DrawContext{
 		Max: vxfw.Size{Width: w, Height: h},
@@ -34,7 +39,7 @@ func testDrawContext(w, h uint16) vxfw.DrawContext {
 func newTestServices() *internal.Services {
 	return internal.NewServices(
 		&truenas.MockDatasetService{},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{
 			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
 				return &truenas.SystemInfo{Hostname: "test", Model: "Test"}, nil
@@ -54,6 +59,10 @@ func newTestServices() *internal.Services {
 				return nil, nil
 			},
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 }
 
@@ -71,7 +80,7 @@ func newTestServicesWithData() *internal.Services {
 				}, nil
 			},
 		},
-		&truenas.MockSnapshotService{
+		&internal.MockSnapshotService{
 			ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 				return []truenas.Snapshot{
 					{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"},
@@ -112,6 +121,10 @@ func newTestServicesWithData() *internal.Services {
 				}, nil
 			},
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 }
 
@@ -225,7 +238,7 @@ func TestApp_LoadActiveView_Error_Pools(t *testing.T) {
 				return nil, context.DeadlineExceeded
 			},
 		},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{
 			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
 				return &truenas.SystemInfo{Hostname: "test", Model: "Test"}, nil
@@ -239,6 +252,10 @@ func TestApp_LoadActiveView_Error_Pools(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 	a := newApp(svc)
 	a.SetTab(1)
@@ -256,7 +273,7 @@ func TestApp_LoadActiveView_Error_Datasets(t *testing.T) {
 				return nil, context.DeadlineExceeded
 			},
 		},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{
 			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
 				return &truenas.SystemInfo{Hostname: "test", Model: "Test"}, nil
@@ -270,6 +287,10 @@ func TestApp_LoadActiveView_Error_Datasets(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 	a := newApp(svc)
 	a.SetTab(2)
@@ -283,7 +304,7 @@ func TestApp_LoadActiveView_Error_Datasets(t *testing.T) {
 func TestApp_LoadActiveView_Error_Snapshots(t *testing.T) {
 	svc := internal.NewServices(
 		&truenas.MockDatasetService{},
-		&truenas.MockSnapshotService{
+		&internal.MockSnapshotService{
 			ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 				return nil, context.DeadlineExceeded
 			},
@@ -301,6 +322,10 @@ func TestApp_LoadActiveView_Error_Snapshots(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 	a := newApp(svc)
 	a.SetTab(3)
@@ -474,8 +499,8 @@ func TestApp_CaptureEvent_ShiftTab(t *testing.T) {
 	if cmd == nil {
 		t.Fatal("expected non-nil command for Shift+Tab")
 	}
-	if a.ActiveTab() != 3 {
-		t.Errorf("expected tab 3 after Shift+Tab, got %d", a.ActiveTab())
+	if a.ActiveTab() != 5 {
+		t.Errorf("expected tab 5 after Shift+Tab, got %d", a.ActiveTab())
 	}
 }
 
@@ -551,7 +576,7 @@ func TestApp_HandleEvent_Init_WithConnectFn(t *testing.T) {
 	a := app.New(app.Params{
 		ServerName: "test-server",
 		StaleTTL:   testStaleTTL,
-		Connect: func(ctx context.Context) (*internal.Services, error) {
+		Connect: func(ctx context.Context, server string) (*internal.Services, error) {
 			called = true
 			return svc, nil
 		},
@@ -584,7 +609,7 @@ func TestApp_HandleEvent_Init_WithConnectFn_Error(t *testing.T) {
 	a := app.New(app.Params{
 		ServerName: "test-server",
 		StaleTTL:   testStaleTTL,
-		Connect: func(ctx context.Context) (*internal.Services, error) {
+		Connect: func(ctx context.Context, server string) (*internal.Services, error) {
 			return nil, fmt.Errorf("connection refused")
 		},
 	})
@@ -622,7 +647,7 @@ func TestApp_HandleEvent_Connected(t *testing.T) {
 
 	var mu sync.Mutex
 	var events []views.ViewLoaded
-	done := make(chan struct{}, 4)
+	done := make(chan struct{}, testTabCount)
 
 	a.SetPostEvent(func(ev vaxis.Event) {
 		if vl, ok := ev.(views.ViewLoaded); ok {
@@ -645,8 +670,8 @@ func TestApp_HandleEvent_Connected(t *testing.T) {
 		t.Error("expected connected after Connected event")
 	}
 
-	// Wait for LoadAll goroutines (4 tabs now)
-	for i := 0; i < 4; i++ {
+	// Wait for LoadAll goroutines, one per tab
+	for i := 0; i < testTabCount; i++ {
 		select {
 		case <-done:
 		case <-time.After(5 * time.Second):
@@ -656,8 +681,8 @@ func TestApp_HandleEvent_Connected(t *testing.T) {
 
 	mu.Lock()
 	defer mu.Unlock()
-	if len(events) != 4 {
-		t.Fatalf("expected 4 ViewLoaded events, got %d", len(events))
+	if len(events) != testTabCount {
+		t.Fatalf("expected %d ViewLoaded events, got %d", testTabCount, len(events))
 	}
 }
 
@@ -731,7 +756,7 @@ func TestApp_LoadAll(t *testing.T) {
 
 	var mu sync.Mutex
 	var events []views.ViewLoaded
-	done := make(chan struct{}, 4)
+	done := make(chan struct{}, testTabCount)
 
 	a.SetPostEvent(func(ev vaxis.Event) {
 		if vl, ok := ev.(views.ViewLoaded); ok {
@@ -744,7 +769,7 @@ func TestApp_LoadAll(t *testing.T) {
 
 	a.LoadAll(context.Background())
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < testTabCount; i++ {
 		select {
 		case <-done:
 		case <-time.After(5 * time.Second):
@@ -755,8 +780,8 @@ func TestApp_LoadAll(t *testing.T) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	if len(events) != 4 {
-		t.Fatalf("expected 4 ViewLoaded events, got %d", len(events))
+	if len(events) != testTabCount {
+		t.Fatalf("expected %d ViewLoaded events, got %d", testTabCount, len(events))
 	}
 
 	tabs := map[int]bool{}
@@ -766,7 +791,7 @@ func TestApp_LoadAll(t *testing.T) {
 		}
 		tabs[ev.Tab] = true
 	}
-	for i := 0; i < 4; i++ {
+	for i := 0; i < testTabCount; i++ {
 		if !tabs[i] {
 			t.Errorf("missing ViewLoaded event for tab %d", i)
 		}
@@ -780,7 +805,7 @@ func TestApp_LoadAll_WithErrors(t *testing.T) {
 				return nil, context.DeadlineExceeded
 			},
 		},
-		&truenas.MockSnapshotService{
+		&internal.MockSnapshotService{
 			ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 				return nil, context.Canceled
 			},
@@ -798,12 +823,16 @@ func TestApp_LoadAll_WithErrors(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 	a := newApp(svc)
 
 	var mu sync.Mutex
 	var events []views.ViewLoaded
-	done := make(chan struct{}, 4)
+	done := make(chan struct{}, testTabCount)
 
 	a.SetPostEvent(func(ev vaxis.Event) {
 		if vl, ok := ev.(views.ViewLoaded); ok {
@@ -816,7 +845,7 @@ func TestApp_LoadAll_WithErrors(t *testing.T) {
 
 	a.LoadAll(context.Background())
 
-	for i := 0; i < 4; i++ {
+	for i := 0; i < testTabCount; i++ {
 		select {
 		case <-done:
 		case <-time.After(5 * time.Second):
@@ -855,7 +884,7 @@ func TestApp_TabSwitch_RefetchesStale(t *testing.T) {
 				}, nil
 			},
 		},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{
 			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
 				return &truenas.SystemInfo{Hostname: "test", Model: "Test"}, nil
@@ -869,6 +898,10 @@ func TestApp_TabSwitch_RefetchesStale(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 
 	done := make(chan struct{}, 1)
@@ -903,7 +936,7 @@ func TestApp_TabSwitch_NoRefetchWhenFresh(t *testing.T) {
 				}, nil
 			},
 		},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{
 			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
 				return &truenas.SystemInfo{Hostname: "test", Model: "Test"}, nil
@@ -917,6 +950,10 @@ func TestApp_TabSwitch_NoRefetchWhenFresh(t *testing.T) {
 		&truenas.MockAppService{
 			ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) { return nil, nil },
 		},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 
 	a := app.New(app.Params{Services: svc, ServerName: "test-server", StaleTTL: time.Hour})
@@ -957,3 +994,333 @@ func TestApp_DashboardNeverStale(t *testing.T) {
 		t.Error("dashboard should never be refetched via stale mechanism")
 	}
 }
+
+func TestApp_CaptureEvent_SkippedWhileViewCapturesInput(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	a.SetTab(3)
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Open the create snapshot form.
+	if _, err := a.HandleEvent(vaxis.Key{Keycode: 'c', Text: "c"}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []vaxis.Key{
+		{Keycode: 'q', Text: "q"},
+		{Keycode: '1', Text: "1"},
+		{Keycode: vaxis.KeyTab},
+	} {
+		cmd, err := a.CaptureEvent(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cmd != nil {
+			t.Errorf("expected %v to pass through to the form, got %T", key, cmd)
+		}
+	}
+	if a.ActiveTab() != 3 {
+		t.Errorf("expected to stay on tab 3, got %d", a.ActiveTab())
+	}
+}
+
+func TestApp_HandleEvent_ActionCompleted_ReloadsView(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	events := make(chan vaxis.Event, 4)
+	a.SetPostEvent(func(ev vaxis.Event) { events <- ev })
+	a.SetTab(3)
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Place a hold on the selected snapshot.
+	key := vaxis.Key{Keycode: 'h', ShiftedCode: 'H', Modifiers: vaxis.ModShift, Text: "H"}
+	if _, err := a.HandleEvent(key, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	next := func() vaxis.Event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+		}
+		return nil
+	}
+
+	ac, ok := next().(views.ActionCompleted)
+	if !ok {
+		t.Fatal("expected ActionCompleted")
+	}
+	cmd, err := a.HandleEvent(ac, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd, got %T", cmd)
+	}
+
+	reloaded := map[int]bool{}
+	for i := 0; i < 3; i++ {
+		vl, ok := next().(views.ViewLoaded)
+		if !ok {
+			t.Fatal("expected ViewLoaded after action")
+		}
+		reloaded[vl.Tab] = true
+	}
+	for _, tab := range []int{1, 2, 3} {
+		if !reloaded[tab] {
+			t.Errorf("expected tab %d reloaded", tab)
+		}
+	}
+}
+
+func TestApp_HandleEvent_PoolDetailLoaded(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	cmd, err := a.HandleEvent(views.PoolDetailLoaded{PoolID: 1}, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd, got %T", cmd)
+	}
+}
+
+// tabBarText draws the app and returns the tab bar row.
+func tabBarText(t *testing.T, a *app.App) string {
+	t.Helper()
+	s, err := a.Draw(testDrawContext(120, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b strings.Builder
+	for _, c := range s.Children[0].Surface.Buffer {
+		b.WriteString(c.Grapheme)
+	}
+	return b.String()
+}
+
+func TestApp_AlertBadge(t *testing.T) {
+	alerts := []internal.Alert{
+		{ID: "a1", Level: internal.AlertLevelCritical, Source: "SMART"},
+		{ID: "a2", Level: internal.AlertLevelWarning, Source: "ZpoolCapacityWarning"},
+		{ID: "a3", Level: internal.AlertLevelInfo, Source: "Update", Dismissed: true},
+	}
+	svc := newTestServices()
+	svc.Alerts = &internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) {
+			return alerts, nil
+		},
+	}
+	a := newApp(svc)
+	a.SetTab(4)
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.SetTab(0)
+	if _, err := a.HandleEvent(views.ViewLoaded{Tab: 4}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := tabBarText(t, a); !strings.Contains(got, " Alerts 2 ") {
+		t.Errorf("expected 2 unread alerts in the tab bar, got %q", got)
+	}
+
+	// Opening the Alerts tab marks them read.
+	if _, err := a.CaptureEvent(vaxis.Key{Keycode: '5', Text: "5"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.ActiveTab() != 4 {
+		t.Fatalf("expected 5 to switch to the Alerts tab, got %d", a.ActiveTab())
+	}
+	if got := tabBarText(t, a); strings.Contains(got, "Alerts 2") {
+		t.Errorf("expected badge cleared on the Alerts tab, got %q", got)
+	}
+
+	// A new alert arriving while elsewhere is unread again.
+	a.SetTab(0)
+	alerts = append(alerts, internal.Alert{ID: "a4", Level: internal.AlertLevelError, Source: "Replication"})
+	a.SetTab(4)
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a.SetTab(0)
+	if _, err := a.HandleEvent(views.ViewLoaded{Tab: 4}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := tabBarText(t, a); !strings.Contains(got, " Alerts 1 ") {
+		t.Errorf("expected 1 unread alert, got %q", got)
+	}
+}
+
+func TestApp_HandleEvent_AlertsChanged_ReloadsAlerts(t *testing.T) {
+	a := newApp(newTestServices())
+	events := make(chan vaxis.Event, 4)
+	a.SetPostEvent(func(ev vaxis.Event) { events <- ev })
+
+	if _, err := a.HandleEvent(views.AlertsChanged{}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case ev := <-events:
+		if vl, ok := ev.(views.ViewLoaded); !ok || vl.Tab != 4 {
+			t.Errorf("expected ViewLoaded for the Alerts tab, got %#v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for alerts reload")
+	}
+}
+
+func TestApp_HandleEvent_DiskDetailLoaded(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	if _, err := a.CaptureEvent(vaxis.Key{Keycode: '6', Text: "6"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.ActiveTab() != 5 {
+		t.Errorf("expected 6 to switch to the Disks tab, got %d", a.ActiveTab())
+	}
+	cmd, err := a.HandleEvent(views.DiskDetailLoaded{Disk: "sda"}, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd, got %T", cmd)
+	}
+}
+
+func TestApp_Console(t *testing.T) {
+	a := newApp(newTestServices())
+	press(t, a, vaxis.Key{Keycode: '~', Text: "~"})
+	if !a.ConsoleOpen() {
+		t.Fatal("expected ~ to open the console")
+	}
+
+	// Load errors end up in the console
+	if _, err := a.HandleEvent(views.ViewLoaded{Tab: 1, Server: "test-server", Err: fmt.Errorf("pool query timed out")}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	screen := strings.Join(screenText(t, a, 120, 30), "\n")
+	if !strings.Contains(screen, "CONSOLE") || !strings.Contains(screen, "ERROR test-server: error loading tab 1: pool query timed out") {
+		t.Errorf("expected the load error in the console, got:\n%s", screen)
+	}
+
+	// Keys go to the console while it is open
+	press(t, a, vaxis.Key{Keycode: '2', Text: "2"})
+	if a.ActiveTab() != 0 {
+		t.Errorf("expected the tab to stay put while the console is open, got %d", a.ActiveTab())
+	}
+	press(t, a, vaxis.Key{Keycode: '~', Text: "~"})
+	if a.ConsoleOpen() {
+		t.Error("expected ~ to close the console")
+	}
+}
+
+func TestApp_HandleEvent_ViewLoaded_Error(t *testing.T) {
+	a := newApp(newTestServices())
+	a.SetTab(1)
+	if _, err := a.HandleEvent(views.ViewLoaded{Tab: 1, Server: "test-server", Err: fmt.Errorf("pool.query timed out")}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	screen := screenText(t, a, 120, 10)
+	if !strings.Contains(screen[0], "Pools !") {
+		t.Errorf("expected an error marker on the Pools tab, got %q", screen[0])
+	}
+	if !strings.Contains(strings.Join(screen, "\n"), "Failed to load: pool.query timed out") {
+		t.Errorf("expected the error in the view, got:\n%s", strings.Join(screen, "\n"))
+	}
+
+	if _, err := a.HandleEvent(views.ViewLoaded{Tab: 1, Server: "test-server"}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if screen := screenText(t, a, 120, 10); strings.Contains(screen[0], "Pools !") {
+		t.Errorf("expected a successful load to clear the marker, got %q", screen[0])
+	}
+}
+
+func TestApp_Help(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	a.SetTab(1)
+	press(t, a, vaxis.Key{Keycode: '?', Text: "?"})
+	if !a.HelpOpen() {
+		t.Fatal("expected ? to open the help")
+	}
+	screen := strings.Join(screenText(t, a, 120, 60), "\n")
+	for _, want := range []string{"HELP", "Global", "Ctrl+S", "Switch server", "Pools", "Start a scrub on the selected pool", "Sorting and filtering"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected %q in the help, got:\n%s", want, screen)
+		}
+	}
+	if strings.Contains(screen, "Snapshots\n") || strings.Contains(screen, "Create a snapshot") {
+		t.Error("expected only the active view's bindings")
+	}
+
+	// Keys go to the help while it is open
+	press(t, a, vaxis.Key{Keycode: '3', Text: "3"})
+	if a.ActiveTab() != 1 {
+		t.Errorf("expected the tab to stay put while the help is open, got %d", a.ActiveTab())
+	}
+	press(t, a, key(vaxis.KeyEsc))
+	if a.HelpOpen() {
+		t.Error("expected Esc to close the help")
+	}
+}
+
+func TestApp_RemappedKeys(t *testing.T) {
+	km, err := keys.Resolve(map[string][]string{"quit": {"ctrl+q"}, "tab_2": {"alt+2"}, "down": {"ctrl+n"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys.Apply(km)
+	t.Cleanup(func() { keys.Apply(nil) })
+
+	a := newApp(newTestServicesWithData())
+	if _, ok := press(t, a, key('q')).(vxfw.QuitCmd); ok {
+		t.Error("expected q to no longer quit")
+	}
+	if cmd := press(t, a, key('q', vaxis.ModCtrl)); cmd != (vxfw.QuitCmd{}) {
+		t.Errorf("expected Ctrl+Q to quit, got %T", cmd)
+	}
+
+	press(t, a, key('2'))
+	if a.ActiveTab() != 0 {
+		t.Errorf("expected 2 to no longer switch tabs, got %d", a.ActiveTab())
+	}
+	press(t, a, key('2', vaxis.ModAlt))
+	if a.ActiveTab() != 1 {
+		t.Errorf("expected Alt+2 to switch to Pools, got %d", a.ActiveTab())
+	}
+
+	press(t, a, vaxis.Key{Keycode: '?', Text: "?"})
+	screen := strings.Join(screenText(t, a, 120, 60), "\n")
+	if !strings.Contains(screen, "Ctrl+Q") || !strings.Contains(screen, "Alt+2") || !strings.Contains(screen, "Ctrl+N") {
+		t.Errorf("expected the help to show the remapped keys, got:\n%s", screen)
+	}
+}
+
+func TestApp_MouseTabs(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	tabBar := screenText(t, a, 120, 30)[0]
+	clickTab := func(label string) vxfw.Command {
+		t.Helper()
+		cmd, err := a.CaptureEvent(vaxis.Mouse{Button: vaxis.MouseLeftButton, Col: strings.Index(tabBar, label) + 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return cmd
+	}
+
+	if clickTab("Datasets"); a.ActiveTab() != 2 {
+		t.Errorf("expected clicking Datasets to switch to tab 2, got %d", a.ActiveTab())
+	}
+	if cmd := clickTab(" | "); cmd != nil || a.ActiveTab() != 2 {
+		t.Errorf("expected a click between tabs to do nothing, got tab %d", a.ActiveTab())
+	}
+
+	// The help takes the mouse while it is open
+	press(t, a, vaxis.Key{Keycode: '?', Text: "?"})
+	if cmd := clickTab("Pools"); cmd == nil || a.ActiveTab() != 2 {
+		t.Errorf("expected the click to be swallowed by the help, got tab %d", a.ActiveTab())
+	}
+}
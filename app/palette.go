@@ -0,0 +1,88 @@
+package app
+
+import (
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/views"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+// PaletteOpen reports whether the command palette is shown.
+func (a *App) PaletteOpen() bool {
+	return a.palette != nil
+}
+
+// openPalette shows the command palette: the active view's commands first,
+// then the global ones.
+func (a *App) openPalette() {
+	cmds := a.commands()
+	items := make([]widgets.PaletteItem, len(cmds))
+	for i, c := range cmds {
+		items[i] = widgets.PaletteItem{Name: c.Name, Key: c.Key, Disabled: c.Disabled}
+	}
+	a.palette = &widgets.Palette{
+		Title: "Commands",
+		Items: items,
+		OnSelect: func(i int) (vxfw.Command, error) {
+			a.palette = nil
+			cmd, err := cmds[i].Run()
+			return vxfw.BatchCmd{cmd, vxfw.ConsumeAndRedraw()}, err
+		},
+		OnCancel: func() (vxfw.Command, error) {
+			a.palette = nil
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+	}
+}
+
+// commands returns every command that can be run from the palette right
+// now, with those that don't apply disabled.
+func (a *App) commands() []views.PaletteCommand {
+	var cmds []views.PaletteCommand
+	cur := a.current
+	onServer := !a.showFleet && cur != nil && cur.connected
+	if onServer || a.showFleet {
+		if p, ok := a.activeView().(views.CommandProvider); ok {
+			cmds = append(cmds, p.Commands()...)
+		}
+	}
+
+	// run wraps a global action that only changes App state.
+	run := func(fn func()) func() (vxfw.Command, error) {
+		return func() (vxfw.Command, error) {
+			fn()
+			return nil, nil
+		}
+	}
+	for tab, name := range tabNames {
+		cmds = append(cmds, views.PaletteCommand{
+			Name: "Go to " + name, Key: keys.Tabs[tab].Short(), Disabled: !onServer,
+			Run: run(func() { a.selectTab(tab) }),
+		})
+	}
+	refresh := "Refresh tab"
+	switch {
+	case a.showFleet:
+		refresh = "Refresh fleet"
+	case cur != nil && (!cur.connected || cur.lost != nil):
+		refresh = "Retry connection"
+	}
+	fleet := "Fleet overview"
+	if a.showFleet {
+		fleet = "Back to server"
+	}
+	return append(cmds,
+		views.PaletteCommand{Name: "Next tab", Key: keys.NextTab.Short(), Disabled: !onServer, Run: run(func() {
+			a.selectTab((cur.tabBar.Active() + 1) % tabCount)
+		})},
+		views.PaletteCommand{Name: "Previous tab", Key: keys.PrevTab.Short(), Disabled: !onServer, Run: run(func() {
+			a.selectTab((cur.tabBar.Active() + tabCount - 1) % tabCount)
+		})},
+		views.PaletteCommand{Name: refresh, Key: keys.Refresh.Short(), Disabled: !a.canRefresh(), Run: run(a.refresh)},
+		views.PaletteCommand{Name: "Switch server", Key: keys.SwitchServer.Short(), Disabled: len(a.servers) == 0, Run: run(a.openPicker)},
+		views.PaletteCommand{Name: fleet, Key: keys.Fleet.Short(), Disabled: len(a.servers) == 0 || a.showFleet && cur == nil, Run: run(a.toggleFleet)},
+		views.PaletteCommand{Name: "Toggle log console", Key: keys.Console.Short(), Run: run(a.toggleConsole)},
+		views.PaletteCommand{Name: "Show keybindings", Key: keys.Help.Short(), Run: run(a.openHelp)},
+		views.PaletteCommand{Name: "Quit", Key: keys.Quit.Short(), Run: func() (vxfw.Command, error) { return vxfw.QuitCmd{}, nil }},
+	)
+}
@@ -0,0 +1,77 @@
+package app_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-tui/app"
+)
+
+func typeKeys(t *testing.T, a *app.App, text string) {
+	t.Helper()
+	for _, r := range text {
+		press(t, a, vaxis.Key{Keycode: r, Text: string(r)})
+	}
+}
+
+func TestApp_Palette_SwitchTab(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	press(t, a, vaxis.Key{Keycode: ':', Text: ":"})
+	if !a.PaletteOpen() {
+		t.Fatal("expected : to open the palette")
+	}
+	typeKeys(t, a, "go snap")
+	press(t, a, key(vaxis.KeyEnter))
+	if a.PaletteOpen() {
+		t.Error("expected running a command to close the palette")
+	}
+	if a.ActiveTab() != 3 {
+		t.Errorf("expected the Snapshots tab, got %d", a.ActiveTab())
+	}
+}
+
+func TestApp_Palette_ViewCommands(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	a.SetTab(1)
+	press(t, a, key('p', vaxis.ModCtrl))
+	if !a.PaletteOpen() {
+		t.Fatal("expected Ctrl+P to open the palette")
+	}
+	typeKeys(t, a, "scrub")
+	screen := strings.Join(screenText(t, a, 120, 30), "\n")
+	if !strings.Contains(screen, "Start scrub") {
+		t.Errorf("expected the Pools tab's commands, got:\n%s", screen)
+	}
+	// The pools list hasn't loaded, so nothing is selected
+	if !strings.Contains(screen, "Start scrub (n/a)") {
+		t.Errorf("expected Start scrub disabled without a selection, got:\n%s", screen)
+	}
+
+	press(t, a, key(vaxis.KeyEsc))
+	if a.PaletteOpen() {
+		t.Error("expected Esc to close the palette")
+	}
+}
+
+func TestApp_Palette_KeysStayInPalette(t *testing.T) {
+	a := newApp(newTestServicesWithData())
+	press(t, a, vaxis.Key{Keycode: ':', Text: ":"})
+	typeKeys(t, a, "2q")
+	if a.ActiveTab() != 0 {
+		t.Errorf("expected typed keys not to switch tabs, got %d", a.ActiveTab())
+	}
+	if !a.PaletteOpen() {
+		t.Error("expected q to be typed into the palette, not quit")
+	}
+}
+
+func TestApp_Palette_NotConnected(t *testing.T) {
+	a := app.New(app.Params{Servers: []string{"home", "work"}, StaleTTL: testStaleTTL})
+	press(t, a, vaxis.Key{Keycode: ':', Text: ":"})
+	typeKeys(t, a, "go to pools")
+	screen := strings.Join(screenText(t, a, 120, 30), "\n")
+	if !strings.Contains(screen, "Go to Pools (n/a)") {
+		t.Errorf("expected tab commands disabled while not connected, got:\n%s", screen)
+	}
+}
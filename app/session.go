@@ -0,0 +1,433 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/views"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+// viewSet holds the views of one connection to a server.
+type viewSet struct {
+	dashboard *views.DashboardView
+	pools     *views.PoolsView
+	datasets  *views.DatasetsView
+	snapshots *views.SnapshotsView
+	alerts    *views.AlertsView
+	disks     *views.DisksView
+}
+
+// session is the connection and views of one server profile. Sessions stay
+// alive, subscriptions and all, while another server is on screen, so
+// switching back is instant.
+type session struct {
+	viewSet
+	name       string
+	staleTTL   time.Duration
+	postEvent  func(vaxis.Event)
+	tabBar     *widgets.TabBar
+	services   *internal.Services
+	connected  bool
+	connecting bool
+	connectErr error
+	loaded     bool // LoadAll has run since connecting
+
+	// Connection supervision. While the connection is lost the views keep
+	// showing what they last loaded; after reconnecting, the views from the
+	// old connection stay on screen until their replacements have loaded.
+	previous   *viewSet
+	lost       error     // why the connection dropped, nil while healthy
+	lostAt     time.Time // when it dropped
+	attempt    int       // reconnect attempts since the drop
+	retryAt    time.Time // next attempt while offline, zero while one runs
+	retryNow   chan struct{}
+	stopHealth context.CancelFunc
+}
+
+func newSession(name string, staleTTL time.Duration, post func(vaxis.Event)) *session {
+	return &session{
+		name:      name,
+		staleTTL:  staleTTL,
+		postEvent: post,
+		tabBar:    widgets.NewTabBar(tabNames),
+		retryNow:  make(chan struct{}, 1),
+	}
+}
+
+// initServices creates views backed by the given services and marks the
+// session as connected.
+func (s *session) initServices(svc *internal.Services) {
+	s.services = svc
+	s.dashboard = views.NewDashboardView(views.DashboardViewParams{
+		System:     svc.System,
+		Reporting:  svc.Reporting,
+		Interfaces: svc.Interfaces,
+		Apps:       svc.Apps,
+		PostEvent:  s.postEvent,
+	})
+	s.pools = views.NewPoolsView(views.PoolsViewParams{Service: svc.Datasets, Pools: svc.Pools, StaleTTL: s.staleTTL, PostEvent: s.postEvent})
+	s.datasets = views.NewDatasetsView(views.DatasetsViewParams{Service: svc.Datasets, StaleTTL: s.staleTTL})
+	s.snapshots = views.NewSnapshotsView(views.SnapshotsViewParams{Service: svc.Snapshots, StaleTTL: s.staleTTL, PostEvent: s.postEvent, Datasets: s.datasets.Datasets})
+	s.alerts = views.NewAlertsView(views.AlertsViewParams{Service: svc.Alerts, StaleTTL: s.staleTTL, PostEvent: s.postEvent})
+	s.disks = views.NewDisksView(views.DisksViewParams{Service: svc.Disks, StaleTTL: s.staleTTL, PostEvent: s.postEvent})
+	s.connected = true
+	s.connecting = false
+	s.connectErr = nil
+	s.loaded = false
+}
+
+// connect runs fn in the background and posts Connected or ConnectFailed
+// for this session.
+func (s *session) connect(fn func(ctx context.Context, server string) (*internal.Services, error)) {
+	if fn == nil || s.connected || s.connecting {
+		return
+	}
+	s.connecting = true
+	s.connectErr = nil
+	go func() {
+		svc, err := fn(context.Background(), s.name)
+		if err != nil {
+			s.postEvent(ConnectFailed{Server: s.name, Err: err})
+		} else {
+			s.postEvent(Connected{Server: s.name, Services: svc})
+		}
+	}()
+}
+
+// supervise pings the connection every interval and posts ConnectionLost
+// the first time a ping fails. It does nothing if the services have no
+// connection to check.
+func (s *session) supervise(interval time.Duration) {
+	conn := s.services.Conn
+	if conn == nil || interval <= 0 {
+		return
+	}
+	if s.stopHealth != nil {
+		s.stopHealth()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopHealth = cancel
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			pingCtx, cancelPing := context.WithTimeout(ctx, pingTimeout)
+			err := conn.Ping(pingCtx)
+			cancelPing()
+			if err != nil && ctx.Err() == nil {
+				s.postEvent(ConnectionLost{Server: s.name, Err: err})
+				return
+			}
+		}
+	}()
+}
+
+// connectionLost stops everything that talks to the dead connection and
+// starts reconnecting with exponential backoff. The views keep their data.
+func (s *session) connectionLost(err error, fn func(ctx context.Context, server string) (*internal.Services, error), base time.Duration) {
+	if s.lost != nil || !s.connected {
+		return
+	}
+	s.lost = err
+	s.lostAt = time.Now()
+	s.attempt = 0
+	if s.stopHealth != nil {
+		s.stopHealth()
+		s.stopHealth = nil
+	}
+	s.dashboard.StopSubscriptions()
+	s.alerts.StopSubscription()
+	s.pools.StopPolling()
+	if s.services.Conn != nil {
+		if err := s.services.Conn.Close(); err != nil {
+			logging.Warnf("%s: closing connection: %v", s.name, err)
+		}
+	}
+	if fn == nil {
+		return
+	}
+	go func() {
+		for attempt := 0; ; attempt++ {
+			s.postEvent(Reconnecting{Server: s.name, Attempt: attempt + 1})
+			svc, err := fn(context.Background(), s.name)
+			if err == nil {
+				s.postEvent(Connected{Server: s.name, Services: svc})
+				return
+			}
+			delay := reconnectDelay(base, attempt)
+			s.postEvent(ReconnectFailed{Server: s.name, Err: err, RetryIn: delay})
+			select {
+			case <-time.After(delay):
+			case <-s.retryNow:
+			}
+		}
+	}()
+}
+
+// reconnectDelay returns base*2^attempt, capped at base*32. A zero base
+// means one second.
+func reconnectDelay(base time.Duration, attempt int) time.Duration {
+	if base == 0 {
+		base = time.Second
+	}
+	return base * time.Duration(1<<min(attempt, 5))
+}
+
+// retry cuts the wait before the next reconnect attempt short.
+func (s *session) retry() {
+	select {
+	case s.retryNow <- struct{}{}:
+	default:
+	}
+}
+
+// reconnected swaps in views backed by the new services. The old views stay
+// on screen, tab by tab, until the new ones have loaded.
+func (s *session) reconnected(svc *internal.Services) {
+	old := s.viewSet
+	s.previous = &old
+	s.lost = nil
+	s.attempt = 0
+	s.retryAt = time.Time{}
+	s.initServices(svc)
+}
+
+// offline reports whether the connection is lost and no reconnect attempt
+// is running.
+func (s *session) offline() bool {
+	return s.lost != nil && !s.retryAt.IsZero()
+}
+
+// LoadAll loads data for all views in parallel using goroutines.
+// Each view posts a ViewLoaded event when done.
+func (s *session) LoadAll(ctx context.Context) {
+	if !s.connected {
+		return
+	}
+	s.loaded = true
+	for tab := 0; tab < tabCount; tab++ {
+		go func(t int) {
+			err := s.loadTab(ctx, t)
+			s.postEvent(views.ViewLoaded{Tab: t, Err: err, Server: s.name})
+		}(tab)
+	}
+}
+
+// loadTab fetches data for the view at the given tab index.
+func (s *session) loadTab(ctx context.Context, tab int) error {
+	switch tab {
+	case tabDashboard:
+		return s.dashboard.Load(ctx)
+	case tabPools:
+		return s.pools.Load(ctx)
+	case tabDatasets:
+		return s.datasets.Load(ctx)
+	case tabSnapshots:
+		return s.snapshots.Load(ctx)
+	case tabAlerts:
+		return s.alerts.Load(ctx)
+	case tabDisks:
+		return s.disks.Load(ctx)
+	}
+	return nil
+}
+
+// loadTabAsync loads the given tab's data in a background goroutine.
+func (s *session) loadTabAsync(tab int) {
+	go func() {
+		err := s.loadTab(context.Background(), tab)
+		s.postEvent(views.ViewLoaded{Tab: tab, Err: err, Server: s.name})
+	}()
+}
+
+// tabOf returns the tab index showing the given view, or -1.
+func (s *session) tabOf(v vxfw.Widget) int {
+	if v == nil {
+		return -1
+	}
+	switch v {
+	case s.dashboard:
+		return tabDashboard
+	case s.pools:
+		return tabPools
+	case s.datasets:
+		return tabDatasets
+	case s.snapshots:
+		return tabSnapshots
+	case s.alerts:
+		return tabAlerts
+	case s.disks:
+		return tabDisks
+	}
+	return -1
+}
+
+func (s *session) activeView() vxfw.Widget {
+	if !s.connected {
+		return nil
+	}
+	v := s.viewSet.view(s.tabBar.Active())
+	if s.previous != nil && !v.Loaded() {
+		return s.previous.view(s.tabBar.Active())
+	}
+	return v
+}
+
+// tabView is implemented by the view of every tab.
+type tabView interface {
+	vxfw.Widget
+	Loaded() bool
+	SetLoadError(err error)
+}
+
+// view returns the view at the given tab index.
+func (vs *viewSet) view(tab int) tabView {
+	switch tab {
+	case tabDashboard:
+		return vs.dashboard
+	case tabPools:
+		return vs.pools
+	case tabDatasets:
+		return vs.datasets
+	case tabSnapshots:
+		return vs.snapshots
+	case tabAlerts:
+		return vs.alerts
+	case tabDisks:
+		return vs.disks
+	default:
+		return vs.dashboard
+	}
+}
+
+// refetchIfStale reloads the active view's data in the background if stale.
+func (s *session) refetchIfStale() {
+	if !s.connected {
+		return
+	}
+	var stale bool
+	switch s.tabBar.Active() {
+	case tabDashboard:
+		// Dashboard is streaming, never stale
+		return
+	case tabPools:
+		stale = s.pools.Stale()
+	case tabDatasets:
+		stale = s.datasets.Stale()
+	case tabSnapshots:
+		stale = s.snapshots.Stale()
+	case tabAlerts:
+		stale = s.alerts.Stale()
+	case tabDisks:
+		stale = s.disks.Stale()
+	}
+	if stale {
+		s.loadTabAsync(s.tabBar.Active())
+	}
+}
+
+// syncAlertBadge updates the unread count on the Alerts tab. Alerts count as
+// read once the Alerts tab has been shown with them loaded.
+func (s *session) syncAlertBadge() {
+	if s.alerts == nil {
+		return
+	}
+	if s.tabBar.Active() == tabAlerts {
+		s.alerts.MarkRead()
+	}
+	s.tabBar.SetBadge(tabAlerts, s.alerts.Unread())
+}
+
+// dropPrevious forgets the views from before a reconnect once every new
+// view has loaded.
+func (s *session) dropPrevious() {
+	if s.previous == nil {
+		return
+	}
+	for tab := 0; tab < tabCount; tab++ {
+		if !s.viewSet.view(tab).Loaded() {
+			return
+		}
+	}
+	s.previous = nil
+}
+
+// handleEvent applies an event posted by this session's views or loaders.
+// handled is false for events it does not know.
+func (s *session) handleEvent(ev vaxis.Event) (cmd vxfw.Command, handled bool) {
+	switch ev := ev.(type) {
+	case views.ViewLoaded:
+		if ev.Err != nil {
+			logging.Errorf("%s: error loading tab %d: %v", s.name, ev.Tab, ev.Err)
+		}
+		if ev.Tab >= 0 && ev.Tab < tabCount && s.dashboard != nil {
+			s.view(ev.Tab).SetLoadError(ev.Err)
+			// The previous connection's view is shown until this one loads
+			if s.previous != nil {
+				s.previous.view(ev.Tab).SetLoadError(ev.Err)
+			}
+			s.tabBar.SetError(ev.Tab, ev.Err != nil)
+		}
+		// Start dashboard subscriptions once it has loaded
+		if ev.Tab == tabDashboard && ev.Err == nil && s.dashboard != nil {
+			s.dashboard.StartSubscriptions(context.Background())
+		}
+		// Watch for alert changes once the initial list is in
+		if ev.Tab == tabAlerts && ev.Err == nil && s.alerts != nil {
+			s.alerts.StartSubscription(context.Background())
+			s.syncAlertBadge()
+		}
+		s.dropPrevious()
+		return vxfw.RedrawCmd{}, true
+	case views.DashboardUpdated:
+		return vxfw.RedrawCmd{}, true
+	case views.AlertsChanged:
+		if s.connected {
+			s.loadTabAsync(tabAlerts)
+		}
+		return nil, true
+	case views.PoolDetailLoaded:
+		// A late poll by the views from before a reconnect is stale.
+		if s.pools == nil || (ev.View != nil && ev.View != s.pools) {
+			return nil, true
+		}
+		s.pools.DetailLoaded(ev)
+		return vxfw.RedrawCmd{}, true
+	case views.DiskDetailLoaded:
+		if s.disks == nil || (ev.View != nil && ev.View != s.disks) {
+			return nil, true
+		}
+		s.disks.DetailLoaded(ev)
+		return vxfw.RedrawCmd{}, true
+	case views.ActionCompleted:
+		if ev.Err != nil {
+			logging.Errorf("%s: action failed: %v", s.name, ev.Err)
+		}
+		ev.View.ActionDone(ev)
+		if tab := s.tabOf(ev.View); tab >= 0 && s.connected {
+			s.loadTabAsync(tab)
+			// Storage actions can change what the other storage lists show
+			// too (a clone adds a dataset, a rollback changes usage), so
+			// refresh them all.
+			if tab >= tabPools && tab <= tabSnapshots {
+				for t := tabPools; t <= tabSnapshots; t++ {
+					if t != tab {
+						s.loadTabAsync(t)
+					}
+				}
+			}
+		}
+		return vxfw.RedrawCmd{}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,582 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/app"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/views"
+)
+
+// multiServer is a Connect callback backed by test services. It records
+// which servers were dialled and forwards Connected/ConnectFailed events.
+type multiServer struct {
+	mu        sync.Mutex
+	dialled   []string
+	fail      map[string]error // servers that refuse to connect
+	connected chan vaxis.Event
+}
+
+func newMultiServer() *multiServer {
+	return &multiServer{connected: make(chan vaxis.Event, 8)}
+}
+
+func (m *multiServer) connect(ctx context.Context, server string) (*internal.Services, error) {
+	m.mu.Lock()
+	m.dialled = append(m.dialled, server)
+	fail := m.fail[server]
+	m.mu.Unlock()
+	if fail != nil {
+		return nil, fail
+	}
+	svc := newTestServices()
+	svc.Reporting = &truenas.MockReportingService{SubscribeRealtimeFunc: blockingSub[truenas.RealtimeUpdate]}
+	return svc, nil
+}
+
+// blockingSub is a subscription that stays open, silent, until ctx is done.
+func blockingSub[T any](ctx context.Context) (*truenas.Subscription[T], error) {
+	ch := make(chan T)
+	go func() { <-ctx.Done(); close(ch) }()
+	return truenas.NewSubscription((<-chan T)(ch), func() {}), nil
+}
+
+func (m *multiServer) post(ev vaxis.Event) {
+	switch ev.(type) {
+	case app.Connected, app.ConnectFailed:
+		m.connected <- ev
+	}
+}
+
+func (m *multiServer) dials() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.dialled...)
+}
+
+// newMultiApp creates an App over the given servers and wires it to m.
+func newMultiApp(m *multiServer, serverName string, servers ...string) *app.App {
+	a := app.New(app.Params{
+		ServerName: serverName,
+		Servers:    servers,
+		StaleTTL:   testStaleTTL,
+		Connect:    m.connect,
+	})
+	a.SetPostEvent(m.post)
+	return a
+}
+
+// deliverConnect waits for the next connection result and hands it to a.
+func deliverConnect(t *testing.T, a *app.App, m *multiServer) {
+	t.Helper()
+	select {
+	case ev := <-m.connected:
+		if _, err := a.HandleEvent(ev, vxfw.EventPhase(0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for connection")
+	}
+}
+
+// screenText draws the app and returns every row of the screen.
+func screenText(t *testing.T, a *app.App, w, h uint16) []string {
+	t.Helper()
+	s, err := a.Draw(testDrawContext(w, h))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	grid := make([][]string, h)
+	for i := range grid {
+		grid[i] = make([]string, w)
+	}
+	var paint func(s vxfw.Surface, col, row int)
+	paint = func(s vxfw.Surface, col, row int) {
+		for i, c := range s.Buffer {
+			r, cc := row+i/int(s.Size.Width), col+i%int(s.Size.Width)
+			if c.Grapheme != "" && r < int(h) && cc < int(w) {
+				grid[r][cc] = c.Grapheme
+			}
+		}
+		for _, ch := range s.Children {
+			paint(ch.Surface, col+int(ch.Origin.Col), row+int(ch.Origin.Row))
+		}
+	}
+	paint(s, 0, 0)
+	rows := make([]string, h)
+	for i, cells := range grid {
+		var b strings.Builder
+		for _, g := range cells {
+			if g == "" {
+				g = " "
+			}
+			b.WriteString(g)
+		}
+		rows[i] = b.String()
+	}
+	return rows
+}
+
+func key(r rune, mods ...vaxis.ModifierMask) vaxis.Key {
+	k := vaxis.Key{Keycode: r}
+	for _, m := range mods {
+		k.Modifiers |= m
+	}
+	return k
+}
+
+func press(t *testing.T, a *app.App, k vaxis.Key) vxfw.Command {
+	t.Helper()
+	cmd, err := a.CaptureEvent(k)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cmd
+}
+
+func TestApp_New_SingleServerSelected(t *testing.T) {
+	a := app.New(app.Params{Servers: []string{"home"}, StaleTTL: testStaleTTL})
+	if a.ServerName() != "home" {
+		t.Errorf("expected home, got %q", a.ServerName())
+	}
+}
+
+func TestApp_Init_OpensServerPicker(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "", "home", "office")
+
+	cmd, err := a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd, got %T", cmd)
+	}
+	screen := strings.Join(screenText(t, a, 80, 20), "\n")
+	for _, want := range []string{"Server", "home", "office"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected %q on screen:\n%s", want, screen)
+		}
+	}
+	if len(m.dials()) != 0 {
+		t.Errorf("expected no connection before a server is picked, got %v", m.dials())
+	}
+
+	press(t, a, key('j'))
+	press(t, a, key(vaxis.KeyEnter))
+	if a.ServerName() != "office" {
+		t.Errorf("expected office, got %q", a.ServerName())
+	}
+	deliverConnect(t, a, m)
+	if !a.IsConnected() {
+		t.Error("expected connected after picking a server")
+	}
+	if got := m.dials(); len(got) != 1 || got[0] != "office" {
+		t.Errorf("expected to dial office, got %v", got)
+	}
+}
+
+func TestApp_Init_PickerCancelQuits(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "", "home", "office")
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+
+	if _, ok := press(t, a, key(vaxis.KeyEsc)).(vxfw.QuitCmd); !ok {
+		t.Error("expected QuitCmd when cancelling the picker with no server chosen")
+	}
+}
+
+func TestApp_SwitchServer_KeepsSessions(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "home", "home", "office")
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	deliverConnect(t, a, m)
+	a.SetTab(2)
+
+	// Ctrl+S opens the picker; pick the second server.
+	press(t, a, key('s', vaxis.ModCtrl))
+	screen := strings.Join(screenText(t, a, 80, 20), "\n")
+	if !strings.Contains(screen, "connected") {
+		t.Errorf("expected home marked connected in picker:\n%s", screen)
+	}
+	press(t, a, key('j'))
+	press(t, a, key(vaxis.KeyEnter))
+	if a.ServerName() != "office" || a.IsConnected() {
+		t.Fatalf("expected office connecting, got %q connected=%v", a.ServerName(), a.IsConnected())
+	}
+	deliverConnect(t, a, m)
+	if a.ActiveTab() != 0 {
+		t.Errorf("expected office to start on the dashboard, got tab %d", a.ActiveTab())
+	}
+
+	// Switching back is instant and keeps the tab.
+	a.SwitchServer("home")
+	if !a.IsConnected() {
+		t.Error("expected home to still be connected")
+	}
+	if a.ActiveTab() != 2 {
+		t.Errorf("expected home to keep tab 2, got %d", a.ActiveTab())
+	}
+	if got := m.dials(); len(got) != 2 {
+		t.Errorf("expected one dial per server, got %v", got)
+	}
+}
+
+func TestApp_ServerPicker_Cancel(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "home", "home", "office")
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	deliverConnect(t, a, m)
+
+	press(t, a, key('s', vaxis.ModCtrl))
+	cmd := press(t, a, key(vaxis.KeyEsc))
+	if _, ok := cmd.(vxfw.QuitCmd); ok {
+		t.Fatal("expected cancelling the picker not to quit")
+	}
+	if a.ServerName() != "home" {
+		t.Errorf("expected home, got %q", a.ServerName())
+	}
+	// q quits again once the picker is closed.
+	if _, ok := press(t, a, key('q')).(vxfw.QuitCmd); !ok {
+		t.Error("expected q to quit after the picker closed")
+	}
+}
+
+func TestApp_HandleEvent_BackgroundSession(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "home", "home", "office")
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	deliverConnect(t, a, m)
+	a.SwitchServer("office")
+	deliverConnect(t, a, m)
+
+	cmd, err := a.HandleEvent(views.ViewLoaded{Tab: 1, Server: "home"}, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd != nil {
+		t.Errorf("expected no redraw for a background server, got %T", cmd)
+	}
+
+	cmd, _ = a.HandleEvent(views.ViewLoaded{Tab: 1, Server: "office"}, vxfw.EventPhase(0))
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd for the server on screen, got %T", cmd)
+	}
+}
+
+func TestApp_ConnectFailed_Retry(t *testing.T) {
+	m := newMultiServer()
+	fail := true
+	a := app.New(app.Params{
+		ServerName: "home",
+		Servers:    []string{"home", "office"},
+		StaleTTL:   testStaleTTL,
+		Connect: func(ctx context.Context, server string) (*internal.Services, error) {
+			if fail {
+				return nil, context.DeadlineExceeded
+			}
+			return m.connect(ctx, server)
+		},
+	})
+	a.SetPostEvent(m.post)
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	deliverConnect(t, a, m)
+
+	screen := screenText(t, a, 120, 5)[0]
+	if !strings.Contains(screen, "r to retry, Ctrl+S for another server") {
+		t.Errorf("expected retry hint, got %q", screen)
+	}
+
+	fail = false
+	press(t, a, key('r'))
+	deliverConnect(t, a, m)
+	if !a.IsConnected() {
+		t.Error("expected connected after retry")
+	}
+}
+
+func TestApp_Draw_ServerName(t *testing.T) {
+	a := app.New(app.Params{Services: newTestServices(), ServerName: "home", StaleTTL: testStaleTTL})
+	row := screenText(t, a, 120, 10)[0]
+	if !strings.HasSuffix(strings.TrimRight(row, " "), "● home connected") {
+		t.Errorf("expected server name and state at the end of the tab bar row, got %q", row)
+	}
+}
+
+func TestApp_Fleet_ConnectsEveryServer(t *testing.T) {
+	m := newMultiServer()
+	m.fail = map[string]error{"office": errors.New("connection refused")}
+	a := app.New(app.Params{
+		Servers:  []string{"home", "office"},
+		Fleet:    true,
+		StaleTTL: testStaleTTL,
+		Connect:  m.connect,
+	})
+	a.SetPostEvent(m.post)
+
+	cmd, err := a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := cmd.(vxfw.RedrawCmd); !ok {
+		t.Errorf("expected RedrawCmd, got %T", cmd)
+	}
+	if !a.FleetShown() {
+		t.Fatal("expected the fleet overview to be shown")
+	}
+	deliverConnect(t, a, m)
+	deliverConnect(t, a, m)
+	defer a.Fleet().Stop()
+
+	if got := a.Fleet().State("home"); got != views.FleetUp {
+		t.Errorf("expected home up, got %q", got)
+	}
+	if got := a.Fleet().State("office"); got != views.FleetDown {
+		t.Errorf("expected office down, got %q", got)
+	}
+	screen := strings.Join(screenText(t, a, 120, 10), "\n")
+	if !strings.Contains(screen, "connection refused") {
+		t.Errorf("expected the connection error on screen:\n%s", screen)
+	}
+
+	// Enter drills into the selected server's tabs.
+	if _, err := a.HandleEvent(key(vaxis.KeyEnter), vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if a.FleetShown() || a.ServerName() != "home" || !a.IsConnected() {
+		t.Errorf("expected home's tabs, got fleet=%v server=%q connected=%v", a.FleetShown(), a.ServerName(), a.IsConnected())
+	}
+}
+
+func TestApp_Fleet_Toggle(t *testing.T) {
+	m := newMultiServer()
+	a := newMultiApp(m, "home", "home", "office")
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	deliverConnect(t, a, m)
+
+	press(t, a, key('f', vaxis.ModCtrl))
+	if !a.FleetShown() {
+		t.Fatal("expected Ctrl+F to open the fleet overview")
+	}
+	deliverConnect(t, a, m)
+	defer a.Fleet().Stop()
+	if got := m.dials(); len(got) != 2 {
+		t.Errorf("expected the fleet to connect the other server once, got %v", got)
+	}
+	if got := a.Fleet().State("home"); got != views.FleetUp {
+		t.Errorf("expected home to reuse its connection, got %q", got)
+	}
+
+	press(t, a, key('f', vaxis.ModCtrl))
+	if a.FleetShown() || a.ServerName() != "home" {
+		t.Errorf("expected Ctrl+F to return to home, got fleet=%v server=%q", a.FleetShown(), a.ServerName())
+	}
+}
+
+// flakyServer is a Connect callback whose connections can be made to fail
+// their health check, and whose next dials can be made to fail.
+type flakyServer struct {
+	mu       sync.Mutex
+	dials    int
+	down     bool               // health checks fail
+	failNext int                // dials to refuse
+	scan     *internal.PoolScan // scan running on pool 1, if any
+	events   chan vaxis.Event
+}
+
+func newFlakyServer() *flakyServer {
+	return &flakyServer{events: make(chan vaxis.Event, 64)}
+}
+
+func (f *flakyServer) connect(ctx context.Context, server string) (*internal.Services, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dials++
+	if f.failNext > 0 {
+		f.failNext--
+		return nil, errors.New("connection refused")
+	}
+	svc := newTestServicesWithData()
+	svc.Reporting = &truenas.MockReportingService{SubscribeRealtimeFunc: blockingSub[truenas.RealtimeUpdate]}
+	svc.Apps = &truenas.MockAppService{SubscribeStatsFunc: blockingSub[[]truenas.AppStats]}
+	svc.Alerts = &internal.MockAlertService{SubscribeFunc: blockingSub[struct{}]}
+	if scan := f.scan; scan != nil {
+		detail := internal.PoolDetail{Pool: truenas.Pool{ID: 1, Name: "tank"}, Scan: scan}
+		svc.Pools = &internal.MockPoolService{
+			ListPoolsFunc: func(ctx context.Context) ([]internal.PoolDetail, error) {
+				return []internal.PoolDetail{detail}, nil
+			},
+			GetPoolFunc: func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+				return &detail, nil
+			},
+		}
+	}
+	svc.Conn = &internal.MockConnService{
+		PingFunc: func(ctx context.Context) error {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			if f.down {
+				return errors.New("websocket: close 1006")
+			}
+			return nil
+		},
+	}
+	return svc, nil
+}
+
+func (f *flakyServer) set(fn func(f *flakyServer)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(f)
+}
+
+// pump hands posted events to a until done reports true.
+func (f *flakyServer) pump(t *testing.T, a *app.App, done func() bool) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for !done() {
+		select {
+		case ev := <-f.events:
+			if _, err := a.HandleEvent(ev, vxfw.EventPhase(0)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for events")
+		}
+	}
+}
+
+func screenContains(t *testing.T, a *app.App, want string) func() bool {
+	return func() bool {
+		return strings.Contains(strings.Join(screenText(t, a, 120, 10), "\n"), want)
+	}
+}
+
+func TestApp_Reconnect(t *testing.T) {
+	f := newFlakyServer()
+	a := app.New(app.Params{
+		ServerName:     "home",
+		StaleTTL:       testStaleTTL,
+		HealthInterval: 5 * time.Millisecond,
+		ReconnectDelay: 5 * time.Millisecond,
+		Connect:        f.connect,
+	})
+	a.SetPostEvent(func(ev vaxis.Event) { f.events <- ev })
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	a.SetTab(1)
+	f.pump(t, a, screenContains(t, a, "tank"))
+
+	// The server stops answering and refuses the first reconnect.
+	f.set(func(f *flakyServer) { f.down = true; f.failNext = 1 })
+	f.pump(t, a, screenContains(t, a, "offline, retrying at"))
+	screen := strings.Join(screenText(t, a, 120, 10), "\n")
+	for _, want := range []string{"tank", "Showing data from", "connection refused"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("expected %q while offline:\n%s", want, screen)
+		}
+	}
+	if !a.IsConnected() {
+		t.Error("expected the views to stay up during the outage")
+	}
+
+	// The next attempt succeeds and the data is reloaded.
+	f.set(func(f *flakyServer) { f.down = false })
+	f.pump(t, a, screenContains(t, a, "● home connected"))
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !screenContains(t, a, "tank")() {
+		t.Error("expected the pools to be shown after reconnecting")
+	}
+	if screenContains(t, a, "Showing data from")() {
+		t.Error("expected the stale banner to be gone")
+	}
+	f.set(func(f *flakyServer) {
+		if f.dials != 3 {
+			t.Errorf("expected 3 dials (connect, refused, reconnect), got %d", f.dials)
+		}
+	})
+}
+
+func TestApp_Reconnect_RetryNow(t *testing.T) {
+	f := newFlakyServer()
+	a := app.New(app.Params{
+		ServerName:     "home",
+		StaleTTL:       testStaleTTL,
+		HealthInterval: 5 * time.Millisecond,
+		ReconnectDelay: time.Hour,
+		Connect:        f.connect,
+	})
+	a.SetPostEvent(func(ev vaxis.Event) { f.events <- ev })
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	f.pump(t, a, a.IsConnected)
+
+	f.set(func(f *flakyServer) { f.down = true; f.failNext = 1 })
+	f.pump(t, a, screenContains(t, a, "offline"))
+
+	// r skips the hour-long wait.
+	f.set(func(f *flakyServer) { f.down = false })
+	press(t, a, key('r'))
+	f.pump(t, a, screenContains(t, a, "● home connected"))
+}
+
+func TestApp_Reconnect_DropsStaleDetail(t *testing.T) {
+	f := newFlakyServer()
+	f.scan = &internal.PoolScan{Function: internal.ScanFunctionScrub, State: internal.ScanStateScanning, Percentage: 42}
+	a := app.New(app.Params{
+		ServerName:     "home",
+		StaleTTL:       testStaleTTL,
+		HealthInterval: 5 * time.Millisecond,
+		ReconnectDelay: 5 * time.Millisecond,
+		Connect:        f.connect,
+	})
+	a.SetPostEvent(func(ev vaxis.Event) { f.events <- ev })
+	_, _ = a.HandleEvent(vxfw.Init{}, vxfw.EventPhase(0))
+	a.SetTab(1)
+
+	// Keep a scan poll from the first connection's pools view.
+	var stale views.PoolDetailLoaded
+	deadline := time.After(5 * time.Second)
+	for stale.View == nil {
+		select {
+		case ev := <-f.events:
+			if pd, ok := ev.(views.PoolDetailLoaded); ok {
+				stale = pd
+				continue
+			}
+			if _, err := a.HandleEvent(ev, vxfw.EventPhase(0)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-deadline:
+			t.Fatal("timed out waiting for a scan poll")
+		}
+	}
+
+	// Reconnect to a server where the scrub has finished.
+	f.set(func(f *flakyServer) { f.down = true; f.failNext = 1; f.scan = nil })
+	f.pump(t, a, screenContains(t, a, "offline"))
+	f.set(func(f *flakyServer) { f.down = false })
+	f.pump(t, a, screenContains(t, a, "● home connected"))
+	if err := a.LoadActiveView(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cmd, err := a.HandleEvent(stale, vxfw.EventPhase(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cmd != nil {
+		t.Errorf("expected the stale poll to be dropped, got %T", cmd)
+	}
+	if screenContains(t, a, "scrub")() {
+		t.Error("expected the new pools view not to show the old connection's scrub")
+	}
+}
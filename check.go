@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// Exit codes of truenas-tui config check.
+const (
+	exitOK            = 0
+	exitConfigInvalid = 1
+	exitUsage         = 2
+	exitUnreachable   = 3
+)
+
+// runConfigCheck implements truenas-tui config check: it validates the
+// config file, then connects to each server, or just the one named with
+// --server, over the API and SSH, reporting each step. It never prompts,
+// and returns the exit code:
+//
+//	0  the config is valid and every check passed
+//	1  the config is invalid
+//	2  usage error, e.g. an unknown server
+//	3  the config is valid but a connection check failed
+func runConfigCheck(args []string, configPath, server string) int {
+	flags := flag.NewFlagSet("config check", flag.ContinueOnError)
+	flags.StringVar(&configPath, "config", configPath, "path to config file")
+	flags.StringVar(&server, "server", server, "check only this server profile")
+	timeout := flags.Duration("timeout", 10*time.Second, "time limit for each connection step")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s config check [flags]\n\nFlags:\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	fmt.Printf("Config %s\n", configPath)
+	problems, err := config.Check(configPath)
+	if err != nil {
+		printStep("FAIL", "parse", "", err.Error())
+		return exitConfigInvalid
+	}
+	invalid := false
+	for _, p := range problems {
+		status := "warn"
+		if !p.Warning {
+			status, invalid = "FAIL", true
+		}
+		printStep(status, "schema", "", p.String())
+	}
+	if invalid {
+		return exitConfigInvalid
+	}
+	cfg, err := config.LoadFrom(configPath)
+	if err != nil {
+		printStep("FAIL", "secrets", "", err.Error())
+		return exitConfigInvalid
+	}
+	printStep("ok", "valid", "", "")
+
+	names := cfg.ServerNames()
+	if server != "" {
+		if _, ok := cfg.Servers[server]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: server %q not found in config\nAvailable: %v\n", server, names)
+			return exitUsage
+		}
+		names = []string{server}
+	}
+
+	code := exitOK
+	for _, name := range names {
+		c := &checker{timeout: *timeout}
+		c.checkAPI(name, cfg.Servers[name])
+		if cfg.Servers[name].SSH != nil {
+			c.checkSSH(name, cfg.Servers[name])
+		}
+		if c.failed {
+			code = exitUnreachable
+		}
+	}
+	return code
+}
+
+// printStep prints one line of the check report.
+func printStep(status, step, elapsed, detail string) {
+	line := fmt.Sprintf("  %-4s  %-12s %7s  %s", status, step, elapsed, detail)
+	fmt.Println(strings.TrimRight(line, " "))
+}
+
+// checker runs a server's connection checks in order. Once a step fails,
+// the rest of its section is skipped, since they depend on it.
+type checker struct {
+	timeout time.Duration
+	failed  bool
+	skip    bool
+}
+
+// section starts a new group of steps.
+func (c *checker) section(title string) {
+	fmt.Printf("\n%s\n", title)
+	c.skip = false
+}
+
+// step runs fn with the step time limit and prints how it went. fn returns
+// a detail to show when it passes.
+func (c *checker) step(name string, fn func(ctx context.Context) (string, error)) bool {
+	if c.skip {
+		printStep("skip", name, "", "")
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	start := time.Now()
+	detail, err := fn(ctx)
+	elapsed := time.Since(start).Round(time.Millisecond).String()
+	if err != nil {
+		printStep("FAIL", name, elapsed, err.Error())
+		c.failed, c.skip = true, true
+		return false
+	}
+	printStep("ok", name, elapsed, detail)
+	return true
+}
+
+// checkAPI checks the websocket API: DNS, TCP, TLS, logging in with the API
+// key and a call for the TrueNAS version.
+func (c *checker) checkAPI(name string, sc config.ServerConfig) {
+	port := sc.Port
+	if port == 0 {
+		port = 443
+	}
+	c.section(fmt.Sprintf("Server %s API (%s)", name, net.JoinHostPort(sc.Host, strconv.Itoa(port))))
+
+	conn := c.dial(sc.Host, port)
+	if conn != nil {
+		defer conn.Close()
+	}
+	c.step("TLS", func(ctx context.Context) (string, error) {
+		tc := tls.Client(conn, &tls.Config{ServerName: sc.Host, InsecureSkipVerify: sc.InsecureSkipVerify}) //nolint:gosec
+		if err := tc.HandshakeContext(ctx); err != nil {
+			var unknownAuthority x509.UnknownAuthorityError
+			var invalid x509.CertificateInvalidError
+			var hostname x509.HostnameError
+			if errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname) {
+				return "", fmt.Errorf("%v; set insecure_skip_verify = true if the server uses the self-signed certificate TrueNAS ships with", err)
+			}
+			return "", err
+		}
+		state := tc.ConnectionState()
+		detail := tls.VersionName(state.Version)
+		if sc.InsecureSkipVerify {
+			return detail + ", certificate not verified (insecure_skip_verify)", nil
+		}
+		return detail + ", certificate valid until " + state.PeerCertificates[0].NotAfter.Format(time.DateOnly), nil
+	})
+
+	var ws *client.WebSocketClient
+	var versionErr error
+	c.step("auth", func(ctx context.Context) (string, error) {
+		var err error
+		ws, err = client.NewWebSocketClient(client.WebSocketConfig{
+			Host:               sc.Host,
+			Port:               sc.Port,
+			Username:           sc.Username,
+			APIKey:             sc.APIKey,
+			InsecureSkipVerify: sc.InsecureSkipVerify,
+		})
+		if err != nil {
+			return "", err
+		}
+		// Connecting logs in and then asks for the version, so an
+		// unsupported version means the login worked.
+		err = ws.Connect(ctx)
+		if errors.Is(err, client.ErrUnsupportedVersion) {
+			versionErr, err = err, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return "as " + sc.Username, nil
+	})
+	if ws != nil {
+		defer ws.Close()
+	}
+	c.step("API version", func(ctx context.Context) (string, error) {
+		if versionErr != nil {
+			return "", versionErr
+		}
+		return truenas.NewSystemService(ws, ws.Version()).GetVersion(ctx)
+	})
+}
+
+// checkSSH checks the SSH fallback: DNS, TCP, the host key, the private key
+// and logging in, which runs midclt for the TrueNAS version.
+func (c *checker) checkSSH(name string, sc config.ServerConfig) {
+	host := sshHost(sc)
+	c.section(fmt.Sprintf("Server %s SSH (%s)", name, net.JoinHostPort(host, strconv.Itoa(sc.SSH.Port))))
+
+	if conn := c.dial(host, sc.SSH.Port); conn != nil {
+		conn.Close()
+	}
+	var fingerprint string
+	c.step("host key", func(context.Context) (string, error) {
+		var err error
+		fingerprint, err = hostKeyFingerprint(name, sc.SSH, host)
+		if err != nil {
+			return "", err
+		}
+		if sc.SSH.HostKeyFingerprint != "" {
+			return fingerprint + " (host_key_fingerprint, checked on login)", nil
+		}
+		return fingerprint + " (" + sc.SSH.KnownHosts + ")", nil
+	})
+	var privateKey string
+	c.step("private key", func(context.Context) (string, error) {
+		var err error
+		privateKey, err = sshPrivateKey(name, sc.SSH)
+		if err != nil {
+			return "", err
+		}
+		signer, err := ssh.ParsePrivateKey([]byte(privateKey))
+		if err != nil {
+			return "", err
+		}
+		return sc.SSH.PrivateKeyPath + " (" + signer.PublicKey().Type() + ")", nil
+	})
+	c.step("auth", func(ctx context.Context) (string, error) {
+		sshClient, err := client.NewSSHClient(&client.SSHConfig{
+			Host:               host,
+			Port:               sc.SSH.Port,
+			User:               sc.SSH.Username,
+			PrivateKey:         privateKey,
+			HostKeyFingerprint: fingerprint,
+		})
+		if err != nil {
+			return "", err
+		}
+		defer sshClient.Close()
+		if err := sshClient.Connect(ctx); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("as %s, TrueNAS %s", sc.SSH.Username, sshClient.Version().Raw), nil
+	})
+}
+
+// dial runs the DNS and TCP steps and returns the connection, or nil if
+// either failed.
+func (c *checker) dial(host string, port int) net.Conn {
+	var addrs []string
+	c.step("DNS", func(ctx context.Context) (string, error) {
+		var err error
+		addrs, err = net.DefaultResolver.LookupHost(ctx, host)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(addrs, ", "), nil
+	})
+	var conn net.Conn
+	c.step("TCP", func(ctx context.Context) (string, error) {
+		addr := net.JoinHostPort(addrs[0], strconv.Itoa(port))
+		var err error
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		return addr, nil
+	})
+	return conn
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// runCheck runs truenas-tui config check on a config with the given
+// contents and returns the exit code and the report.
+func runCheck(t *testing.T, contents string, args ...string) (int, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	report := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		report <- string(data)
+	}()
+
+	code := runConfigCheck(args, path, "")
+	w.Close()
+	return code, <-report
+}
+
+// closedPort returns a local port nothing listens on.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestRunConfigCheck_Invalid(t *testing.T) {
+	code, report := runCheck(t, "[servers.home]\nhots = \"nas.local\"\nport = 443\nusername = \"admin\"\napi_key = \"1-abc\"\n")
+	if code != exitConfigInvalid {
+		t.Errorf("expected exit code %d, got %d", exitConfigInvalid, code)
+	}
+	for _, want := range []string{"FAIL  schema", "servers.home.hots: unknown setting", "servers.home.host: not set"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in the report, got\n%s", want, report)
+		}
+	}
+
+	if code, _ := runCheck(t, "[servers.home\n"); code != exitConfigInvalid {
+		t.Errorf("expected exit code %d for a file that doesn't parse, got %d", exitConfigInvalid, code)
+	}
+}
+
+func TestRunConfigCheck_Usage(t *testing.T) {
+	valid := "[servers.home]\nhost = \"127.0.0.1\"\nport = 443\nusername = \"admin\"\napi_key = \"1-abc\"\n"
+	if code, _ := runCheck(t, valid, "--server", "nope"); code != exitUsage {
+		t.Errorf("expected exit code %d for an unknown server, got %d", exitUsage, code)
+	}
+	if code, _ := runCheck(t, valid, "--timeout", "soon"); code != exitUsage {
+		t.Errorf("expected exit code %d for a bad flag, got %d", exitUsage, code)
+	}
+	if code, _ := runCheck(t, valid, "extra"); code != exitUsage {
+		t.Errorf("expected exit code %d for an extra argument, got %d", exitUsage, code)
+	}
+}
+
+func TestRunConfigCheck_Unreachable(t *testing.T) {
+	keyPath := writeKey(t, t.TempDir(), "")
+	config := "[servers.home]\nhost = \"127.0.0.1\"\nport = " + strconv.Itoa(closedPort(t)) + "\nusername = \"admin\"\napi_key = \"1-abc\"\n" +
+		"\n[servers.home.ssh]\nport = " + strconv.Itoa(closedPort(t)) + "\nprivate_key_path = \"" + keyPath + "\"\n"
+	code, report := runCheck(t, config, "--server", "home", "--timeout", "2s")
+	if code != exitUnreachable {
+		t.Errorf("expected exit code %d, got %d", exitUnreachable, code)
+	}
+	for _, want := range []string{"ok    valid", "Server home API", "Server home SSH", "ok    DNS", "FAIL  TCP", "skip  TLS", "skip  host key"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected %q in the report, got\n%s", want, report)
+		}
+	}
+}
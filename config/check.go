@@ -0,0 +1,164 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+	"golang.org/x/crypto/ssh"
+)
+
+// Problem is something wrong with a config file, found by Check.
+type Problem struct {
+	// Key is the setting or section at fault, e.g. servers.home.port.
+	Key     string
+	Message string
+	// Warning is set for problems truenas-tui works around, such as a
+	// missing port that defaults to 443.
+	Warning bool
+}
+
+func (p Problem) String() string {
+	return p.Key + ": " + p.Message
+}
+
+// Check validates the config file at path against the schema without
+// connecting to anything or running secret commands: unknown keys, missing
+// or out-of-range settings, secret and key files that can't be read, and
+// host key fingerprints that aren't SHA256 ones. The error is for a file
+// that can't be read or parsed at all.
+func Check(path string) ([]Problem, error) {
+	var cfg Config
+	md, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("loading config from %s: %w", path, err)
+	}
+
+	var problems []Problem
+	add := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(key, format string, args ...any) {
+		problems = append(problems, Problem{Key: key, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	for _, key := range md.Undecoded() {
+		add(key.String(), "unknown setting")
+	}
+	if len(cfg.Servers) == 0 {
+		add("servers", "no servers defined")
+	}
+
+	for _, name := range cfg.ServerNames() {
+		server := cfg.Servers[name]
+		prefix := toml.Key{"servers", name}.String() + "."
+		if server.Host == "" {
+			add(prefix+"host", "not set")
+		}
+		switch {
+		case server.Port == 0:
+			warn(prefix+"port", "not set; 443 is used")
+		case server.Port < 0 || server.Port > 65535:
+			add(prefix+"port", "%d is not a port number", server.Port)
+		}
+		if server.Username == "" {
+			add(prefix+"username", "not set")
+		}
+		checkSecret(server.apiKey(), prefix, add, true)
+
+		if server.SSH == nil {
+			continue
+		}
+		ssc := server.SSH
+		prefix += "ssh."
+		if ssc.Port < 0 || ssc.Port > 65535 {
+			add(prefix+"port", "%d is not a port number", ssc.Port)
+		}
+		if ssc.Username == "" && server.Username == "" {
+			add(prefix+"username", "not set, and the server has no username to default to")
+		}
+		checkSecret(ssc.passphrase(), prefix, add, false)
+		checkPrivateKey(ssc, prefix, add, warn)
+		if ssc.HostKeyFingerprint != "" && !validFingerprint(ssc.HostKeyFingerprint) {
+			add(prefix+"host_key_fingerprint", "%q is not a SHA256 fingerprint such as ssh-keygen -l prints (SHA256: and 43 base64 characters)", ssc.HostKeyFingerprint)
+		}
+	}
+
+	remap := make(map[string][]string, len(cfg.Keys))
+	for action, kl := range cfg.Keys {
+		remap[action] = kl
+	}
+	if _, err := keys.Resolve(remap); err != nil {
+		add("keys", "%v", err)
+	}
+	if _, err := theme.Resolve(cfg.Theme.Name, cfg.Theme.overrides()); err != nil {
+		add("theme", "%v", err)
+	}
+	return problems, nil
+}
+
+// checkSecret checks a secret's sources without running its command.
+// Required secrets must have a source.
+func checkSecret(s secret, prefix string, add func(key, format string, args ...any), required bool) {
+	if s.env != "" && os.Getenv(s.env) == "" && s.command == "" && s.file == "" && s.value == "" {
+		add(prefix+s.name+"_env", "$%s is not set", s.env)
+	}
+	if s.file != "" {
+		if err := readable(expandPath(s.file)); err != nil {
+			add(prefix+s.name+"_file", "%v", err)
+		}
+	}
+	if required && s.env == "" && s.command == "" && s.file == "" && s.value == "" {
+		add(prefix+s.name, "not set, and neither is %[1]s_file, %[1]s_command or %[1]s_env", s.name)
+	}
+}
+
+// checkPrivateKey checks that the SSH private key can be read and parsed.
+func checkPrivateKey(ssc *SSHConfig, prefix string, add, warn func(key, format string, args ...any)) {
+	key := prefix + "private_key_path"
+	if ssc.PrivateKeyPath == "" {
+		add(key, "not set")
+		return
+	}
+	path := expandPath(ssc.PrivateKeyPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		add(key, "%v", err)
+		return
+	}
+	_, err = ssh.ParseRawPrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case errors.As(err, &missing):
+		if s := ssc.passphrase(); s.value == "" && s.file == "" && s.command == "" && s.env == "" {
+			warn(key, "%s is passphrase-protected and no passphrase is configured; truenas-tui asks for it when started from a terminal", path)
+		}
+	case err != nil:
+		add(key, "%s: %v", path, err)
+	}
+}
+
+// readable reports whether the file at path can be opened for reading.
+func readable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// validFingerprint reports whether s looks like an SSH SHA256 fingerprint,
+// e.g. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s.
+func validFingerprint(s string) bool {
+	b64, ok := strings.CutPrefix(s, "SHA256:")
+	if !ok {
+		return false
+	}
+	sum, err := base64.RawStdEncoding.DecodeString(b64)
+	return err == nil && len(sum) == 32
+}
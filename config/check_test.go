@@ -0,0 +1,120 @@
+package config_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestKey writes an unencrypted ed25519 private key and returns its
+// path and a fingerprint in the format ssh-keygen prints.
+func writeTestKey(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, ssh.FingerprintSHA256(sshPub)
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	keyPath, fingerprint := writeTestKey(t, dir)
+	notAKey := filepath.Join(dir, "not-a-key")
+	if err := os.WriteFile(notAKey, []byte("hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	valid := `
+[servers.home]
+host = "truenas.local"
+port = 443
+username = "admin"
+api_key = "1-abc"
+
+[servers.home.ssh]
+private_key_path = "` + keyPath + `"
+host_key_fingerprint = "` + fingerprint + `"
+
+[keys]
+quit = ["q", "ctrl+q"]
+
+[theme]
+name = "default"
+`
+
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		warning bool
+	}{
+		{"valid", valid, nil, false},
+		{"unknown key", strings.Replace(valid, "port = 443", "port = 443\nhots = \"x\"", 1), []string{"servers.home.hots: unknown setting"}, false},
+		{"unknown ssh key", strings.Replace(valid, "[servers.home.ssh]", "[servers.home.ssh]\nprivate_key = \"x\"", 1), []string{"servers.home.ssh.private_key: unknown setting"}, false},
+		{"missing port", strings.Replace(valid, "port = 443\n", "", 1), []string{"servers.home.port: not set; 443 is used"}, true},
+		{"bad port", strings.Replace(valid, "port = 443", "port = 70000", 1), []string{"servers.home.port: 70000 is not a port number"}, false},
+		{"missing host", strings.Replace(valid, "host = \"truenas.local\"\n", "", 1), []string{"servers.home.host: not set"}, false},
+		{"missing api key", strings.Replace(valid, "api_key = \"1-abc\"\n", "", 1), []string{"servers.home.api_key: not set"}, false},
+		{"unreadable api key file", strings.Replace(valid, `api_key = "1-abc"`, `api_key_file = "`+filepath.Join(dir, "missing")+`"`, 1), []string{"servers.home.api_key_file: open"}, false},
+		{"unreadable private key", strings.Replace(valid, keyPath, filepath.Join(dir, "missing"), 1), []string{"servers.home.ssh.private_key_path: open"}, false},
+		{"not a private key", strings.Replace(valid, keyPath, notAKey, 1), []string{"servers.home.ssh.private_key_path: " + notAKey}, false},
+		{"bad fingerprint", strings.Replace(valid, fingerprint, "SHA256:abc123", 1), []string{`servers.home.ssh.host_key_fingerprint: "SHA256:abc123" is not a SHA256 fingerprint`}, false},
+		{"md5 fingerprint", strings.Replace(valid, fingerprint, "MD5:16:27:ac:a5:76:28:2d:36:63:1b:56:4d:eb:df:a6:48", 1), []string{"servers.home.ssh.host_key_fingerprint: "}, false},
+		{"unknown action", strings.Replace(valid, "quit =", "qiut =", 1), []string{"keys: "}, false},
+		{"unknown theme", strings.Replace(valid, `name = "default"`, `name = "nope"`, 1), []string{"theme: "}, false},
+		{"no servers", "log_file = \"/tmp/x\"\n", []string{"servers: no servers defined"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			problems, err := config.Check(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("expected %d problems, got %v", len(tt.want), problems)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(problems[i].String(), want) {
+					t.Errorf("expected a problem starting %q, got %q", want, problems[i])
+				}
+				if problems[i].Warning != tt.warning {
+					t.Errorf("expected warning=%v for %q", tt.warning, problems[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheck_Unparseable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[servers.home\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Check(path); err == nil {
+		t.Fatal("expected an error for a file that doesn't parse")
+	}
+}
@@ -8,30 +8,126 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 // Config is the top-level configuration.
 type Config struct {
+	// LogFile is where log lines are written while the UI runs. Empty
+	// keeps them in the in-app console only.
+	LogFile string                  `toml:"log_file"`
 	Servers map[string]ServerConfig `toml:"servers"`
+	// Keys remaps actions to keys, e.g. quit = "ctrl+q" or
+	// down = ["j", "ctrl+n"]. An empty list unbinds the action.
+	Keys map[string]KeyList `toml:"keys"`
+
+	// Theme picks a built-in theme and overrides its roles.
+	Theme ThemeConfig `toml:"theme"`
+
+	// Keymap is Keys parsed and checked for conflicts by LoadFrom.
+	Keymap keys.Keymap `toml:"-"`
+	// Colors is Theme resolved by LoadFrom.
+	Colors theme.Theme `toml:"-"`
+}
+
+// ThemeConfig picks a theme and overrides some of its roles with style
+// specs, e.g. critical = "bold #ff5555" or selection = "black on cyan".
+type ThemeConfig struct {
+	Name      string `toml:"name"`
+	OK        string `toml:"ok"`
+	Warning   string `toml:"warning"`
+	Critical  string `toml:"critical"`
+	Accent    string `toml:"accent"`
+	Dim       string `toml:"dim"`
+	Selection string `toml:"selection"`
+}
+
+// overrides returns the roles that are set, keyed by role name.
+func (tc ThemeConfig) overrides() map[string]string {
+	m := map[string]string{}
+	for role, spec := range map[string]string{
+		"ok": tc.OK, "warning": tc.Warning, "critical": tc.Critical,
+		"accent": tc.Accent, "dim": tc.Dim, "selection": tc.Selection,
+	} {
+		if spec != "" {
+			m[role] = spec
+		}
+	}
+	return m
+}
+
+// KeyList is the keys for one action: a single string or a list of them.
+type KeyList []string
+
+// UnmarshalTOML accepts a string or an array of strings.
+func (kl *KeyList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*kl = KeyList{v}
+	case []any:
+		list := make(KeyList, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected a key or a list of keys, got %v", item)
+			}
+			list = append(list, s)
+		}
+		*kl = list
+	default:
+		return fmt.Errorf("expected a key or a list of keys, got %v", v)
+	}
+	return nil
 }
 
 // ServerConfig holds connection details for one TrueNAS server.
 type ServerConfig struct {
-	Host               string     `toml:"host"`
-	Port               int        `toml:"port"`
-	Username           string     `toml:"username"`
+	Host     string `toml:"host"`
+	Port     int    `toml:"port"`
+	Username string `toml:"username"`
+	// APIKey is the key itself, or after LoadFrom the key read from
+	// whichever of APIKeyEnv, APIKeyCommand and APIKeyFile is set.
 	APIKey             string     `toml:"api_key"`
+	APIKeyFile         string     `toml:"api_key_file"`
+	APIKeyCommand      string     `toml:"api_key_command"`
+	APIKeyEnv          string     `toml:"api_key_env"`
 	InsecureSkipVerify bool       `toml:"insecure_skip_verify"`
 	SSH                *SSHConfig `toml:"ssh"`
 }
 
 // SSHConfig holds optional SSH connection details for filesystem operations.
 type SSHConfig struct {
-	Host               string `toml:"host"`
-	Port               int    `toml:"port"`
-	Username           string `toml:"username"`
-	PrivateKeyPath     string `toml:"private_key_path"`
+	Host           string `toml:"host"`
+	Port           int    `toml:"port"`
+	Username       string `toml:"username"`
+	PrivateKeyPath string `toml:"private_key_path"`
+	// Passphrase decrypts the private key. Like the API key it can come
+	// from a file, a command or an environment variable instead, and
+	// holds the resolved passphrase after LoadFrom.
+	Passphrase         string `toml:"passphrase"`
+	PassphraseFile     string `toml:"passphrase_file"`
+	PassphraseCommand  string `toml:"passphrase_command"`
+	PassphraseEnv      string `toml:"passphrase_env"`
 	HostKeyFingerprint string `toml:"host_key_fingerprint"`
+	// KnownHosts is the known_hosts file the server's host key is checked
+	// against when HostKeyFingerprint isn't set. Defaults to
+	// ~/.ssh/known_hosts.
+	KnownHosts string `toml:"known_hosts"`
+
+	// PassphraseFrom names where Passphrase came from, e.g.
+	// "passphrase_command", so errors can say which one was wrong.
+	PassphraseFrom string `toml:"-"`
+}
+
+// apiKey returns the sources of the server's API key.
+func (sc ServerConfig) apiKey() secret {
+	return secret{name: "api_key", value: sc.APIKey, file: sc.APIKeyFile, command: sc.APIKeyCommand, env: sc.APIKeyEnv}
+}
+
+// passphrase returns the sources of the private key's passphrase.
+func (sc SSHConfig) passphrase() secret {
+	return secret{name: "passphrase", value: sc.Passphrase, file: sc.PassphraseFile, command: sc.PassphraseCommand, env: sc.PassphraseEnv}
 }
 
 // DefaultPath returns the default config file path using XDG conventions.
@@ -44,7 +140,10 @@ func DefaultPath() string {
 }
 
 // LoadFrom reads and parses the config file at the given path.
-// It applies defaults for SSH config fields after parsing.
+// It applies defaults for SSH config fields after parsing, reads API keys
+// and passphrases from their sources, and resolves the key remappings and
+// the theme, failing on unreadable secrets, unknown actions, conflicting
+// keys or invalid colors.
 func LoadFrom(path string) (*Config, error) {
 	var cfg Config
 	if _, err := toml.DecodeFile(path, &cfg); err != nil {
@@ -53,8 +152,19 @@ func LoadFrom(path string) (*Config, error) {
 	if len(cfg.Servers) == 0 {
 		return nil, fmt.Errorf("config has no servers defined")
 	}
-	for name, server := range cfg.Servers {
+	for _, name := range cfg.ServerNames() {
+		server := cfg.Servers[name]
+		key, _, err := server.apiKey().resolve()
+		if err != nil {
+			return nil, fmt.Errorf("invalid [servers.%s] in %s: %w", name, path, err)
+		}
+		server.APIKey = key
 		if server.SSH != nil {
+			passphrase, from, err := server.SSH.passphrase().resolve()
+			if err != nil {
+				return nil, fmt.Errorf("invalid [servers.%s.ssh] in %s: %w", name, path, err)
+			}
+			server.SSH.Passphrase, server.SSH.PassphraseFrom = passphrase, from
 			if server.SSH.Port == 0 {
 				server.SSH.Port = 22
 			}
@@ -62,9 +172,30 @@ func LoadFrom(path string) (*Config, error) {
 				server.SSH.Username = server.Username
 			}
 			server.SSH.PrivateKeyPath = expandPath(server.SSH.PrivateKeyPath)
+			if server.SSH.KnownHosts == "" {
+				server.SSH.KnownHosts = "~/.ssh/known_hosts"
+			}
+			server.SSH.KnownHosts = expandPath(server.SSH.KnownHosts)
 		}
 		cfg.Servers[name] = server
 	}
+	cfg.LogFile = expandPath(cfg.LogFile)
+
+	remap := make(map[string][]string, len(cfg.Keys))
+	for action, kl := range cfg.Keys {
+		remap[action] = kl
+	}
+	keymap, err := keys.Resolve(remap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [keys] in %s:\n%w", path, err)
+	}
+	cfg.Keymap = keymap
+
+	colors, err := theme.Resolve(cfg.Theme.Name, cfg.Theme.overrides())
+	if err != nil {
+		return nil, fmt.Errorf("invalid [theme] in %s:\n%w", path, err)
+	}
+	cfg.Colors = colors
 	return &cfg, nil
 }
 
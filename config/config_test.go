@@ -3,9 +3,12 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"git.sr.ht/~rockorager/vaxis"
 	"github.com/deevus/truenas-tui/config"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 func TestLoad_ValidConfig(t *testing.T) {
@@ -129,6 +132,9 @@ host_key_fingerprint = "SHA256:abc123"
 	if ssh.Username != "admin" {
 		t.Errorf("expected ssh username to default to server username 'admin', got %s", ssh.Username)
 	}
+	if home, _ := os.UserHomeDir(); ssh.KnownHosts != filepath.Join(home, ".ssh", "known_hosts") {
+		t.Errorf("expected known_hosts to default to ~/.ssh/known_hosts, got %s", ssh.KnownHosts)
+	}
 }
 
 func TestLoad_ExpandTilde(t *testing.T) {
@@ -196,6 +202,33 @@ host_key_fingerprint = "SHA256:abc123"
 	}
 }
 
+func TestLoad_LogFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_LOG_DIR", "/var/log/truenas-tui")
+
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+log_file = "$TEST_LOG_DIR/tui.log"
+
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "/var/log/truenas-tui/tui.log"
+	if cfg.LogFile != expected {
+		t.Errorf("expected log file %s, got %s", expected, cfg.LogFile)
+	}
+}
+
 func TestLoad_MissingFile(t *testing.T) {
 	_, err := config.LoadFrom("/nonexistent/config.toml")
 	if err == nil {
@@ -257,3 +290,263 @@ func TestDefaultPath(t *testing.T) {
 		t.Fatal("expected non-empty default path")
 	}
 }
+
+func TestLoad_Keys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[keys]
+quit = "ctrl+q"
+down = ["j", "ctrl+n"]
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Keys["down"]) != 2 || cfg.Keys["quit"][0] != "ctrl+q" {
+		t.Errorf("unexpected keys: %v", cfg.Keys)
+	}
+	if len(cfg.Keymap["down"]) != 2 || cfg.Keymap["quit"][0].String() != "Ctrl+Q" {
+		t.Errorf("unexpected keymap: %v", cfg.Keymap)
+	}
+}
+
+func TestLoad_KeysInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[keys]
+launch = "l"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = config.LoadFrom(path)
+	if err == nil {
+		t.Fatal("expected error for invalid keys")
+	}
+	if !strings.Contains(err.Error(), `unknown action "launch"`) {
+		t.Errorf("expected the unknown action in the error, got: %v", err)
+	}
+}
+
+func TestLoad_KeysConflict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[keys]
+refresh = "x"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = config.LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), "x is bound to both refresh and start_scrub") {
+		t.Errorf("expected a conflict error, got: %v", err)
+	}
+}
+
+func TestLoad_Theme(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[theme]
+name = "light"
+critical = "bold #ff0000"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Colors.Name != "light" {
+		t.Errorf("expected the light theme, got %s", cfg.Colors.Name)
+	}
+	if cfg.Colors.Critical.Foreground != vaxis.HexColor(0xff0000) || cfg.Colors.Critical.Attribute != vaxis.AttrBold {
+		t.Errorf("expected critical overridden, got %+v", cfg.Colors.Critical)
+	}
+}
+
+func TestLoad_ThemeDefault(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Colors != theme.Default {
+		t.Errorf("expected the default theme, got %+v", cfg.Colors)
+	}
+}
+
+func TestLoad_ThemeInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[theme]
+name = "neon"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = config.LoadFrom(path)
+	if err == nil || !strings.Contains(err.Error(), `unknown theme "neon"`) {
+		t.Errorf("expected an unknown theme error, got: %v", err)
+	}
+}
+
+func TestLoad_APIKeySources(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("1-from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_TRUENAS_KEY", "1-from-env")
+	t.Setenv("TEST_TRUENAS_UNSET", "")
+
+	tests := []struct {
+		name   string
+		server string
+		want   string
+	}{
+		{"inline", `api_key = "1-inline"`, "1-inline"},
+		{"file", `api_key_file = "` + keyFile + `"`, "1-from-file"},
+		{"command", `api_key_command = "echo 1-from-command"`, "1-from-command"},
+		{"env", `api_key_env = "TEST_TRUENAS_KEY"`, "1-from-env"},
+		{"env first", "api_key_env = \"TEST_TRUENAS_KEY\"\napi_key_command = \"echo 1-from-command\"", "1-from-env"},
+		{"unset env falls through", "api_key_env = \"TEST_TRUENAS_UNSET\"\napi_key_command = \"echo 1-from-command\"", "1-from-command"},
+		{"command before file", "api_key_command = \"echo 1-from-command\"\napi_key_file = \"" + keyFile + "\"", "1-from-command"},
+		{"file before inline", "api_key_file = \"" + keyFile + "\"\napi_key = \"1-inline\"", "1-from-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte("[servers.home]\nhost = \"truenas.local\"\n"+tt.server+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.LoadFrom(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.Servers["home"].APIKey; got != tt.want {
+				t.Errorf("expected API key %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLoad_APIKeySourceErrors(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_TRUENAS_UNSET", "")
+
+	tests := []struct {
+		name   string
+		server string
+		want   string
+	}{
+		{"missing file", `api_key_file = "` + filepath.Join(dir, "missing") + `"`, "api_key_file: open"},
+		{"empty file", `api_key_file = "` + empty + `"`, "api_key_file " + empty + " is empty"},
+		{"failing command", `api_key_command = "echo 1-secret; exit 3"`, `api_key_command "echo 1-secret; exit 3": exited with status 3`},
+		{"silent command", `api_key_command = "true"`, `api_key_command "true" printed nothing`},
+		{"unset env", `api_key_env = "TEST_TRUENAS_UNSET"`, "api_key_env: $TEST_TRUENAS_UNSET is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte("[servers.home]\nhost = \"truenas.local\"\n"+tt.server+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := config.LoadFrom(path)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.Contains(err.Error(), "[servers.home]") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected the server and %q in the error, got: %v", tt.want, err)
+			}
+			if strings.Contains(strings.ReplaceAll(err.Error(), "echo 1-secret", ""), "1-secret") {
+				t.Errorf("expected the error not to leak the command's output, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad_SSHPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+api_key = "1-abc"
+
+[servers.home.ssh]
+private_key_path = "/home/test/.ssh/id_ed25519"
+passphrase_command = "echo hunter2"
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Servers["home"].SSH.Passphrase; got != "hunter2" {
+		t.Errorf("expected the passphrase from the command, got %q", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`
+[servers.home]
+host = "truenas.local"
+
+[servers.home.ssh]
+passphrase_file = "`+filepath.Join(dir, "missing")+`"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadFrom(path); err == nil || !strings.Contains(err.Error(), "[servers.home.ssh]") || !strings.Contains(err.Error(), "passphrase_file") {
+		t.Errorf("expected a passphrase_file error for the ssh section, got: %v", err)
+	}
+}
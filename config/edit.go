@@ -0,0 +1,180 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	tableHeader = regexp.MustCompile(`^\s*\[([^\[\]]+)\]\s*(#.*)?$`)
+	keyLine     = regexp.MustCompile(`^\s*([A-Za-z0-9_-]+)\s*=`)
+	bareKey     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// SetHostKeyFingerprint writes host_key_fingerprint into the
+// [servers.<server>.ssh] section of the config file at path, replacing the
+// value if there is one. The rest of the file, comments included, is left
+// as it is.
+func SetHostKeyFingerprint(path, server, fingerprint string) error {
+	return setValue(path, []string{"servers", server, "ssh"}, "host_key_fingerprint", strconv.Quote(fingerprint))
+}
+
+// setValue sets key to value, a TOML literal, in the table named by table.
+// The table must have its own [header] in the file.
+func setValue(path string, table []string, key, value string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+
+	start := -1
+	for i, line := range lines {
+		if m := tableHeader.FindStringSubmatch(line); m != nil && slices.Equal(splitKey(m[1]), table) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return fmt.Errorf("no [%s] section in %s", strings.Join(table, "."), path)
+	}
+
+	entry := key + " = " + value
+	insert := start + 1
+	for i := start + 1; i < len(lines) && !tableHeader.MatchString(lines[i]); i++ {
+		if m := keyLine.FindStringSubmatch(lines[i]); m != nil {
+			if m[1] == key {
+				lines[i] = entry
+				return os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+			}
+			insert = i + 1
+		}
+	}
+	lines = append(lines[:insert], append([]string{entry}, lines[insert:]...)...)
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")), info.Mode().Perm())
+}
+
+// splitKey splits a dotted TOML key such as servers."my nas".ssh into its
+// parts, unquoting quoted ones.
+func splitKey(s string) []string {
+	var parts []string
+	var part strings.Builder
+	quote := rune(0)
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		case r != ' ' && r != '\t':
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+// AppendServer adds a [servers.<name>] profile, and its [ssh] section if
+// it has one, to the end of the config file at path, creating the file with
+// 0600 permissions if it doesn't exist. The rest of an existing file,
+// comments included, is left as it is. It fails if the profile exists.
+func AppendServer(path, name string, sc ServerConfig) error {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		var existing Config
+		if _, err := toml.Decode(string(data), &existing); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		if _, ok := existing.Servers[name]; ok {
+			return fmt.Errorf("server %q already exists in %s", name, path)
+		}
+	}
+
+	var b strings.Builder
+	if len(data) > 0 {
+		if data[len(data)-1] != '\n' {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	writeServer(&b, name, sc)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeServer renders a server profile as TOML, leaving out settings that
+// aren't set.
+func writeServer(b *strings.Builder, name string, sc ServerConfig) {
+	if !bareKey.MatchString(name) {
+		name = strconv.Quote(name)
+	}
+	str := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(b, "%s = %s\n", key, strconv.Quote(value))
+		}
+	}
+	num := func(key string, value int) {
+		if value != 0 {
+			fmt.Fprintf(b, "%s = %d\n", key, value)
+		}
+	}
+
+	fmt.Fprintf(b, "[servers.%s]\n", name)
+	str("host", sc.Host)
+	num("port", sc.Port)
+	str("username", sc.Username)
+	str("api_key", sc.APIKey)
+	str("api_key_file", sc.APIKeyFile)
+	str("api_key_command", sc.APIKeyCommand)
+	str("api_key_env", sc.APIKeyEnv)
+	if sc.InsecureSkipVerify {
+		b.WriteString("insecure_skip_verify = true\n")
+	}
+
+	if sc.SSH == nil {
+		return
+	}
+	fmt.Fprintf(b, "\n[servers.%s.ssh]\n", name)
+	str("host", sc.SSH.Host)
+	num("port", sc.SSH.Port)
+	str("username", sc.SSH.Username)
+	str("private_key_path", sc.SSH.PrivateKeyPath)
+	str("passphrase", sc.SSH.Passphrase)
+	str("passphrase_file", sc.SSH.PassphraseFile)
+	str("passphrase_command", sc.SSH.PassphraseCommand)
+	str("passphrase_env", sc.SSH.PassphraseEnv)
+	str("host_key_fingerprint", sc.SSH.HostKeyFingerprint)
+	str("known_hosts", sc.SSH.KnownHosts)
+}
@@ -0,0 +1,146 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/deevus/truenas-tui/config"
+)
+
+func TestSetHostKeyFingerprint(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "adds the key after the section's last key",
+			in: `# my servers
+[servers.home]
+host = "truenas.local" # the NAS
+
+[servers.home.ssh]
+private_key_path = "~/.ssh/id_ed25519" # work key
+
+[servers.offsite.ssh]
+private_key_path = "~/.ssh/other"
+`,
+			want: `# my servers
+[servers.home]
+host = "truenas.local" # the NAS
+
+[servers.home.ssh]
+private_key_path = "~/.ssh/id_ed25519" # work key
+host_key_fingerprint = "SHA256:abc"
+
+[servers.offsite.ssh]
+private_key_path = "~/.ssh/other"
+`,
+		},
+		{
+			name: "replaces an existing value",
+			in: `[servers.home.ssh]
+host_key_fingerprint = "SHA256:old"
+port = 2222
+`,
+			want: `[servers.home.ssh]
+host_key_fingerprint = "SHA256:abc"
+port = 2222
+`,
+		},
+		{
+			name: "matches quoted names",
+			in: `[ servers."home" . ssh ]
+`,
+			want: `[ servers."home" . ssh ]
+host_key_fingerprint = "SHA256:abc"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.in), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := config.SetHostKeyFingerprint(path, "home", "SHA256:abc"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("unexpected config:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+				t.Errorf("expected permissions kept, got %v", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestSetHostKeyFingerprint_NoSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("[servers.home]\nssh = { port = 22 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.SetHostKeyFingerprint(path, "home", "SHA256:abc"); err == nil {
+		t.Error("expected an error without a [servers.home.ssh] section")
+	}
+}
+
+func TestAppendServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truenas-tui", "config.toml")
+	home := config.ServerConfig{Host: "truenas.local", Port: 443, Username: "admin", APIKey: "1-abc", InsecureSkipVerify: true}
+	if err := config.AppendServer(path, "home", home); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected a new file with 0600 permissions, got %v, %v", info, err)
+	}
+
+	// Appending keeps what's there
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("# the NAS in the basement")
+	f.Close()
+	offsite := config.ServerConfig{
+		Host:          "backup.example.com",
+		APIKeyCommand: `pass show "truenas/offsite"`,
+		SSH:           &config.SSHConfig{PrivateKeyPath: "~/.ssh/id_ed25519", HostKeyFingerprint: "SHA256:abc"},
+	}
+	if err := config.AppendServer(path, "off site", offsite); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# the NAS in the basement\n\n[servers.\"off site\"]\n") {
+		t.Errorf("expected the comment kept and the new profile after it, got:\n%s", data)
+	}
+
+	var got struct {
+		Servers map[string]config.ServerConfig `toml:"servers"`
+	}
+	if _, err := toml.DecodeFile(path, &got); err != nil {
+		t.Fatalf("expected valid TOML, got %v:\n%s", err, data)
+	}
+	if got.Servers["home"].APIKey != "1-abc" || !got.Servers["home"].InsecureSkipVerify {
+		t.Errorf("unexpected home profile %+v", got.Servers["home"])
+	}
+	o := got.Servers["off site"]
+	if o.APIKeyCommand != `pass show "truenas/offsite"` || o.SSH == nil || o.SSH.HostKeyFingerprint != "SHA256:abc" {
+		t.Errorf("unexpected offsite profile %+v", o)
+	}
+
+	if err := config.AppendServer(path, "home", home); err == nil || !strings.Contains(err.Error(), `server "home" already exists`) {
+		t.Errorf("expected an error for an existing profile, got %v", err)
+	}
+}
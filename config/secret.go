@@ -0,0 +1,89 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// secret is a value that can be written into the config or read from
+// somewhere else, so it doesn't have to be stored in plaintext. The first
+// source that is set wins:
+//
+//	<name>_env      an environment variable, when it is set and not empty
+//	<name>_command  a shell command, e.g. a password manager's CLI; its
+//	                trimmed stdout is the secret
+//	<name>_file     a file holding the secret
+//	<name>          the secret itself
+type secret struct {
+	name    string
+	value   string
+	file    string
+	command string
+	env     string
+}
+
+// resolve reads the secret and returns it with the setting it came from,
+// e.g. "api_key_command". An empty result with no error means no source is
+// configured. Errors name the source that failed but never include what it
+// returned.
+func (s secret) resolve() (value, source string, err error) {
+	if s.env != "" {
+		if v := strings.TrimSpace(os.Getenv(s.env)); v != "" {
+			return v, s.name + "_env", nil
+		}
+		if s.command == "" && s.file == "" && s.value == "" {
+			return "", "", fmt.Errorf("%s_env: $%s is not set", s.name, s.env)
+		}
+	}
+	switch {
+	case s.command != "":
+		out, err := runSecretCommand(s.command)
+		if err != nil {
+			return "", "", fmt.Errorf("%s_command %q: %w", s.name, s.command, err)
+		}
+		if out == "" {
+			return "", "", fmt.Errorf("%s_command %q printed nothing", s.name, s.command)
+		}
+		return out, s.name + "_command", nil
+	case s.file != "":
+		path := expandPath(s.file)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("%s_file: %w", s.name, err)
+		}
+		v := strings.TrimSpace(string(data))
+		if v == "" {
+			return "", "", fmt.Errorf("%s_file %s is empty", s.name, path)
+		}
+		return v, s.name + "_file", nil
+	case s.value != "":
+		return s.value, s.name, nil
+	}
+	return "", "", nil
+}
+
+// runSecretCommand runs command with the shell and returns its trimmed
+// stdout. Stdin and stderr are the terminal's, so a password manager can
+// prompt to unlock.
+func runSecretCommand(command string) (string, error) {
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.Command(shell, flag, command)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("exited with status %d", exitErr.ExitCode())
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
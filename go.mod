@@ -9,6 +9,7 @@ require (
 	github.com/dustin/go-humanize v1.0.1
 	golang.org/x/crypto v0.48.0
 	golang.org/x/sync v0.19.0
+	golang.org/x/term v0.40.0
 )
 
 require (
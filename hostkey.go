@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/term"
+)
+
+// unknownHostKeyError is returned for a host key that is neither pinned in
+// the config nor in known_hosts.
+type unknownHostKeyError struct {
+	server string
+	addr   string
+	key    ssh.PublicKey
+	// note explains a key of another type in known_hosts, if there is one.
+	note string
+}
+
+func (e *unknownHostKeyError) Error() string {
+	msg := fmt.Sprintf("SSH host key for %s (%s %s) isn't trusted yet", e.addr, e.key.Type(), ssh.FingerprintSHA256(e.key))
+	if e.note != "" {
+		msg += " (" + e.note + ")"
+	}
+	return msg + fmt.Sprintf(": run truenas-tui --server %[1]s from a terminal to confirm it, or set host_key_fingerprint in [servers.%[1]s.ssh]", e.server)
+}
+
+// hostKeyScanError is returned when the server's host key couldn't be
+// fetched, e.g. because it is offline.
+type hostKeyScanError struct {
+	addr string
+	err  error
+}
+
+func (e *hostKeyScanError) Error() string {
+	return fmt.Sprintf("SSH host key for %s: could not connect: %v", e.addr, e.err)
+}
+
+// hostKeyCheck is the outcome of looking up a server's host key: the
+// fingerprint the SSH client should expect, or why there isn't one.
+type hostKeyCheck struct {
+	fingerprint string
+	err         error
+}
+
+// hostKeyFingerprint returns the fingerprint the SSH client should expect
+// from the server. A pinned host_key_fingerprint is returned as it is, and
+// the SSH client checks the server's key against it when it connects.
+// Without one, the server's key is fetched and looked up in the known_hosts
+// file; a key that doesn't match is a hard failure that says what changed.
+func hostKeyFingerprint(serverName string, sc *config.SSHConfig, host string) (string, error) {
+	if sc.HostKeyFingerprint != "" {
+		return sc.HostKeyFingerprint, nil
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(sc.Port))
+	key, remote, err := scanHostKey(host, sc.Port)
+	if err != nil {
+		return "", &hostKeyScanError{addr: addr, err: err}
+	}
+	if err := checkKnownHosts(serverName, sc.KnownHosts, addr, remote, key); err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(key), nil
+}
+
+// checkKnownHosts looks the host key up in a known_hosts file.
+func checkKnownHosts(serverName, path, addr string, remote net.Addr, key ssh.PublicKey) error {
+	unknown := &unknownHostKeyError{server: serverName, addr: addr, key: key}
+	callback, err := knownhosts.New(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return unknown
+	}
+	if err != nil {
+		return fmt.Errorf("reading known_hosts: %v", err)
+	}
+
+	err = callback(addr, remote, key)
+	var keyErr *knownhosts.KeyError
+	var revoked *knownhosts.RevokedError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &revoked):
+		return fmt.Errorf("SSH host key for %s (%s %s) is revoked in %s:%d; refusing to connect",
+			addr, key.Type(), ssh.FingerprintSHA256(key), revoked.Revoked.Filename, revoked.Revoked.Line)
+	case !errors.As(err, &keyErr):
+		return fmt.Errorf("checking known_hosts: %v", err)
+	case len(keyErr.Want) == 0:
+		return unknown
+	}
+
+	for _, want := range keyErr.Want {
+		if want.Key.Type() == key.Type() {
+			return fmt.Errorf("SSH host key for %s has changed: %s:%d has %s, but the server offered %s. "+
+				"Someone could be intercepting the connection, or the server was reinstalled; if you expect the change, remove the old key with: ssh-keygen -R '%s' -f %s",
+				addr, want.Filename, want.Line, ssh.FingerprintSHA256(want.Key), ssh.FingerprintSHA256(key), knownhosts.Normalize(addr), want.Filename)
+		}
+	}
+	// Only keys of other types are known, e.g. RSA while the server now
+	// offers Ed25519. That isn't a change of key, so ask as for a new one.
+	unknown.note = fmt.Sprintf("%s has a %s key for it", path, keyErr.Want[0].Key.Type())
+	return unknown
+}
+
+// scanHostKey connects to an SSH server and returns its host key and the
+// address it connected to.
+func scanHostKey(host string, port int) (ssh.PublicKey, net.Addr, error) {
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	var hostKey ssh.PublicKey
+	var remoteAddr net.Addr
+	cfg := &ssh.ClientConfig{
+		User: "probe",
+		HostKeyCallback: func(_ string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey, remoteAddr = key, remote
+			return nil
+		},
+		Timeout: 5 * time.Second,
+	}
+	conn, err := ssh.Dial("tcp", addr, cfg)
+	if conn != nil {
+		conn.Close()
+	}
+	if hostKey != nil {
+		return hostKey, remoteAddr, nil
+	}
+	return nil, nil, err
+}
+
+// trustHostKeys asks on the terminal whether to trust the host key of each
+// named SSH server that is neither pinned nor in known_hosts, before the UI
+// takes the terminal over, and saves the keys accepted to the config or to
+// known_hosts. Servers that can't be reached are left for the connection to
+// report. A changed or revoked key is an error.
+//
+// It returns what it found for each server it looked up, so the key needn't
+// be fetched again to connect.
+func trustHostKeys(cfg *config.Config, configPath string, names []string) (map[string]hostKeyCheck, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, nil
+	}
+
+	// Fetch the keys concurrently so offline servers don't add up.
+	checks := make([]*hostKeyCheck, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		server := cfg.Servers[name]
+		if server.SSH == nil || server.SSH.HostKeyFingerprint != "" {
+			continue
+		}
+		checks[i] = &hostKeyCheck{}
+		wg.Go(func() {
+			checks[i].fingerprint, checks[i].err = hostKeyFingerprint(name, server.SSH, sshHost(server))
+		})
+	}
+	wg.Wait()
+
+	checked := make(map[string]hostKeyCheck)
+	in := bufio.NewReader(os.Stdin)
+	for i, name := range names {
+		check := checks[i]
+		if check == nil {
+			continue
+		}
+		var unknown *unknownHostKeyError
+		var scanErr *hostKeyScanError
+		switch err := check.err; {
+		case err == nil, errors.As(err, &scanErr):
+			checked[name] = *check
+			continue
+		case !errors.As(err, &unknown):
+			return nil, err
+		}
+		sc := cfg.Servers[name].SSH
+		trusted, err := askTrustHostKey(in, configPath, sc, unknown)
+		if err != nil {
+			return nil, err
+		}
+		if trusted {
+			check.fingerprint, check.err = ssh.FingerprintSHA256(unknown.key), nil
+		}
+		checked[name] = *check
+	}
+	return checked, nil
+}
+
+// askTrustHostKey shows an unknown host key and saves it where the user
+// chooses. It reports whether the key is now trusted.
+func askTrustHostKey(in *bufio.Reader, configPath string, sc *config.SSHConfig, unknown *unknownHostKeyError) (bool, error) {
+	fingerprint := ssh.FingerprintSHA256(unknown.key)
+	fmt.Fprintf(os.Stderr, "The SSH host key of %s (server %s) isn't known yet.\n", unknown.addr, unknown.server)
+	if unknown.note != "" {
+		fmt.Fprintf(os.Stderr, "Note: %s.\n", unknown.note)
+	}
+	fmt.Fprintf(os.Stderr, "%s key fingerprint: %s\n", unknown.key.Type(), fingerprint)
+	fmt.Fprintf(os.Stderr, "Trust it and save it to\n")
+	fmt.Fprintf(os.Stderr, "  [c] %s (host_key_fingerprint in [servers.%s.ssh])\n", configPath, unknown.server)
+	fmt.Fprintf(os.Stderr, "  [k] %s\n", sc.KnownHosts)
+	fmt.Fprintf(os.Stderr, "  [n] don't trust it\n")
+	for {
+		fmt.Fprint(os.Stderr, "Choice [c/k/n]: ")
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return false, fmt.Errorf("reading answer: %v", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "c":
+			if err := config.SetHostKeyFingerprint(configPath, unknown.server, fingerprint); err != nil {
+				return false, fmt.Errorf("saving host key: %v; add host_key_fingerprint = %q to [servers.%s.ssh] instead", err, fingerprint, unknown.server)
+			}
+			sc.HostKeyFingerprint = fingerprint
+			return true, nil
+		case "k":
+			if err := appendKnownHost(sc.KnownHosts, unknown.addr, unknown.key); err != nil {
+				return false, fmt.Errorf("saving host key to %s: %v", sc.KnownHosts, err)
+			}
+			return true, nil
+		case "n", "":
+			return false, nil
+		}
+	}
+}
+
+// appendKnownHost adds a host key to a known_hosts file, creating it if
+// needed.
+func appendKnownHost(path, addr string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr)}, key) + "\n"
+	// Don't run the new entry into a last line without a newline.
+	if data, err := os.ReadFile(path); err == nil && len(data) > 0 && data[len(data)-1] != '\n' {
+		line = "\n" + line
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// remote is the address the test host keys are offered from.
+var remote = &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 22}
+
+const testAddr = "truenas.local:22"
+
+// hostKey returns a new Ed25519 host key, or an ECDSA one for a key of
+// another type.
+func hostKey(t *testing.T, ecdsaKey bool) ssh.PublicKey {
+	t.Helper()
+	var pub crypto.PublicKey
+	if ecdsaKey {
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub = priv.Public()
+	} else {
+		var err error
+		if pub, _, err = ed25519.GenerateKey(rand.Reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestCheckKnownHosts(t *testing.T) {
+	known := hostKey(t, false)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	if err := appendKnownHost(path, testAddr, known); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkKnownHosts("home", path, testAddr, remote, known); err != nil {
+		t.Errorf("expected the known key to be trusted, got %v", err)
+	}
+
+	err := checkKnownHosts("home", path, testAddr, remote, hostKey(t, false))
+	if err == nil || !strings.Contains(err.Error(), "has changed") || !strings.Contains(err.Error(), ssh.FingerprintSHA256(known)) {
+		t.Errorf("expected a changed key error naming the old key, got %v", err)
+	}
+
+	var unknown *unknownHostKeyError
+	if err := checkKnownHosts("home", path, "nas.local:22", remote, known); !errors.As(err, &unknown) {
+		t.Errorf("expected an unknown host, got %v", err)
+	}
+	if err := checkKnownHosts("home", filepath.Join(t.TempDir(), "missing"), testAddr, remote, known); !errors.As(err, &unknown) {
+		t.Errorf("expected an unknown host without a known_hosts file, got %v", err)
+	}
+	err = checkKnownHosts("home", path, testAddr, remote, hostKey(t, true))
+	if !errors.As(err, &unknown) || !strings.Contains(unknown.note, "ssh-ed25519") {
+		t.Errorf("expected a key of another type to be unknown with a note, got %v", err)
+	}
+}
+
+func TestAppendKnownHost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// A last line without a newline is kept apart from the new entry.
+	if err := os.WriteFile(path, []byte("# hosts"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key := hostKey(t, false)
+	if err := appendKnownHost(path, testAddr, key); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || lines[0] != "# hosts" || !strings.HasPrefix(lines[1], "truenas.local ssh-ed25519 ") {
+		t.Errorf("unexpected known_hosts %q", data)
+	}
+	if err := checkKnownHosts("home", path, testAddr, remote, key); err != nil {
+		t.Errorf("expected the appended key to be trusted, got %v", err)
+	}
+
+	// The file and its directory are created if needed.
+	path = filepath.Join(t.TempDir(), "new", "known_hosts")
+	if err := appendKnownHost(path, testAddr, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkKnownHosts("home", path, testAddr, remote, key); err != nil {
+		t.Errorf("expected the key in a new file to be trusted, got %v", err)
+	}
+}
+
+func TestAskTrustHostKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		pinned bool
+		known  bool
+	}{
+		{"config", "c\n", true, false},
+		{"known_hosts", "k\n", false, true},
+		{"don't trust", "n\n", false, false},
+		{"default", "\n", false, false},
+		{"asks again", "x\nK\n", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+			configPath := writeSSHConfig(t, "known_hosts = \""+knownHosts+"\"\n")
+			sc := loadSSHConfig(t, configPath)
+			key := hostKey(t, false)
+			unknown := checkKnownHosts("home", sc.KnownHosts, testAddr, remote, key)
+			var u *unknownHostKeyError
+			if !errors.As(unknown, &u) {
+				t.Fatalf("expected an unknown key, got %v", unknown)
+			}
+
+			trusted, err := askTrustHostKey(bufio.NewReader(strings.NewReader(tt.answer)), configPath, sc, u)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if trusted != (tt.pinned || tt.known) {
+				t.Errorf("expected trusted=%v, got %v", tt.pinned || tt.known, trusted)
+			}
+
+			fingerprint := ssh.FingerprintSHA256(key)
+			if saved := loadSSHConfig(t, configPath).HostKeyFingerprint; tt.pinned != (saved == fingerprint) || !tt.pinned && saved != "" {
+				t.Errorf("expected pinned=%v, config has %q", tt.pinned, saved)
+			}
+			if tt.pinned && sc.HostKeyFingerprint != fingerprint {
+				t.Errorf("expected the fingerprint set for this run, got %q", sc.HostKeyFingerprint)
+			}
+			err = checkKnownHosts("home", sc.KnownHosts, testAddr, remote, key)
+			if tt.known != (err == nil) {
+				t.Errorf("expected known=%v, got %v", tt.known, err)
+			}
+		})
+	}
+}
+
+func TestAskTrustHostKey_EOF(t *testing.T) {
+	configPath := writeSSHConfig(t, "")
+	sc := loadSSHConfig(t, configPath)
+	u := &unknownHostKeyError{server: "home", addr: testAddr, key: hostKey(t, false)}
+	if _, err := askTrustHostKey(bufio.NewReader(strings.NewReader("")), configPath, sc, u); err == nil {
+		t.Error("expected an error when there is no answer")
+	}
+}
+
+// A pinned fingerprint is handed to the SSH client to check without
+// fetching the key first, so it works while the host can't be reached.
+func TestHostKeyFingerprint_Pinned(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	fingerprint := ssh.FingerprintSHA256(hostKey(t, false))
+	sc := loadSSHConfig(t, writeSSHConfig(t, "host_key_fingerprint = \""+fingerprint+"\"\n"))
+	sc.Port = port
+	got, err := hostKeyFingerprint("home", sc, "127.0.0.1")
+	if err != nil || got != fingerprint {
+		t.Errorf("expected %s, got %q, %v", fingerprint, got, err)
+	}
+
+	sc.HostKeyFingerprint = ""
+	var scanErr *hostKeyScanError
+	if _, err := hostKeyFingerprint("home", sc, "127.0.0.1"); !errors.As(err, &scanErr) {
+		t.Errorf("expected a scan error without a pinned key, got %v", err)
+	}
+}
+
+// The host key looked up before the UI starts is used to connect rather
+// than fetched again.
+func TestSSHConfig_Checked(t *testing.T) {
+	sc := loadSSHConfig(t, writeSSHConfig(t, "private_key_path = \""+writeKey(t, t.TempDir(), "")+"\"\n"))
+	sc.Port = closedPort(t)
+	serverCfg := config.ServerConfig{Host: "127.0.0.1", SSH: sc}
+
+	fingerprint := ssh.FingerprintSHA256(hostKey(t, false))
+	got, err := sshConfig("home", serverCfg, map[string]hostKeyCheck{"home": {fingerprint: fingerprint}})
+	if err != nil || got.HostKeyFingerprint != fingerprint {
+		t.Errorf("expected the checked fingerprint %s, got %+v, %v", fingerprint, got, err)
+	}
+
+	declined := &unknownHostKeyError{server: "home", addr: testAddr, key: hostKey(t, false)}
+	if _, err := sshConfig("home", serverCfg, map[string]hostKeyCheck{"home": {err: declined}}); err != declined {
+		t.Errorf("expected the checked error, got %v", err)
+	}
+
+	var scanErr *hostKeyScanError
+	if _, err := sshConfig("home", serverCfg, nil); !errors.As(err, &scanErr) {
+		t.Errorf("expected the key to be fetched when it wasn't checked, got %v", err)
+	}
+}
+
+// sshServer is a local SSH server that offers a host key and then turns
+// every login down, enough to fetch and verify its key.
+type sshServer struct {
+	port int
+	mu   sync.Mutex
+	key  ssh.Signer
+}
+
+// startSSHServer starts an sshServer offering a new Ed25519 key.
+func startSSHServer(t *testing.T) *sshServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &sshServer{port: ln.Addr().(*net.TCPAddr).Port}
+	s.rotateKey(t)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			cfg := &ssh.ServerConfig{
+				PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+					return nil, errors.New("denied")
+				},
+			}
+			s.mu.Lock()
+			cfg.AddHostKey(s.key)
+			s.mu.Unlock()
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = ssh.NewServerConn(conn, cfg)
+			}()
+		}
+	}()
+	return s
+}
+
+// rotateKey gives the server a new host key, as a reinstall would.
+func (s *sshServer) rotateKey(t *testing.T) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	s.key = signer
+	s.mu.Unlock()
+}
+
+// fingerprint returns the fingerprint of the key the server offers.
+func (s *sshServer) fingerprint() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ssh.FingerprintSHA256(s.key.PublicKey())
+}
+
+// login connects with the SSH client the way truenas-tui does, pinning
+// fingerprint, and returns the error. The server turns the login down, so
+// there is always one; it only mentions the host key if that didn't match.
+func (s *sshServer) login(t *testing.T, fingerprint string) error {
+	t.Helper()
+	privateKey, err := os.ReadFile(writeKey(t, t.TempDir(), ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.NewSSHClient(&client.SSHConfig{
+		Host:               "127.0.0.1",
+		Port:               s.port,
+		User:               "admin",
+		PrivateKey:         string(privateKey),
+		HostKeyFingerprint: fingerprint,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return c.Connect(ctx)
+}
+
+// Trusting a host key on first use, saved to known_hosts or pinned in the
+// config, then the same key and a changed one on the next connection.
+func TestHostKey_TrustOnFirstUse(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+	}{
+		{"known_hosts", "k\n"},
+		{"config", "c\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := startSSHServer(t)
+			knownHosts := filepath.Join(t.TempDir(), "known_hosts")
+			configPath := writeSSHConfig(t, fmt.Sprintf("port = %d\nknown_hosts = %q\n", server.port, knownHosts))
+			sc := loadSSHConfig(t, configPath)
+
+			// Unknown: the key is shown and the user trusts it.
+			_, err := hostKeyFingerprint("home", sc, "127.0.0.1")
+			var unknown *unknownHostKeyError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("expected an unknown key, got %v", err)
+			}
+			if _, err := askTrustHostKey(bufio.NewReader(strings.NewReader(tt.answer)), configPath, sc, unknown); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Known: the next run trusts the same key.
+			sc = loadSSHConfig(t, configPath)
+			trusted := server.fingerprint()
+			got, err := hostKeyFingerprint("home", sc, "127.0.0.1")
+			if err != nil || got != trusted {
+				t.Fatalf("expected %s, got %q, %v", trusted, got, err)
+			}
+			if err := server.login(t, got); err == nil || strings.Contains(err.Error(), "host key") {
+				t.Errorf("expected the login to get past the host key, got %v", err)
+			}
+
+			// Changed: the server offers another key.
+			server.rotateKey(t)
+			got, err = hostKeyFingerprint("home", sc, "127.0.0.1")
+			if err != nil {
+				// known_hosts is checked before connecting.
+				if sc.HostKeyFingerprint != "" || !strings.Contains(err.Error(), "has changed") {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			// A pinned key is checked by the SSH client as it connects.
+			if got != trusted {
+				t.Fatalf("expected the pinned %s, got %q", trusted, got)
+			}
+			if err := server.login(t, got); err == nil || !strings.Contains(err.Error(), "host key verification failed") {
+				t.Errorf("expected the changed key to be refused, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// connectTimeout bounds the setup wizard's connection test.
+const connectTimeout = 30 * time.Second
+
+// testConnection checks a profile before runInit saves it. Tests replace it
+// so they don't connect anywhere.
+var testConnection = testServer
+
+// prompter asks the setup wizard's questions on the terminal.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints question and returns the answer, or def for an empty one.
+func (p *prompter) ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+	line, err := p.in.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading answer: %v", err)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line, nil
+	}
+	return def, nil
+}
+
+// require asks until the answer isn't empty.
+func (p *prompter) require(question, def string) (string, error) {
+	for {
+		answer, err := p.ask(question, def)
+		if err != nil || answer != "" {
+			return answer, err
+		}
+	}
+}
+
+// confirm asks a yes/no question.
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := p.ask(question+" ("+hint+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// secret asks for a value without echoing it.
+func (p *prompter) secret(question string) (string, error) {
+	for {
+		fmt.Fprintf(p.out, "%s: ", question)
+		input, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(p.out)
+		if err != nil {
+			return "", fmt.Errorf("reading answer: %v", err)
+		}
+		if s := strings.TrimSpace(string(input)); s != "" {
+			return s, nil
+		}
+	}
+}
+
+// choose offers numbered options and returns the index picked.
+func (p *prompter) choose(question string, options []string) (int, error) {
+	fmt.Fprintln(p.out, question)
+	for i, o := range options {
+		fmt.Fprintf(p.out, "  %d. %s\n", i+1, o)
+	}
+	for {
+		answer, err := p.ask("Choice", "1")
+		if err != nil {
+			return 0, err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+	}
+}
+
+// offerInit runs the setup wizard when the config file doesn't exist and
+// there is a terminal to ask on. It reports whether a config was written.
+func offerInit(path string) (bool, error) {
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, nil
+	}
+	p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+	fmt.Fprintf(p.out, "There is no config at %s.\n", path)
+	ok, err := p.confirm("Set up a server now?", true)
+	if err != nil || !ok {
+		return false, err
+	}
+	return true, runInit(p, path)
+}
+
+// runInit walks through a server profile, tests the connection and adds
+// the profile to the config file at path, creating it if needed.
+func runInit(p *prompter, path string) error {
+	existing := map[string]bool{}
+	if _, err := os.Stat(path); err == nil {
+		cfg, err := config.LoadFrom(path)
+		if err != nil {
+			return fmt.Errorf("%v\nFix the config before adding a server to it", err)
+		}
+		for _, name := range cfg.ServerNames() {
+			existing[name] = true
+		}
+		fmt.Fprintf(p.out, "Adding a server to %s (has %s).\n", path, strings.Join(cfg.ServerNames(), ", "))
+	}
+
+	for {
+		name, sc, err := askServer(p, existing)
+		if err != nil {
+			return err
+		}
+		ok, err := testConnection(p, name, sc)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			again, err := p.confirm("Change the settings and try again?", true)
+			if err != nil {
+				return err
+			}
+			if again {
+				continue
+			}
+			save, err := p.confirm("Save the server anyway?", false)
+			if err != nil {
+				return err
+			}
+			if !save {
+				fmt.Fprintln(p.out, "Nothing was saved.")
+				return nil
+			}
+		}
+		if err := config.AppendServer(path, name, sc); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Saved server %s to %s.\n", name, path)
+		return nil
+	}
+}
+
+// askServer asks for a server profile's settings.
+func askServer(p *prompter, existing map[string]bool) (string, config.ServerConfig, error) {
+	var sc config.ServerConfig
+	def := "home"
+	if existing[def] {
+		def = ""
+	}
+	name, err := p.require("Profile name", def)
+	for err == nil && existing[name] {
+		fmt.Fprintf(p.out, "There is already a server called %s.\n", name)
+		name, err = p.require("Profile name", "")
+	}
+	if err != nil {
+		return "", sc, err
+	}
+
+	if sc.Host, err = p.require("Host", ""); err != nil {
+		return "", sc, err
+	}
+	port, err := p.require("Port", "443")
+	if err != nil {
+		return "", sc, err
+	}
+	if sc.Port, err = strconv.Atoi(port); err != nil || sc.Port < 1 || sc.Port > 65535 {
+		return "", sc, fmt.Errorf("invalid port %q", port)
+	}
+	if sc.Username, err = p.require("Username", "admin"); err != nil {
+		return "", sc, err
+	}
+
+	fmt.Fprintln(p.out, "Generate an API key in the TrueNAS web UI under Credentials > API Keys.")
+	source, err := p.choose("Where should the API key come from?", []string{
+		"Store it in the config file",
+		"A command that prints it, e.g. a password manager's CLI",
+		"A file",
+		"An environment variable",
+	})
+	if err != nil {
+		return "", sc, err
+	}
+	switch source {
+	case 0:
+		sc.APIKey, err = p.secret("API key")
+	case 1:
+		sc.APIKeyCommand, err = p.require("Command", "")
+	case 2:
+		sc.APIKeyFile, err = p.require("File", "")
+	case 3:
+		sc.APIKeyEnv, err = p.require("Variable", "TRUENAS_API_KEY")
+	}
+	if err != nil {
+		return "", sc, err
+	}
+
+	verify, err := p.confirm("Verify the TLS certificate? Answer no only if the server still uses the self-signed one TrueNAS ships with", true)
+	if err != nil {
+		return "", sc, err
+	}
+	if !verify {
+		fmt.Fprintln(p.out, "Warning: without verifying the certificate, anyone on the network path can impersonate the server and read the API key.")
+		sc.InsecureSkipVerify = true
+	}
+
+	useSSH, err := p.confirm("Set up SSH for operations the API can't do?", false)
+	if err != nil || !useSSH {
+		return name, sc, err
+	}
+	sc.SSH, err = askSSH(p, sc)
+	return name, sc, err
+}
+
+// askSSH asks for the SSH section and pins the server's host key if the
+// user trusts it.
+func askSSH(p *prompter, sc config.ServerConfig) (*config.SSHConfig, error) {
+	ssc := &config.SSHConfig{}
+	home, _ := os.UserHomeDir()
+	def := filepath.Join(home, ".ssh", "id_ed25519")
+	var err error
+	if ssc.PrivateKeyPath, err = p.require("Private key", def); err != nil {
+		return nil, err
+	}
+	username, err := p.require("SSH username", sc.Username)
+	if err != nil {
+		return nil, err
+	}
+	if username != sc.Username {
+		ssc.Username = username
+	}
+
+	host, err := p.require("SSH host", sc.Host)
+	if err != nil {
+		return nil, err
+	}
+	if host != sc.Host {
+		ssc.Host = host
+	}
+	answer, err := p.require("SSH port", "22")
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(answer)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid SSH port %q", answer)
+	}
+	if port != 22 {
+		ssc.Port = port
+	}
+
+	fmt.Fprintf(p.out, "Fetching the SSH host key of %s...\n", net.JoinHostPort(host, strconv.Itoa(port)))
+	key, _, err := scanHostKey(host, port)
+	if err != nil {
+		fmt.Fprintf(p.out, "Couldn't fetch it (%v). truenas-tui will ask about it when it connects.\n", err)
+		return ssc, nil
+	}
+	fmt.Fprintf(p.out, "%s key fingerprint: %s\n", key.Type(), ssh.FingerprintSHA256(key))
+	trust, err := p.confirm("Trust it and save it to the config?", true)
+	if err != nil {
+		return nil, err
+	}
+	if trust {
+		ssc.HostKeyFingerprint = ssh.FingerprintSHA256(key)
+	}
+	return ssc, nil
+}
+
+// testServer checks a profile by writing it to a scratch config, loading
+// it the way truenas-tui does and connecting. It reports whether the
+// connection worked.
+func testServer(p *prompter, name string, sc config.ServerConfig) (bool, error) {
+	dir, err := os.MkdirTemp("", "truenas-tui-init")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.toml")
+	if err := config.AppendServer(path, name, sc); err != nil {
+		return false, err
+	}
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		fmt.Fprintf(p.out, "The settings don't work: %v\n", err)
+		return false, nil
+	}
+	if err := promptPassphrases(cfg, []string{name}); err != nil {
+		return false, err
+	}
+
+	server := cfg.Servers[name]
+	fmt.Fprintf(p.out, "Connecting to %s...\n", net.JoinHostPort(server.Host, strconv.Itoa(server.Port)))
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	services, err := connect(ctx, name, server)
+	if err != nil {
+		fmt.Fprintf(p.out, "Connection failed: %v\n", err)
+		return false, nil
+	}
+	defer services.Conn.Close()
+	version, err := services.System.GetVersion(ctx)
+	if err != nil {
+		fmt.Fprintf(p.out, "Connected, but the API call failed: %v\n", err)
+		return false, nil
+	}
+	fmt.Fprintf(p.out, "Connected to %s.\n", version)
+	return true, nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deevus/truenas-tui/config"
+)
+
+// runScript runs the setup wizard on path with one answer per question and
+// returns what it printed. The connection test reports ok instead of
+// connecting.
+func runScript(t *testing.T, path string, ok bool, answers ...string) (string, error) {
+	t.Helper()
+	saved := testConnection
+	t.Cleanup(func() { testConnection = saved })
+	testConnection = func(*prompter, string, config.ServerConfig) (bool, error) { return ok, nil }
+
+	var out strings.Builder
+	p := &prompter{
+		in:  bufio.NewReader(strings.NewReader(strings.Join(answers, "\n") + "\n")),
+		out: &out,
+	}
+	err := runInit(p, path)
+	return out.String(), err
+}
+
+// readConfig returns the config file at path.
+func readConfig(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunInit_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "truenas-tui", "config.toml")
+	_, err := runScript(t, path, true,
+		"",          // profile name: home
+		"nas.local", // host
+		"",          // port: 443
+		"",          // username: admin
+		"4",         // API key from an environment variable
+		"",          // variable: TRUENAS_API_KEY
+		"",          // verify the TLS certificate: yes
+		"",          // SSH: no
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "[servers.home]\nhost = \"nas.local\"\nport = 443\nusername = \"admin\"\napi_key_env = \"TRUENAS_API_KEY\"\n"
+	if got := readConfig(t, path); got != want {
+		t.Errorf("expected config\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestRunInit_SkipVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	out, err := runScript(t, path, true, "lab", "10.0.0.5", "8443", "root", "3", "/run/secrets/api-key", "no", "n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "Warning: without verifying the certificate") {
+		t.Errorf("expected a warning about skipping verification, got %q", out)
+	}
+	want := "[servers.lab]\nhost = \"10.0.0.5\"\nport = 8443\nusername = \"root\"\napi_key_file = \"/run/secrets/api-key\"\ninsecure_skip_verify = true\n"
+	if got := readConfig(t, path); got != want {
+		t.Errorf("expected config\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestRunInit_SSH(t *testing.T) {
+	server := startSSHServer(t)
+	keyPath := writeKey(t, t.TempDir(), "")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	out, err := runScript(t, path, true,
+		"home", "nas.local", "", "", "2", "op read op://homelab/truenas/api-key", "",
+		"y",                     // SSH
+		keyPath,                 // private key
+		"root",                  // SSH username
+		"127.0.0.1",             // SSH host
+		fmt.Sprint(server.port), // SSH port
+		"y",                     // trust the host key
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, server.fingerprint()) {
+		t.Errorf("expected the host key fingerprint to be shown, got %q", out)
+	}
+	want := fmt.Sprintf("[servers.home]\nhost = \"nas.local\"\nport = 443\nusername = \"admin\"\napi_key_command = \"op read op://homelab/truenas/api-key\"\n"+
+		"\n[servers.home.ssh]\nhost = \"127.0.0.1\"\nport = %d\nusername = \"root\"\nprivate_key_path = %q\nhost_key_fingerprint = %q\n",
+		server.port, keyPath, server.fingerprint())
+	if got := readConfig(t, path); got != want {
+		t.Errorf("expected config\n%s\ngot\n%s", want, got)
+	}
+}
+
+// The SSH section leaves out settings that match their defaults, and an
+// untrusted host key isn't pinned.
+func TestRunInit_SSHDefaults(t *testing.T) {
+	server := startSSHServer(t)
+	keyPath := writeKey(t, t.TempDir(), "")
+	path := filepath.Join(t.TempDir(), "config.toml")
+	_, err := runScript(t, path, true,
+		"home", "127.0.0.1", "", "", "4", "", "", "y",
+		keyPath, "", "", fmt.Sprint(server.port), "n",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := fmt.Sprintf("\n[servers.home.ssh]\nport = %d\nprivate_key_path = %q\n", server.port, keyPath)
+	if got := readConfig(t, path); !strings.HasSuffix(got, want) {
+		t.Errorf("expected the config to end with\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestRunInit_ConnectionFailed(t *testing.T) {
+	answers := []string{"home", "nas.local", "", "", "4", "", "", ""}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	out, err := runScript(t, path, false, append(answers, "n", "")...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) || !strings.Contains(out, "Nothing was saved.") {
+		t.Errorf("expected nothing saved by default, got %v and %q", err, out)
+	}
+
+	if _, err := runScript(t, path, false, append(answers, "n", "y")...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readConfig(t, path); !strings.HasPrefix(got, "[servers.home]\n") {
+		t.Errorf("expected the server saved anyway, got\n%s", got)
+	}
+
+	// Trying again asks for every setting anew.
+	path = filepath.Join(t.TempDir(), "config.toml")
+	if _, err := runScript(t, path, false, append(append(answers, "y"), "lab", "10.0.0.5", "", "", "4", "", "", "", "n", "y")...); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readConfig(t, path); !strings.HasPrefix(got, "[servers.lab]\nhost = \"10.0.0.5\"\n") {
+		t.Errorf("expected the second answers saved, got\n%s", got)
+	}
+}
+
+func TestRunInit_Existing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	existing := "# My servers\n[servers.home]\nhost = \"nas.local\"\napi_key = \"1-abc\"\n"
+	if err := os.WriteFile(path, []byte(existing), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := runScript(t, path, true,
+		"home", // taken, so asked again
+		"backup", "backup.local", "", "", "4", "", "", "",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "There is already a server called home.") {
+		t.Errorf("expected the taken name to be refused, got %q", out)
+	}
+	got := readConfig(t, path)
+	if !strings.HasPrefix(got, existing) || !strings.Contains(got, "[servers.backup]\nhost = \"backup.local\"\n") {
+		t.Errorf("expected backup appended to the existing config, got\n%s", got)
+	}
+	t.Setenv("TRUENAS_API_KEY", "1-def")
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if names := cfg.ServerNames(); len(names) != 2 {
+		t.Errorf("expected two servers, got %v", names)
+	}
+}
+
+func TestRunInit_InvalidPort(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if _, err := runScript(t, path, true, "home", "nas.local", "https"); err == nil || !strings.Contains(err.Error(), `invalid port "https"`) {
+		t.Errorf("expected an invalid port error, got %v", err)
+	}
+}
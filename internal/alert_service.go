@@ -0,0 +1,196 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/deevus/truenas-go"
+)
+
+// AlertServiceAPI defines alert operations that truenas-go does not expose.
+type AlertServiceAPI interface {
+	List(ctx context.Context) ([]Alert, error)
+	Dismiss(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Subscribe(ctx context.Context) (*truenas.Subscription[struct{}], error)
+}
+
+// Compile-time checks.
+var _ AlertServiceAPI = (*AlertService)(nil)
+var _ AlertServiceAPI = (*MockAlertService)(nil)
+
+// Alert levels, from least to most severe.
+const (
+	AlertLevelInfo      = "INFO"
+	AlertLevelNotice    = "NOTICE"
+	AlertLevelWarning   = "WARNING"
+	AlertLevelError     = "ERROR"
+	AlertLevelCritical  = "CRITICAL"
+	AlertLevelAlert     = "ALERT"
+	AlertLevelEmergency = "EMERGENCY"
+)
+
+var alertLevelRank = map[string]int{
+	AlertLevelInfo:      0,
+	AlertLevelNotice:    1,
+	AlertLevelWarning:   2,
+	AlertLevelError:     3,
+	AlertLevelCritical:  4,
+	AlertLevelAlert:     5,
+	AlertLevelEmergency: 6,
+}
+
+// Alert is an entry from the TrueNAS alert subsystem (the bell icon in the
+// web UI).
+type Alert struct {
+	ID             string // uuid
+	Source         string // alert class, e.g. "ZpoolCapacityWarning"
+	Level          string
+	Text           string // formatted message
+	Datetime       time.Time
+	LastOccurrence time.Time
+	Dismissed      bool
+}
+
+// AlertSeverity ranks an alert level so alerts can be ordered; unknown
+// levels rank lowest.
+func AlertSeverity(level string) int {
+	return alertLevelRank[level]
+}
+
+// Severity returns the rank of the alert's level.
+func (a Alert) Severity() int {
+	return AlertSeverity(a.Level)
+}
+
+// AlertService provides typed methods for the alert.* API namespace.
+type AlertService struct {
+	client  truenas.SubscribeCaller
+	version truenas.Version
+}
+
+// NewAlertService creates an AlertService for the given client and server version.
+func NewAlertService(c truenas.SubscribeCaller, v truenas.Version) *AlertService {
+	return &AlertService{client: c, version: v}
+}
+
+// List returns all current alerts, including dismissed ones.
+func (s *AlertService) List(ctx context.Context) ([]Alert, error) {
+	result, err := s.client.Call(ctx, "alert.list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []AlertResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse list response: %w", err)
+	}
+	alerts := make([]Alert, len(responses))
+	for i, r := range responses {
+		alerts[i] = alertFromResponse(r)
+	}
+	return alerts, nil
+}
+
+// Dismiss marks an alert as dismissed.
+func (s *AlertService) Dismiss(ctx context.Context, id string) error {
+	_, err := s.client.Call(ctx, "alert.dismiss", []any{id})
+	return err
+}
+
+// Restore brings a dismissed alert back.
+func (s *AlertService) Restore(ctx context.Context, id string) error {
+	_, err := s.client.Call(ctx, "alert.restore", []any{id})
+	return err
+}
+
+// Subscribe reports changes to the alert list. Collection updates carry only
+// the changed fields, not whether an alert was added or removed, so each
+// event is a signal to call List again rather than a delta.
+func (s *AlertService) Subscribe(ctx context.Context) (*truenas.Subscription[struct{}], error) {
+	rawSub, err := s.client.Subscribe(ctx, "alert.list", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan struct{}, 1)
+	go func() {
+		defer close(ch)
+		for range rawSub.C {
+			// Coalesce bursts: one pending signal is enough.
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return truenas.NewSubscription[struct{}](ch, rawSub.Close), nil
+}
+
+// AlertResponse is the wire format of an alert.list entry.
+type AlertResponse struct {
+	UUID           string  `json:"uuid"`
+	Klass          string  `json:"klass"`
+	Level          string  `json:"level"`
+	Formatted      *string `json:"formatted"`
+	Text           string  `json:"text"`
+	Datetime       apiTime `json:"datetime"`
+	LastOccurrence apiTime `json:"last_occurrence"`
+	Dismissed      bool    `json:"dismissed"`
+}
+
+func alertFromResponse(resp AlertResponse) Alert {
+	text := resp.Text
+	if resp.Formatted != nil {
+		text = *resp.Formatted
+	}
+	return Alert{
+		ID:             resp.UUID,
+		Source:         resp.Klass,
+		Level:          resp.Level,
+		Text:           strings.TrimSpace(text),
+		Datetime:       resp.Datetime.Time,
+		LastOccurrence: resp.LastOccurrence.Time,
+		Dismissed:      resp.Dismissed,
+	}
+}
+
+// MockAlertService is a test double for AlertServiceAPI.
+type MockAlertService struct {
+	ListFunc      func(ctx context.Context) ([]Alert, error)
+	DismissFunc   func(ctx context.Context, id string) error
+	RestoreFunc   func(ctx context.Context, id string) error
+	SubscribeFunc func(ctx context.Context) (*truenas.Subscription[struct{}], error)
+}
+
+func (m *MockAlertService) List(ctx context.Context) ([]Alert, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockAlertService) Dismiss(ctx context.Context, id string) error {
+	if m.DismissFunc != nil {
+		return m.DismissFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockAlertService) Restore(ctx context.Context, id string) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockAlertService) Subscribe(ctx context.Context) (*truenas.Subscription[struct{}], error) {
+	if m.SubscribeFunc != nil {
+		return m.SubscribeFunc(ctx)
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/internal"
+)
+
+const alertListResponse = `[{
+	"uuid": "a1",
+	"klass": "ZpoolCapacityWarning",
+	"level": "WARNING",
+	"formatted": "Space usage for pool \"tank\" is 85%.\n",
+	"text": "Space usage for pool \"%(volume)s\" is %(capacity)d%%.",
+	"datetime": {"$date": 1790000000000},
+	"last_occurrence": {"$date": 1790000600000},
+	"dismissed": false
+}, {
+	"uuid": "a2",
+	"klass": "SMART",
+	"level": "CRITICAL",
+	"formatted": null,
+	"text": "Device sdb failed a SMART test.",
+	"datetime": {"$date": 1790000000000},
+	"last_occurrence": null,
+	"dismissed": true
+}]`
+
+func TestAlertService_List(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "alert.list" {
+				t.Errorf("expected alert.list, got %s", method)
+			}
+			return json.RawMessage(alertListResponse), nil
+		},
+	}
+
+	alerts, err := internal.NewAlertService(mock, truenas.Version{}).List(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts, got %d", len(alerts))
+	}
+
+	a := alerts[0]
+	if a.ID != "a1" || a.Source != "ZpoolCapacityWarning" || a.Level != internal.AlertLevelWarning {
+		t.Errorf("unexpected alert: %+v", a)
+	}
+	if a.Text != `Space usage for pool "tank" is 85%.` {
+		t.Errorf("expected trimmed formatted text, got %q", a.Text)
+	}
+	if !a.Datetime.Equal(time.UnixMilli(1790000000000)) {
+		t.Errorf("unexpected datetime %v", a.Datetime)
+	}
+
+	b := alerts[1]
+	if b.Text != "Device sdb failed a SMART test." {
+		t.Errorf("expected raw text when formatted is null, got %q", b.Text)
+	}
+	if !b.Dismissed || !b.LastOccurrence.IsZero() {
+		t.Errorf("unexpected alert: %+v", b)
+	}
+	if b.Severity() <= a.Severity() {
+		t.Error("expected CRITICAL to outrank WARNING")
+	}
+}
+
+func TestAlertService_DismissRestore(t *testing.T) {
+	var calls []string
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			p, ok := params.([]any)
+			if !ok || len(p) != 1 || p[0] != "a1" {
+				t.Errorf("unexpected params for %s: %v", method, params)
+			}
+			calls = append(calls, method)
+			return json.RawMessage(`null`), nil
+		},
+	}
+
+	svc := internal.NewAlertService(mock, truenas.Version{})
+	if err := svc.Dismiss(context.Background(), "a1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.Restore(context.Background(), "a1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 2 || calls[0] != "alert.dismiss" || calls[1] != "alert.restore" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
+
+func TestAlertService_Subscribe(t *testing.T) {
+	raw := make(chan json.RawMessage, 3)
+	closed := false
+	mock := &client.MockClient{
+		SubscribeFunc: func(ctx context.Context, collection string, params any) (*truenas.Subscription[json.RawMessage], error) {
+			if collection != "alert.list" {
+				t.Errorf("expected alert.list, got %s", collection)
+			}
+			return truenas.NewSubscription[json.RawMessage](raw, func() { closed = true }), nil
+		},
+	}
+
+	sub, err := internal.NewAlertService(mock, truenas.Version{}).Subscribe(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw <- json.RawMessage(`{"uuid": "a1"}`)
+	select {
+	case <-sub.C:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change signal")
+	}
+
+	close(raw)
+	if _, ok := <-sub.C; ok {
+		t.Error("expected channel to close with the raw subscription")
+	}
+	sub.Close()
+	if !closed {
+		t.Error("expected Close to close the raw subscription")
+	}
+}
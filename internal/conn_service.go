@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"io"
+
+	"github.com/deevus/truenas-go"
+)
+
+// ConnServiceAPI checks that the connection to a server is alive and closes
+// it when it is replaced.
+type ConnServiceAPI interface {
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// Compile-time checks.
+var _ ConnServiceAPI = (*ConnService)(nil)
+var _ ConnServiceAPI = (*MockConnService)(nil)
+
+// ConnService implements ConnServiceAPI over a truenas-go client.
+type ConnService struct {
+	client truenas.Caller
+}
+
+// NewConnService creates a new ConnService.
+func NewConnService(c truenas.Caller) *ConnService {
+	return &ConnService{client: c}
+}
+
+// Ping makes a round trip to the middleware.
+func (s *ConnService) Ping(ctx context.Context) error {
+	_, err := s.client.Call(ctx, "core.ping", nil)
+	return err
+}
+
+// Close closes the client if it can be closed.
+func (s *ConnService) Close() error {
+	if c, ok := s.client.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MockConnService is a test double for ConnServiceAPI.
+type MockConnService struct {
+	PingFunc  func(ctx context.Context) error
+	CloseFunc func() error
+}
+
+func (m *MockConnService) Ping(ctx context.Context) error {
+	if m.PingFunc != nil {
+		return m.PingFunc(ctx)
+	}
+	return nil
+}
+
+func (m *MockConnService) Close() error {
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/internal"
+)
+
+func TestConnService_Ping(t *testing.T) {
+	var method string
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, m string, params any) (json.RawMessage, error) {
+			method = m
+			return json.RawMessage(`"pong"`), nil
+		},
+	}
+
+	if err := internal.NewConnService(mock).Ping(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "core.ping" {
+		t.Errorf("expected core.ping, got %s", method)
+	}
+}
+
+func TestConnService_Ping_Error(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, m string, params any) (json.RawMessage, error) {
+			return nil, errors.New("connection reset")
+		},
+	}
+
+	if err := internal.NewConnService(mock).Ping(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestConnService_Close(t *testing.T) {
+	closed := false
+	mock := &client.MockClient{
+		CloseFunc: func() error {
+			closed = true
+			return nil
+		},
+	}
+
+	if err := internal.NewConnService(mock).Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !closed {
+		t.Error("expected the client to be closed")
+	}
+}
@@ -0,0 +1,321 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deevus/truenas-go"
+)
+
+// DiskServiceAPI defines disk and SMART operations that truenas-go does not
+// expose.
+type DiskServiceAPI interface {
+	ListDisks(ctx context.Context) ([]Disk, error)
+	Temperatures(ctx context.Context, names []string) (map[string]float64, error)
+	SmartAttributes(ctx context.Context, name string) ([]SmartAttribute, error)
+	SmartTestResults(ctx context.Context, names []string) (map[string][]SmartTest, error)
+	StartSmartTest(ctx context.Context, identifier string, kind SmartTestType) error
+}
+
+// Compile-time checks.
+var _ DiskServiceAPI = (*DiskService)(nil)
+var _ DiskServiceAPI = (*MockDiskService)(nil)
+
+// SmartTestType is the kind of SMART self-test passed to smart.test.manual_test.
+type SmartTestType string
+
+const (
+	SmartTestShort SmartTestType = "SHORT"
+	SmartTestLong  SmartTestType = "LONG"
+)
+
+// SMART self-test statuses reported in SmartTest.
+const (
+	SmartTestSuccess = "SUCCESS"
+	SmartTestFailed  = "FAILED"
+	SmartTestRunning = "RUNNING"
+)
+
+// Disk is a physical disk.
+type Disk struct {
+	Identifier string // stable id used by smart.test, e.g. "{serial_lunid}5000c500..."
+	Name       string // device name, e.g. "sda"
+	Serial     string
+	Model      string
+	Size       int64
+	Type       string // HDD or SSD
+	Pool       string // pool the disk belongs to, or "" if unused
+}
+
+// SmartAttribute is one row of the SMART attribute table.
+type SmartAttribute struct {
+	ID         int
+	Name       string
+	Value      int
+	Worst      int
+	Threshold  int
+	Raw        string
+	WhenFailed string // "" unless the attribute has crossed its threshold
+}
+
+// Failing reports whether the attribute is at or past its failure threshold.
+func (a SmartAttribute) Failing() bool {
+	return a.WhenFailed != "" || (a.Threshold > 0 && a.Value <= a.Threshold)
+}
+
+// SmartTest is one entry of a disk's SMART self-test log, newest first.
+type SmartTest struct {
+	Num           int
+	Description   string // e.g. "Short offline", "Extended offline"
+	Status        string // SUCCESS, FAILED, RUNNING, ABORTED, ...
+	StatusVerbose string
+	Remaining     float64 // percent remaining while running
+	Lifetime      int64   // power-on hours when the test ran
+	LBAOfError    string
+}
+
+// DiskService provides typed methods for the disk.* and smart.* API namespaces.
+type DiskService struct {
+	client  truenas.AsyncCaller
+	version truenas.Version
+}
+
+// NewDiskService creates a DiskService for the given client and server version.
+func NewDiskService(c truenas.AsyncCaller, v truenas.Version) *DiskService {
+	return &DiskService{client: c, version: v}
+}
+
+// ListDisks returns all physical disks with their pool membership.
+func (s *DiskService) ListDisks(ctx context.Context) ([]Disk, error) {
+	params := []any{[]any{}, map[string]any{"extra": map[string]any{"pools": true}}}
+	result, err := s.client.Call(ctx, "disk.query", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []DiskResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse query response: %w", err)
+	}
+	disks := make([]Disk, len(responses))
+	for i, r := range responses {
+		disks[i] = diskFromResponse(r)
+	}
+	return disks, nil
+}
+
+// Temperatures returns the current temperature in °C of the named disks.
+// Disks that do not report a temperature are absent from the map.
+func (s *DiskService) Temperatures(ctx context.Context, names []string) (map[string]float64, error) {
+	result, err := s.client.Call(ctx, "disk.temperatures", []any{names})
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]*float64
+	if err := json.Unmarshal(result, &raw); err != nil {
+		return nil, fmt.Errorf("parse temperatures response: %w", err)
+	}
+	temps := make(map[string]float64, len(raw))
+	for name, t := range raw {
+		if t != nil {
+			temps[name] = *t
+		}
+	}
+	return temps, nil
+}
+
+// SmartAttributes returns the SMART attribute table of the named disk.
+func (s *DiskService) SmartAttributes(ctx context.Context, name string) ([]SmartAttribute, error) {
+	result, err := s.client.Call(ctx, "disk.smart_attributes", []any{name})
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []SmartAttributeResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse smart attributes response: %w", err)
+	}
+	attrs := make([]SmartAttribute, len(responses))
+	for i, r := range responses {
+		attrs[i] = SmartAttribute{
+			ID:         r.ID,
+			Name:       r.Name,
+			Value:      r.Value,
+			Worst:      r.Worst,
+			Threshold:  r.Thresh,
+			Raw:        r.Raw.String,
+			WhenFailed: r.WhenFailed,
+		}
+	}
+	return attrs, nil
+}
+
+// SmartTestResults returns the self-test log of each named disk, or of every
+// disk when names is empty.
+func (s *DiskService) SmartTestResults(ctx context.Context, names []string) (map[string][]SmartTest, error) {
+	filter := [][]any{}
+	if len(names) > 0 {
+		filter = append(filter, []any{"disk", "in", names})
+	}
+	result, err := s.client.Call(ctx, "smart.test.results", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []SmartTestResultsResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse smart test results response: %w", err)
+	}
+	results := make(map[string][]SmartTest, len(responses))
+	for _, r := range responses {
+		tests := make([]SmartTest, len(r.Tests))
+		for i, t := range r.Tests {
+			tests[i] = SmartTest{
+				Num:           t.Num,
+				Description:   t.Description,
+				Status:        t.Status,
+				StatusVerbose: t.StatusVerbose,
+				Lifetime:      t.Lifetime,
+			}
+			if t.Remaining != nil {
+				tests[i].Remaining = *t.Remaining
+			}
+			if t.LBAOfFirstError != nil {
+				tests[i].LBAOfError = fmt.Sprint(*t.LBAOfFirstError)
+			}
+		}
+		results[r.Disk] = tests
+	}
+	return results, nil
+}
+
+// StartSmartTest starts a SMART self-test on the disk with the given
+// identifier. It returns once the test has started, not when it finishes.
+func (s *DiskService) StartSmartTest(ctx context.Context, identifier string, kind SmartTestType) error {
+	params := []any{[]map[string]any{{"identifier": identifier, "type": string(kind)}}}
+	result, err := s.client.Call(ctx, "smart.test.manual_test", params)
+	if err != nil {
+		return err
+	}
+
+	var responses []struct {
+		Disk  string  `json:"disk"`
+		Error *string `json:"error"`
+	}
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return fmt.Errorf("parse manual test response: %w", err)
+	}
+	for _, r := range responses {
+		if r.Error != nil && *r.Error != "" {
+			return errors.New(strings.TrimSpace(*r.Error))
+		}
+	}
+	return nil
+}
+
+// DiskResponse is the wire format of a disk.query entry.
+type DiskResponse struct {
+	Identifier string  `json:"identifier"`
+	Name       string  `json:"name"`
+	Serial     string  `json:"serial"`
+	Model      *string `json:"model"`
+	Size       *int64  `json:"size"`
+	Type       *string `json:"type"`
+	Pool       *string `json:"pool"`
+}
+
+// SmartAttributeResponse is the wire format of a disk.smart_attributes entry.
+type SmartAttributeResponse struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Value      int    `json:"value"`
+	Worst      int    `json:"worst"`
+	Thresh     int    `json:"thresh"`
+	WhenFailed string `json:"when_failed"`
+	Raw        struct {
+		String string `json:"string"`
+	} `json:"raw"`
+}
+
+// SmartTestResultsResponse is the wire format of a smart.test.results entry.
+type SmartTestResultsResponse struct {
+	Disk  string `json:"disk"`
+	Tests []struct {
+		Num             int      `json:"num"`
+		Description     string   `json:"description"`
+		Status          string   `json:"status"`
+		StatusVerbose   string   `json:"status_verbose"`
+		Remaining       *float64 `json:"remaining"`
+		Lifetime        int64    `json:"lifetime"`
+		LBAOfFirstError *int64   `json:"lba_of_first_error"`
+	} `json:"tests"`
+}
+
+func diskFromResponse(resp DiskResponse) Disk {
+	d := Disk{
+		Identifier: resp.Identifier,
+		Name:       resp.Name,
+		Serial:     resp.Serial,
+	}
+	if resp.Model != nil {
+		d.Model = *resp.Model
+	}
+	if resp.Size != nil {
+		d.Size = *resp.Size
+	}
+	if resp.Type != nil {
+		d.Type = *resp.Type
+	}
+	if resp.Pool != nil {
+		d.Pool = *resp.Pool
+	}
+	return d
+}
+
+// MockDiskService is a test double for DiskServiceAPI.
+type MockDiskService struct {
+	ListDisksFunc        func(ctx context.Context) ([]Disk, error)
+	TemperaturesFunc     func(ctx context.Context, names []string) (map[string]float64, error)
+	SmartAttributesFunc  func(ctx context.Context, name string) ([]SmartAttribute, error)
+	SmartTestResultsFunc func(ctx context.Context, names []string) (map[string][]SmartTest, error)
+	StartSmartTestFunc   func(ctx context.Context, identifier string, kind SmartTestType) error
+}
+
+func (m *MockDiskService) ListDisks(ctx context.Context) ([]Disk, error) {
+	if m.ListDisksFunc != nil {
+		return m.ListDisksFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockDiskService) Temperatures(ctx context.Context, names []string) (map[string]float64, error) {
+	if m.TemperaturesFunc != nil {
+		return m.TemperaturesFunc(ctx, names)
+	}
+	return nil, nil
+}
+
+func (m *MockDiskService) SmartAttributes(ctx context.Context, name string) ([]SmartAttribute, error) {
+	if m.SmartAttributesFunc != nil {
+		return m.SmartAttributesFunc(ctx, name)
+	}
+	return nil, nil
+}
+
+func (m *MockDiskService) SmartTestResults(ctx context.Context, names []string) (map[string][]SmartTest, error) {
+	if m.SmartTestResultsFunc != nil {
+		return m.SmartTestResultsFunc(ctx, names)
+	}
+	return nil, nil
+}
+
+func (m *MockDiskService) StartSmartTest(ctx context.Context, identifier string, kind SmartTestType) error {
+	if m.StartSmartTestFunc != nil {
+		return m.StartSmartTestFunc(ctx, identifier, kind)
+	}
+	return nil
+}
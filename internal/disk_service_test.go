@@ -0,0 +1,169 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/internal"
+)
+
+func TestDiskService_ListDisks(t *testing.T) {
+	var gotParams any
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "disk.query" {
+				t.Errorf("expected disk.query, got %s", method)
+			}
+			gotParams = params
+			return json.RawMessage(`[
+				{"identifier": "{serial}A1", "name": "sda", "serial": "A1", "model": "WDC WD80EFAX", "size": 8001563222016, "type": "HDD", "pool": "tank"},
+				{"identifier": "{serial}B2", "name": "nvme0n1", "serial": "B2", "model": null, "size": null, "type": "SSD", "pool": null}
+			]`), nil
+		},
+	}
+
+	disks, err := internal.NewDiskService(mock, truenas.Version{}).ListDisks(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []internal.Disk{
+		{Identifier: "{serial}A1", Name: "sda", Serial: "A1", Model: "WDC WD80EFAX", Size: 8001563222016, Type: "HDD", Pool: "tank"},
+		{Identifier: "{serial}B2", Name: "nvme0n1", Serial: "B2", Type: "SSD"},
+	}
+	if !reflect.DeepEqual(disks, want) {
+		t.Errorf("got %+v, want %+v", disks, want)
+	}
+
+	p, ok := gotParams.([]any)
+	if !ok || len(p) != 2 {
+		t.Fatalf("unexpected params %v", gotParams)
+	}
+	opts, _ := p[1].(map[string]any)
+	if extra, _ := opts["extra"].(map[string]any); extra["pools"] != true {
+		t.Errorf("expected pools extra, got %v", p[1])
+	}
+}
+
+func TestDiskService_Temperatures(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "disk.temperatures" {
+				t.Errorf("expected disk.temperatures, got %s", method)
+			}
+			return json.RawMessage(`{"sda": 38, "sdb": null}`), nil
+		},
+	}
+
+	temps, err := internal.NewDiskService(mock, truenas.Version{}).Temperatures(context.Background(), []string{"sda", "sdb"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(temps) != 1 || temps["sda"] != 38 {
+		t.Errorf("unexpected temperatures %v", temps)
+	}
+}
+
+func TestDiskService_SmartAttributes(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "disk.smart_attributes" {
+				t.Errorf("expected disk.smart_attributes, got %s", method)
+			}
+			return json.RawMessage(`[
+				{"id": 5, "name": "Reallocated_Sector_Ct", "value": 100, "worst": 100, "thresh": 10, "when_failed": "", "raw": {"value": 0, "string": "0"}},
+				{"id": 197, "name": "Current_Pending_Sector", "value": 1, "worst": 1, "thresh": 0, "when_failed": "now", "raw": {"value": 24, "string": "24"}}
+			]`), nil
+		},
+	}
+
+	attrs, err := internal.NewDiskService(mock, truenas.Version{}).SmartAttributes(context.Background(), "sda")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(attrs) != 2 || attrs[0].Name != "Reallocated_Sector_Ct" || attrs[1].Raw != "24" {
+		t.Fatalf("unexpected attributes %+v", attrs)
+	}
+	if attrs[0].Failing() || !attrs[1].Failing() {
+		t.Error("expected only the failed attribute to be failing")
+	}
+}
+
+func TestDiskService_SmartTestResults(t *testing.T) {
+	var gotParams any
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "smart.test.results" {
+				t.Errorf("expected smart.test.results, got %s", method)
+			}
+			gotParams = params
+			return json.RawMessage(`[{"disk": "sda", "tests": [
+				{"num": 1, "description": "Short offline", "status": "RUNNING", "status_verbose": "Self-test routine in progress", "remaining": 60, "lifetime": 12000, "lba_of_first_error": null},
+				{"num": 2, "description": "Extended offline", "status": "FAILED", "status_verbose": "Completed: read failure", "remaining": 0, "lifetime": 11000, "lba_of_first_error": 123456}
+			]}]`), nil
+		},
+	}
+
+	results, err := internal.NewDiskService(mock, truenas.Version{}).SmartTestResults(context.Background(), []string{"sda"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := results["sda"]
+	if len(tests) != 2 {
+		t.Fatalf("expected 2 tests, got %d", len(tests))
+	}
+	if tests[0].Status != internal.SmartTestRunning || tests[0].Remaining != 60 || tests[0].LBAOfError != "" {
+		t.Errorf("unexpected running test %+v", tests[0])
+	}
+	if tests[1].LBAOfError != "123456" {
+		t.Errorf("unexpected failed test %+v", tests[1])
+	}
+	if f, ok := gotParams.([][]any); !ok || len(f) != 1 || f[0][1] != "in" {
+		t.Errorf("expected disk filter, got %v", gotParams)
+	}
+}
+
+func TestDiskService_StartSmartTest(t *testing.T) {
+	var gotParams any
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			if method != "smart.test.manual_test" {
+				t.Errorf("expected smart.test.manual_test, got %s", method)
+			}
+			gotParams = params
+			return json.RawMessage(`[{"disk": "sda", "identifier": "{serial}A1", "error": null}]`), nil
+		},
+	}
+
+	if err := internal.NewDiskService(mock, truenas.Version{}).StartSmartTest(context.Background(), "{serial}A1", internal.SmartTestLong); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []any{[]map[string]any{{"identifier": "{serial}A1", "type": "LONG"}}}
+	if !reflect.DeepEqual(gotParams, want) {
+		t.Errorf("got params %v, want %v", gotParams, want)
+	}
+}
+
+func TestDiskService_StartSmartTest_Error(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[{"disk": "sda", "error": "Test already in progress\n"}]`), nil
+		},
+	}
+	err := internal.NewDiskService(mock, truenas.Version{}).StartSmartTest(context.Background(), "{serial}A1", internal.SmartTestShort)
+	if err == nil || err.Error() != "Test already in progress" {
+		t.Errorf("expected the per-disk error, got %v", err)
+	}
+
+	mock.CallFunc = func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+		return nil, errors.New("connection lost")
+	}
+	err = internal.NewDiskService(mock, truenas.Version{}).StartSmartTest(context.Background(), "{serial}A1", internal.SmartTestShort)
+	if err == nil || err.Error() != "connection lost" {
+		t.Errorf("expected call error, got %v", err)
+	}
+}
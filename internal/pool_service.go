@@ -0,0 +1,319 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/deevus/truenas-go"
+)
+
+// PoolServiceAPI defines pool operations that truenas-go does not expose:
+// vdev topology, scrub/resilver status and scrub control.
+type PoolServiceAPI interface {
+	GetPool(ctx context.Context, id int64) (*PoolDetail, error)
+	ListPools(ctx context.Context) ([]PoolDetail, error)
+	Scrub(ctx context.Context, id int64, action ScrubAction) error
+}
+
+// Compile-time checks.
+var _ PoolServiceAPI = (*PoolService)(nil)
+var _ PoolServiceAPI = (*MockPoolService)(nil)
+
+// Scan functions and states reported in PoolScan.
+const (
+	ScanFunctionScrub    = "SCRUB"
+	ScanFunctionResilver = "RESILVER"
+
+	ScanStateScanning = "SCANNING"
+	ScanStateFinished = "FINISHED"
+	ScanStateCanceled = "CANCELED"
+)
+
+// ScrubAction is the action passed to pool.scrub.
+type ScrubAction string
+
+const (
+	ScrubStart ScrubAction = "START" // also resumes a paused scrub
+	ScrubPause ScrubAction = "PAUSE"
+	ScrubStop  ScrubAction = "STOP"
+)
+
+// PoolDetail is a pool with its vdev topology and last scan.
+type PoolDetail struct {
+	truenas.Pool
+	Healthy      bool
+	StatusDetail string
+	Topology     PoolTopology
+	Scan         *PoolScan // nil if the pool has never been scanned
+}
+
+// PoolTopology groups a pool's vdevs by role.
+type PoolTopology struct {
+	Data    []Vdev
+	Log     []Vdev
+	Cache   []Vdev
+	Spare   []Vdev
+	Special []Vdev
+	Dedup   []Vdev
+}
+
+// Vdev is a node in the pool topology: a group (MIRROR, RAIDZ1, ...) or a disk.
+type Vdev struct {
+	Name           string
+	Type           string
+	Status         string
+	Disk           string // device name for DISK vdevs, e.g. "sda"
+	ReadErrors     int64
+	WriteErrors    int64
+	ChecksumErrors int64
+	Children       []Vdev
+}
+
+// DisplayName returns the device name for disks and the vdev name otherwise.
+func (v Vdev) DisplayName() string {
+	if v.Disk != "" {
+		return v.Disk
+	}
+	return v.Name
+}
+
+// PoolScan is the state of the last or running scrub/resilver.
+type PoolScan struct {
+	Function       string
+	State          string
+	Percentage     float64
+	StartTime      time.Time
+	EndTime        time.Time
+	BytesToProcess int64
+	BytesProcessed int64
+	Errors         int64
+	Paused         bool
+	SecondsLeft    int64
+}
+
+// Running reports whether the scan is in progress (including paused).
+func (s *PoolScan) Running() bool {
+	return s != nil && s.State == ScanStateScanning
+}
+
+// PoolService provides typed methods for the pool.* API namespace.
+type PoolService struct {
+	client  truenas.AsyncCaller
+	version truenas.Version
+}
+
+// NewPoolService creates a PoolService for the given client and server version.
+func NewPoolService(c truenas.AsyncCaller, v truenas.Version) *PoolService {
+	return &PoolService{client: c, version: v}
+}
+
+// GetPool returns a pool with its topology and scan status, or nil if not found.
+func (s *PoolService) GetPool(ctx context.Context, id int64) (*PoolDetail, error) {
+	filter := [][]any{{"id", "=", id}}
+	result, err := s.client.Call(ctx, "pool.query", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []PoolDetailResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse query response: %w", err)
+	}
+	if len(responses) == 0 {
+		return nil, nil
+	}
+
+	detail := poolDetailFromResponse(responses[0])
+	return &detail, nil
+}
+
+// ListPools returns all pools with their topology and scan status.
+func (s *PoolService) ListPools(ctx context.Context) ([]PoolDetail, error) {
+	result, err := s.client.Call(ctx, "pool.query", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []PoolDetailResponse
+	if err := json.Unmarshal(result, &responses); err != nil {
+		return nil, fmt.Errorf("parse query response: %w", err)
+	}
+
+	pools := make([]PoolDetail, len(responses))
+	for i, resp := range responses {
+		pools[i] = poolDetailFromResponse(resp)
+	}
+	return pools, nil
+}
+
+// Scrub starts, pauses or stops a scrub. pool.scrub is a job; starting a
+// scrub blocks until the scrub finishes, so callers should run it in the
+// background.
+func (s *PoolService) Scrub(ctx context.Context, id int64, action ScrubAction) error {
+	_, err := s.client.CallAndWait(ctx, "pool.scrub", []any{id, string(action)})
+	return err
+}
+
+// PoolDetailResponse is the wire format of pool.query including topology and scan.
+type PoolDetailResponse struct {
+	truenas.PoolResponse
+	Healthy      bool                 `json:"healthy"`
+	StatusDetail *string              `json:"status_detail"`
+	Topology     PoolTopologyResponse `json:"topology"`
+	Scan         *PoolScanResponse    `json:"scan"`
+}
+
+// PoolTopologyResponse is the wire format of a pool's topology.
+type PoolTopologyResponse struct {
+	Data    []VdevResponse `json:"data"`
+	Log     []VdevResponse `json:"log"`
+	Cache   []VdevResponse `json:"cache"`
+	Spare   []VdevResponse `json:"spare"`
+	Special []VdevResponse `json:"special"`
+	Dedup   []VdevResponse `json:"dedup"`
+}
+
+// VdevResponse is the wire format of a topology node.
+type VdevResponse struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Status string  `json:"status"`
+	Disk   *string `json:"disk"`
+	Stats  struct {
+		ReadErrors     int64 `json:"read_errors"`
+		WriteErrors    int64 `json:"write_errors"`
+		ChecksumErrors int64 `json:"checksum_errors"`
+	} `json:"stats"`
+	Children []VdevResponse `json:"children"`
+}
+
+// PoolScanResponse is the wire format of a pool's scan status.
+type PoolScanResponse struct {
+	Function       string   `json:"function"`
+	State          string   `json:"state"`
+	Percentage     *float64 `json:"percentage"`
+	StartTime      apiTime  `json:"start_time"`
+	EndTime        apiTime  `json:"end_time"`
+	BytesToProcess int64    `json:"bytes_to_process"`
+	BytesProcessed int64    `json:"bytes_processed"`
+	Errors         int64    `json:"errors"`
+	Pause          apiTime  `json:"pause"`
+	TotalSecsLeft  *int64   `json:"total_secs_left"`
+}
+
+// apiTime decodes the {"$date": <unix millis>} timestamps used by the
+// middleware. null decodes to the zero time.
+type apiTime struct {
+	time.Time
+}
+
+func (t *apiTime) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	var v struct {
+		Date int64 `json:"$date"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	t.Time = time.UnixMilli(v.Date)
+	return nil
+}
+
+func poolDetailFromResponse(resp PoolDetailResponse) PoolDetail {
+	d := PoolDetail{
+		Pool: truenas.Pool{
+			ID:        resp.ID,
+			Name:      resp.Name,
+			Path:      resp.Path,
+			Status:    resp.Status,
+			Size:      resp.Size,
+			Allocated: resp.Allocated,
+			Free:      resp.Free,
+		},
+		Healthy: resp.Healthy,
+		Topology: PoolTopology{
+			Data:    vdevsFromResponse(resp.Topology.Data),
+			Log:     vdevsFromResponse(resp.Topology.Log),
+			Cache:   vdevsFromResponse(resp.Topology.Cache),
+			Spare:   vdevsFromResponse(resp.Topology.Spare),
+			Special: vdevsFromResponse(resp.Topology.Special),
+			Dedup:   vdevsFromResponse(resp.Topology.Dedup),
+		},
+	}
+	if resp.StatusDetail != nil {
+		d.StatusDetail = *resp.StatusDetail
+	}
+	if s := resp.Scan; s != nil && s.Function != "" {
+		d.Scan = &PoolScan{
+			Function:       s.Function,
+			State:          s.State,
+			StartTime:      s.StartTime.Time,
+			EndTime:        s.EndTime.Time,
+			BytesToProcess: s.BytesToProcess,
+			BytesProcessed: s.BytesProcessed,
+			Errors:         s.Errors,
+			Paused:         !s.Pause.IsZero(),
+		}
+		if s.Percentage != nil {
+			d.Scan.Percentage = *s.Percentage
+		}
+		if s.TotalSecsLeft != nil {
+			d.Scan.SecondsLeft = *s.TotalSecsLeft
+		}
+	}
+	return d
+}
+
+func vdevsFromResponse(resps []VdevResponse) []Vdev {
+	if len(resps) == 0 {
+		return nil
+	}
+	vdevs := make([]Vdev, len(resps))
+	for i, r := range resps {
+		vdevs[i] = Vdev{
+			Name:           r.Name,
+			Type:           r.Type,
+			Status:         r.Status,
+			ReadErrors:     r.Stats.ReadErrors,
+			WriteErrors:    r.Stats.WriteErrors,
+			ChecksumErrors: r.Stats.ChecksumErrors,
+			Children:       vdevsFromResponse(r.Children),
+		}
+		if r.Disk != nil {
+			vdevs[i].Disk = *r.Disk
+		}
+	}
+	return vdevs
+}
+
+// MockPoolService is a test double for PoolServiceAPI.
+type MockPoolService struct {
+	GetPoolFunc   func(ctx context.Context, id int64) (*PoolDetail, error)
+	ListPoolsFunc func(ctx context.Context) ([]PoolDetail, error)
+	ScrubFunc     func(ctx context.Context, id int64, action ScrubAction) error
+}
+
+func (m *MockPoolService) GetPool(ctx context.Context, id int64) (*PoolDetail, error) {
+	if m.GetPoolFunc != nil {
+		return m.GetPoolFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockPoolService) ListPools(ctx context.Context) ([]PoolDetail, error) {
+	if m.ListPoolsFunc != nil {
+		return m.ListPoolsFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockPoolService) Scrub(ctx context.Context, id int64, action ScrubAction) error {
+	if m.ScrubFunc != nil {
+		return m.ScrubFunc(ctx, id, action)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/internal"
+)
+
+const poolQueryResponse = `[{
+	"id": 1,
+	"name": "tank",
+	"path": "/mnt/tank",
+	"status": "DEGRADED",
+	"healthy": false,
+	"status_detail": "One or more devices has experienced an error",
+	"size": 1000,
+	"allocated": 400,
+	"free": 600,
+	"topology": {
+		"data": [{
+			"name": "mirror-0",
+			"type": "MIRROR",
+			"status": "DEGRADED",
+			"disk": null,
+			"stats": {"read_errors": 0, "write_errors": 0, "checksum_errors": 0},
+			"children": [
+				{"name": "a1b2", "type": "DISK", "status": "ONLINE", "disk": "sda",
+				 "stats": {"read_errors": 0, "write_errors": 0, "checksum_errors": 0}, "children": []},
+				{"name": "c3d4", "type": "DISK", "status": "FAULTED", "disk": "sdb",
+				 "stats": {"read_errors": 3, "write_errors": 1, "checksum_errors": 7}, "children": []}
+			]
+		}],
+		"log": [],
+		"cache": [{"name": "e5f6", "type": "DISK", "status": "ONLINE", "disk": "nvme0n1",
+			"stats": {"read_errors": 0, "write_errors": 0, "checksum_errors": 0}, "children": []}],
+		"spare": [],
+		"special": [],
+		"dedup": []
+	},
+	"scan": {
+		"function": "RESILVER",
+		"state": "SCANNING",
+		"percentage": 42.5,
+		"start_time": {"$date": 1760000000000},
+		"end_time": null,
+		"bytes_to_process": 400,
+		"bytes_processed": 170,
+		"errors": 0,
+		"pause": null,
+		"total_secs_left": 600
+	}
+}]`
+
+func TestPoolService_GetPool(t *testing.T) {
+	var gotMethod string
+	var gotParams any
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			gotMethod, gotParams = method, params
+			return json.RawMessage(poolQueryResponse), nil
+		},
+	}
+	svc := internal.NewPoolService(mock, truenas.Version{Major: 25, Minor: 4})
+
+	pool, err := svc.GetPool(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != "pool.query" {
+		t.Errorf("expected pool.query, got %s", gotMethod)
+	}
+	if f, ok := gotParams.([][]any); !ok || f[0][2] != int64(1) {
+		t.Errorf("expected id filter, got %v", gotParams)
+	}
+
+	if pool.Name != "tank" || pool.Healthy || pool.StatusDetail == "" {
+		t.Errorf("unexpected pool header: %+v", pool.Pool)
+	}
+	if len(pool.Topology.Data) != 1 || len(pool.Topology.Data[0].Children) != 2 {
+		t.Fatalf("unexpected data topology: %+v", pool.Topology.Data)
+	}
+	bad := pool.Topology.Data[0].Children[1]
+	if bad.DisplayName() != "sdb" || bad.ReadErrors != 3 || bad.WriteErrors != 1 || bad.ChecksumErrors != 7 {
+		t.Errorf("unexpected disk vdev: %+v", bad)
+	}
+	if pool.Topology.Data[0].DisplayName() != "mirror-0" {
+		t.Errorf("expected group vdev to keep its name, got %s", pool.Topology.Data[0].DisplayName())
+	}
+	if len(pool.Topology.Cache) != 1 || pool.Topology.Log != nil {
+		t.Errorf("unexpected aux vdevs: %+v", pool.Topology)
+	}
+
+	scan := pool.Scan
+	if !scan.Running() || scan.Function != internal.ScanFunctionResilver {
+		t.Fatalf("expected running resilver, got %+v", scan)
+	}
+	if scan.Percentage != 42.5 || scan.SecondsLeft != 600 || scan.Paused {
+		t.Errorf("unexpected scan progress: %+v", scan)
+	}
+	if !scan.StartTime.Equal(time.UnixMilli(1760000000000)) || !scan.EndTime.IsZero() {
+		t.Errorf("unexpected scan times: %v - %v", scan.StartTime, scan.EndTime)
+	}
+}
+
+func TestPoolService_GetPool_NotFound(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	pool, err := internal.NewPoolService(mock, truenas.Version{}).GetPool(context.Background(), 9)
+	if err != nil || pool != nil {
+		t.Errorf("expected nil, nil; got %v, %v", pool, err)
+	}
+}
+
+func TestPoolService_GetPool_Error(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	if _, err := internal.NewPoolService(mock, truenas.Version{}).GetPool(context.Background(), 1); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestPoolService_GetPool_NeverScanned(t *testing.T) {
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return json.RawMessage(`[{"id": 2, "name": "new", "status": "ONLINE", "healthy": true, "topology": {}, "scan": null}]`), nil
+		},
+	}
+	pool, err := internal.NewPoolService(mock, truenas.Version{}).GetPool(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.Scan != nil || pool.Scan.Running() {
+		t.Errorf("expected no scan, got %+v", pool.Scan)
+	}
+}
+
+func TestPoolService_ListPools(t *testing.T) {
+	var gotParams any = "unset"
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			gotParams = params
+			return json.RawMessage(poolQueryResponse), nil
+		},
+	}
+	pools, err := internal.NewPoolService(mock, truenas.Version{}).ListPools(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotParams != nil {
+		t.Errorf("expected no filter, got %v", gotParams)
+	}
+	if len(pools) != 1 || !pools[0].Scan.Running() {
+		t.Errorf("expected one pool with a running scan, got %+v", pools)
+	}
+}
+
+func TestPoolService_Scrub(t *testing.T) {
+	for _, action := range []internal.ScrubAction{internal.ScrubStart, internal.ScrubPause, internal.ScrubStop} {
+		var gotMethod string
+		var gotParams any
+		mock := &client.MockClient{
+			CallAndWaitFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+				gotMethod, gotParams = method, params
+				return json.RawMessage(`null`), nil
+			},
+		}
+		if err := internal.NewPoolService(mock, truenas.Version{}).Scrub(context.Background(), 3, action); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if gotMethod != "pool.scrub" {
+			t.Errorf("expected pool.scrub, got %s", gotMethod)
+		}
+		p, ok := gotParams.([]any)
+		if !ok || p[0] != int64(3) || p[1] != string(action) {
+			t.Errorf("unexpected params for %s: %v", action, gotParams)
+		}
+	}
+}
+
+func TestPoolService_Scrub_JobFailure(t *testing.T) {
+	mock := &client.MockClient{
+		CallAndWaitFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			return nil, errors.New("job failed: pool is busy")
+		},
+	}
+	err := internal.NewPoolService(mock, truenas.Version{}).Scrub(context.Background(), 1, internal.ScrubStart)
+	if err == nil || err.Error() != "job failed: pool is busy" {
+		t.Errorf("expected job error to be surfaced, got %v", err)
+	}
+}
@@ -5,21 +5,29 @@ import "github.com/deevus/truenas-go"
 // Services holds initialized truenas-go service interfaces for one server.
 type Services struct {
 	Datasets   truenas.DatasetServiceAPI
-	Snapshots  truenas.SnapshotServiceAPI
+	Snapshots  SnapshotServiceAPI
 	System     truenas.SystemServiceAPI
 	Reporting  truenas.ReportingServiceAPI
 	Interfaces truenas.InterfaceServiceAPI
 	Apps       truenas.AppServiceAPI
+	Pools      PoolServiceAPI
+	Alerts     AlertServiceAPI
+	Disks      DiskServiceAPI
+	Conn       ConnServiceAPI
 }
 
 // NewServices creates a Services container from the given service interfaces.
 func NewServices(
 	ds truenas.DatasetServiceAPI,
-	ss truenas.SnapshotServiceAPI,
+	ss SnapshotServiceAPI,
 	sys truenas.SystemServiceAPI,
 	rep truenas.ReportingServiceAPI,
 	ifaces truenas.InterfaceServiceAPI,
 	apps truenas.AppServiceAPI,
+	pools PoolServiceAPI,
+	alerts AlertServiceAPI,
+	disks DiskServiceAPI,
+	conn ConnServiceAPI,
 ) *Services {
 	return &Services{
 		Datasets:   ds,
@@ -28,5 +36,9 @@ func NewServices(
 		Reporting:  rep,
 		Interfaces: ifaces,
 		Apps:       apps,
+		Pools:      pools,
+		Alerts:     alerts,
+		Disks:      disks,
+		Conn:       conn,
 	}
 }
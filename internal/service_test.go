@@ -10,11 +10,15 @@ import (
 func TestNewServices(t *testing.T) {
 	svc := internal.NewServices(
 		&truenas.MockDatasetService{},
-		&truenas.MockSnapshotService{},
+		&internal.MockSnapshotService{},
 		&truenas.MockSystemService{},
 		&truenas.MockReportingService{},
 		&truenas.MockInterfaceService{},
 		&truenas.MockAppService{},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
 	)
 
 	if svc.Datasets == nil {
@@ -35,4 +39,16 @@ func TestNewServices(t *testing.T) {
 	if svc.Apps == nil {
 		t.Fatal("expected Apps service")
 	}
+	if svc.Pools == nil {
+		t.Fatal("expected Pools service")
+	}
+	if svc.Alerts == nil {
+		t.Fatal("expected Alerts service")
+	}
+	if svc.Disks == nil {
+		t.Fatal("expected Disks service")
+	}
+	if svc.Conn == nil {
+		t.Fatal("expected Conn service")
+	}
 }
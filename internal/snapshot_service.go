@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+
+	"github.com/deevus/truenas-go"
+)
+
+// RollbackSnapshotOpts contains options for rolling a dataset back to a snapshot.
+type RollbackSnapshotOpts struct {
+	// DestroyNewer destroys any snapshots more recent than the target, which
+	// ZFS requires before it will roll back past them.
+	DestroyNewer bool
+}
+
+// SnapshotServiceAPI extends truenas.SnapshotServiceAPI with rollback, which
+// truenas-go does not expose.
+type SnapshotServiceAPI interface {
+	truenas.SnapshotServiceAPI
+	Rollback(ctx context.Context, id string, opts RollbackSnapshotOpts) error
+}
+
+// Compile-time checks.
+var _ SnapshotServiceAPI = (*SnapshotService)(nil)
+var _ SnapshotServiceAPI = (*MockSnapshotService)(nil)
+
+// SnapshotService wraps truenas.SnapshotService and adds rollback.
+type SnapshotService struct {
+	*truenas.SnapshotService
+	client  truenas.Caller
+	version truenas.Version
+}
+
+// NewSnapshotService creates a SnapshotService for the given client and server version.
+func NewSnapshotService(c truenas.Caller, v truenas.Version) *SnapshotService {
+	return &SnapshotService{
+		SnapshotService: truenas.NewSnapshotService(c, v),
+		client:          c,
+		version:         v,
+	}
+}
+
+// Rollback rolls the snapshot's dataset back to the snapshot.
+func (s *SnapshotService) Rollback(ctx context.Context, id string, opts RollbackSnapshotOpts) error {
+	params := map[string]any{}
+	if opts.DestroyNewer {
+		params["recursive"] = true
+	}
+	_, err := s.client.Call(ctx, resolveSnapshotMethod(s.version, "rollback"), []any{id, params})
+	return err
+}
+
+// resolveSnapshotMethod returns the full API method name for the given version.
+// Pre-25.10 uses "zfs.snapshot.*", 25.10+ uses "pool.snapshot.*".
+func resolveSnapshotMethod(v truenas.Version, method string) string {
+	prefix := "zfs.snapshot"
+	if v.AtLeast(25, 10) {
+		prefix = "pool.snapshot"
+	}
+	return prefix + "." + method
+}
+
+// MockSnapshotService is a test double for SnapshotServiceAPI.
+type MockSnapshotService struct {
+	CreateFunc   func(ctx context.Context, opts truenas.CreateSnapshotOpts) (*truenas.Snapshot, error)
+	GetFunc      func(ctx context.Context, id string) (*truenas.Snapshot, error)
+	ListFunc     func(ctx context.Context) ([]truenas.Snapshot, error)
+	DeleteFunc   func(ctx context.Context, id string) error
+	HoldFunc     func(ctx context.Context, id string) error
+	ReleaseFunc  func(ctx context.Context, id string) error
+	CloneFunc    func(ctx context.Context, snapshot, datasetDst string) error
+	RollbackFunc func(ctx context.Context, id string, opts RollbackSnapshotOpts) error
+}
+
+func (m *MockSnapshotService) Create(ctx context.Context, opts truenas.CreateSnapshotOpts) (*truenas.Snapshot, error) {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ctx, opts)
+	}
+	return nil, nil
+}
+
+func (m *MockSnapshotService) Get(ctx context.Context, id string) (*truenas.Snapshot, error) {
+	if m.GetFunc != nil {
+		return m.GetFunc(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockSnapshotService) List(ctx context.Context) ([]truenas.Snapshot, error) {
+	if m.ListFunc != nil {
+		return m.ListFunc(ctx)
+	}
+	return nil, nil
+}
+
+func (m *MockSnapshotService) Delete(ctx context.Context, id string) error {
+	if m.DeleteFunc != nil {
+		return m.DeleteFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockSnapshotService) Hold(ctx context.Context, id string) error {
+	if m.HoldFunc != nil {
+		return m.HoldFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockSnapshotService) Release(ctx context.Context, id string) error {
+	if m.ReleaseFunc != nil {
+		return m.ReleaseFunc(ctx, id)
+	}
+	return nil
+}
+
+func (m *MockSnapshotService) Clone(ctx context.Context, snapshot, datasetDst string) error {
+	if m.CloneFunc != nil {
+		return m.CloneFunc(ctx, snapshot, datasetDst)
+	}
+	return nil
+}
+
+func (m *MockSnapshotService) Rollback(ctx context.Context, id string, opts RollbackSnapshotOpts) error {
+	if m.RollbackFunc != nil {
+		return m.RollbackFunc(ctx, id, opts)
+	}
+	return nil
+}
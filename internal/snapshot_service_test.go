@@ -0,0 +1,77 @@
+package internal_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-go/client"
+	"github.com/deevus/truenas-tui/internal"
+)
+
+func TestSnapshotService_Rollback(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    truenas.Version
+		opts       internal.RollbackSnapshotOpts
+		wantMethod string
+		wantParams []any
+	}{
+		{
+			name:       "25.04 uses zfs.snapshot",
+			version:    truenas.Version{Major: 25, Minor: 4},
+			wantMethod: "zfs.snapshot.rollback",
+			wantParams: []any{"tank/data@snap1", map[string]any{}},
+		},
+		{
+			name:       "25.10 uses pool.snapshot and destroys newer",
+			version:    truenas.Version{Major: 25, Minor: 10},
+			opts:       internal.RollbackSnapshotOpts{DestroyNewer: true},
+			wantMethod: "pool.snapshot.rollback",
+			wantParams: []any{"tank/data@snap1", map[string]any{"recursive": true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod string
+			var gotParams any
+			mock := &client.MockClient{
+				CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+					gotMethod = method
+					gotParams = params
+					return json.RawMessage(`true`), nil
+				},
+			}
+			svc := internal.NewSnapshotService(mock, tt.version)
+			if err := svc.Rollback(context.Background(), "tank/data@snap1", tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotMethod != tt.wantMethod {
+				t.Errorf("expected method %s, got %s", tt.wantMethod, gotMethod)
+			}
+			if !reflect.DeepEqual(gotParams, tt.wantParams) {
+				t.Errorf("expected params %v, got %v", tt.wantParams, gotParams)
+			}
+		})
+	}
+}
+
+func TestSnapshotService_DelegatesToTruenasGo(t *testing.T) {
+	var gotMethod string
+	mock := &client.MockClient{
+		CallFunc: func(ctx context.Context, method string, params any) (json.RawMessage, error) {
+			gotMethod = method
+			return json.RawMessage(`[]`), nil
+		},
+	}
+	svc := internal.NewSnapshotService(mock, truenas.Version{Major: 25, Minor: 4})
+	if _, err := svc.List(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != "zfs.snapshot.query" {
+		t.Errorf("expected zfs.snapshot.query, got %s", gotMethod)
+	}
+}
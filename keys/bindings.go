@@ -0,0 +1,191 @@
+package keys
+
+import "git.sr.ht/~rockorager/vaxis"
+
+func k(code rune, mods ...vaxis.ModifierMask) Key {
+	key := Key{Code: code}
+	for _, m := range mods {
+		key.Mods |= m
+	}
+	return key
+}
+
+func bind(action, help string, keys ...Key) *Binding {
+	return &Binding{Action: action, Help: help, Keys: keys}
+}
+
+// Global bindings, handled by the App whatever is on screen.
+var (
+	Quit         = bind("quit", "Quit", k('q'))
+	Help         = bind("help", "Show this help", k('?'))
+	Palette      = bind("palette", "Command palette", k(':'), k('p', vaxis.ModCtrl))
+	Console      = bind("console", "Open / close the log console", k('~'))
+	SwitchServer = bind("switch_server", "Switch server", k('s', vaxis.ModCtrl))
+	Fleet        = bind("fleet", "Fleet overview of all servers", k('f', vaxis.ModCtrl))
+	Refresh      = bind("refresh", "Refresh the current view, or retry the connection", k('r'))
+	NextTab      = bind("next_tab", "Next tab", k(vaxis.KeyTab))
+	PrevTab      = bind("prev_tab", "Previous tab", k(vaxis.KeyTab, vaxis.ModShift))
+	Tab1         = bind("tab_1", "Dashboard tab", k('1'))
+	Tab2         = bind("tab_2", "Pools tab", k('2'))
+	Tab3         = bind("tab_3", "Datasets tab", k('3'))
+	Tab4         = bind("tab_4", "Snapshots tab", k('4'))
+	Tab5         = bind("tab_5", "Alerts tab", k('5'))
+	Tab6         = bind("tab_6", "Disks tab", k('6'))
+)
+
+// Tabs are the tab bindings, indexed by tab.
+var Tabs = []*Binding{Tab1, Tab2, Tab3, Tab4, Tab5, Tab6}
+
+// List bindings, shared by every list.
+var (
+	Down        = bind("down", "Move down", k('j'), k(vaxis.KeyDown))
+	Up          = bind("up", "Move up", k('k'), k(vaxis.KeyUp))
+	Filter      = bind("filter", "Filter (Enter keeps it, Esc clears it)", k('/'))
+	ClearFilter = bind("clear_filter", "Clear the filter", k(vaxis.KeyEsc))
+	Sort        = bind("sort", "Sort by the next column", k('s'))
+	ReverseSort = bind("reverse_sort", "Reverse the sort order", k('S'))
+)
+
+// Scrolling bindings, shared by the log pane, the console and the help.
+var (
+	PageDown = bind("page_down", "Page down", k(vaxis.KeyPgDown), k(vaxis.KeySpace), k('d', vaxis.ModCtrl))
+	PageUp   = bind("page_up", "Page up", k(vaxis.KeyPgUp), k('u', vaxis.ModCtrl))
+	Top      = bind("top", "Jump to the top", k('g'), k(vaxis.KeyHome))
+	Bottom   = bind("bottom", "Jump to the bottom and follow", k('G'), k(vaxis.KeyEnd))
+)
+
+// Detail pane bindings, shared by the Pools and Disks tabs.
+var (
+	ToggleDetail = bind("toggle_detail", "Open / close the detail pane", k(vaxis.KeyEnter))
+	CloseDetail  = bind("close_detail", "Close the detail pane", k(vaxis.KeyEsc))
+)
+
+// Dashboard bindings.
+var (
+	StartApp    = bind("start_app", "Start the selected app", k('s'))
+	StopApp     = bind("stop_app", "Stop the selected app", k('S'))
+	RestartApp  = bind("restart_app", "Restart the selected app", k('R'))
+	RedeployApp = bind("redeploy_app", "Redeploy the selected app", k('D'))
+	AppLogs     = bind("app_logs", "Open the selected app's container logs", k('L'))
+)
+
+// Log pane bindings.
+var (
+	CloseLogs    = bind("close_logs", "Close the log pane", k('q'), k(vaxis.KeyEsc))
+	LogFollow    = bind("log_follow", "Toggle follow", k('f'))
+	LogPause     = bind("log_pause", "Pause / resume", k('p'))
+	LogSearch    = bind("log_search", "Search (Enter jumps to the first match, Esc clears)", k('/'))
+	NextMatch    = bind("next_match", "Next match", k('n'))
+	PrevMatch    = bind("prev_match", "Previous match", k('N'))
+	LogContainer = bind("log_container", "Choose another container", k('c'))
+	LogReconnect = bind("log_reconnect", "Reconnect after the stream ended", k('r'))
+)
+
+// Pools bindings.
+var (
+	StartScrub  = bind("start_scrub", "Start a scrub on the selected pool", k('x'))
+	PauseScrub  = bind("pause_scrub", "Pause / resume the running scrub", k('p'))
+	CancelScrub = bind("cancel_scrub", "Cancel the running scrub", k('X'))
+)
+
+// Datasets bindings.
+var (
+	Expand      = bind("expand", "Expand dataset (or move to first child)", k('l'), k(vaxis.KeyRight))
+	Collapse    = bind("collapse", "Collapse dataset (or move to parent)", k('h'), k(vaxis.KeyLeft))
+	ToggleTree  = bind("toggle_expand", "Toggle expand / collapse", k(vaxis.KeyEnter))
+	ExpandAll   = bind("expand_all", "Expand all", k('L'), k('l', vaxis.ModShift))
+	CollapseAll = bind("collapse_all", "Collapse all", k('H'), k('h', vaxis.ModShift))
+)
+
+// Snapshots bindings.
+var (
+	CreateSnapshot  = bind("create_snapshot", "Create a snapshot", k('c'))
+	DestroySnapshot = bind("destroy_snapshot", "Destroy the selected snapshot", k('d'))
+	HoldSnapshot    = bind("hold_snapshot", "Place / release a hold", k('H'))
+	Rollback        = bind("rollback", "Roll back the dataset to the selected snapshot", k('R'))
+	CloneSnapshot   = bind("clone_snapshot", "Clone the selected snapshot", k('C'))
+)
+
+// Alerts bindings.
+var (
+	DismissAlert = bind("dismiss_alert", "Dismiss the selected alert", k('d'))
+	RestoreAlert = bind("restore_alert", "Restore a dismissed alert", k('u'))
+)
+
+// Disks bindings.
+var (
+	ShortTest = bind("short_test", "Start a short SMART test", k('t'))
+	LongTest  = bind("long_test", "Start a long SMART test", k('T'))
+)
+
+// Fleet bindings.
+var OpenServer = bind("open_server", "Open the selected server's tabs", k(vaxis.KeyEnter))
+
+// Console and help bindings.
+var (
+	ConsoleFilter = bind("console_filter", "Cycle the filter: all, warnings and errors, errors only", k('f'))
+	CloseConsole  = bind("close_console", "Close the console", k('~'), k(vaxis.KeyEsc), k('q'))
+	CloseHelp     = bind("close_help", "Close the help", k('?'), k(vaxis.KeyEsc), k('q'))
+)
+
+// Dialog bindings, shared by the confirmation dialogs and the pickers.
+var (
+	Confirm = bind("confirm", "Confirm", k('y'), k(vaxis.KeyEnter))
+	Cancel  = bind("cancel", "Cancel", k('n'), k(vaxis.KeyEsc))
+	Select  = bind("select", "Pick the highlighted item", k(vaxis.KeyEnter))
+)
+
+// Scopes, in the order the help lists them.
+var (
+	GlobalScope    = &Scope{Name: "Global", Bindings: []*Binding{Quit, Help, Palette, Console, SwitchServer, Fleet, Refresh, Tab1, Tab2, Tab3, Tab4, Tab5, Tab6, NextTab, PrevTab}}
+	ListScope      = &Scope{Name: "Lists", Bindings: []*Binding{Down, Up}}
+	QueryScope     = &Scope{Name: "Sorting and filtering", Bindings: []*Binding{Filter, ClearFilter, Sort, ReverseSort}}
+	DashboardScope = &Scope{Name: "Dashboard", Bindings: []*Binding{StartApp, StopApp, RestartApp, RedeployApp, AppLogs}}
+	LogsScope      = &Scope{Name: "Log pane", Bindings: []*Binding{Down, Up, PageDown, PageUp, Top, Bottom, LogFollow, LogPause, LogSearch, NextMatch, PrevMatch, LogContainer, LogReconnect, CloseLogs}}
+	PoolsScope     = &Scope{Name: "Pools", Bindings: []*Binding{ToggleDetail, CloseDetail, StartScrub, PauseScrub, CancelScrub}}
+	DatasetsScope  = &Scope{Name: "Datasets", Bindings: []*Binding{Expand, Collapse, ToggleTree, ExpandAll, CollapseAll}}
+	SnapshotsScope = &Scope{Name: "Snapshots", Bindings: []*Binding{CreateSnapshot, DestroySnapshot, HoldSnapshot, Rollback, CloneSnapshot}}
+	AlertsScope    = &Scope{Name: "Alerts", Bindings: []*Binding{DismissAlert, RestoreAlert}}
+	DisksScope     = &Scope{Name: "Disks", Bindings: []*Binding{ToggleDetail, CloseDetail, ShortTest, LongTest}}
+	FleetScope     = &Scope{Name: "Fleet", Bindings: []*Binding{OpenServer}}
+	ConsoleScope   = &Scope{Name: "Console", Bindings: []*Binding{Down, Up, PageDown, PageUp, Top, Bottom, ConsoleFilter, CloseConsole}}
+	HelpScope      = &Scope{Name: "Help", Bindings: []*Binding{Down, Up, PageDown, PageUp, Top, Bottom, CloseHelp}}
+	ConfirmScope   = &Scope{Name: "Confirmations", Bindings: []*Binding{Confirm, Cancel}}
+	PickerScope    = &Scope{Name: "Pickers", Bindings: []*Binding{Down, Up, Select, Cancel}}
+)
+
+// scopes lists every scope, so every binding can be found by action.
+var scopes = []*Scope{
+	GlobalScope, ListScope, QueryScope, DashboardScope, LogsScope, PoolsScope, DatasetsScope,
+	SnapshotsScope, AlertsScope, DisksScope, FleetScope, ConsoleScope, HelpScope, ConfirmScope,
+	PickerScope,
+}
+
+// contexts are the scopes that are live at the same time, e.g. on the Pools
+// tab. A key may only trigger one action in each. The log pane, console,
+// help and dialogs take every key while open, so the global bindings don't
+// apply there.
+var contexts = []struct {
+	name   string
+	scopes []*Scope
+}{
+	{"Dashboard", []*Scope{GlobalScope, DashboardScope, ListScope}},
+	{"Pools", []*Scope{GlobalScope, PoolsScope, QueryScope, ListScope}},
+	{"Datasets", []*Scope{GlobalScope, DatasetsScope, QueryScope, ListScope}},
+	{"Snapshots", []*Scope{GlobalScope, SnapshotsScope, QueryScope, ListScope}},
+	{"Alerts", []*Scope{GlobalScope, AlertsScope, QueryScope, ListScope}},
+	{"Disks", []*Scope{GlobalScope, DisksScope, QueryScope, ListScope}},
+	{"Fleet", []*Scope{GlobalScope, FleetScope, ListScope}},
+	{"Log pane", []*Scope{LogsScope}},
+	{"Console", []*Scope{ConsoleScope}},
+	{"Help", []*Scope{HelpScope}},
+	{"Confirmations", []*Scope{ConfirmScope}},
+	{"Pickers", []*Scope{PickerScope}},
+}
+
+// shared are pairs of actions that may share a key because the handler
+// picks one by state: Esc clears the filter if there is one, and otherwise
+// closes the detail pane.
+var shared = [][2]*Binding{
+	{ClearFilter, CloseDetail},
+}
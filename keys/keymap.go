@@ -0,0 +1,215 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// keyAliases are extra names ParseKey accepts, besides those in keyNames.
+var keyAliases = map[string]rune{
+	"escape":   vaxis.KeyEsc,
+	"return":   vaxis.KeyEnter,
+	"pagedown": vaxis.KeyPgDown,
+	"pgdown":   vaxis.KeyPgDown,
+	"pageup":   vaxis.KeyPgUp,
+	"del":      vaxis.KeyDelete,
+	"ins":      vaxis.KeyInsert,
+}
+
+var modNames = map[string]vaxis.ModifierMask{
+	"ctrl":    vaxis.ModCtrl,
+	"control": vaxis.ModCtrl,
+	"alt":     vaxis.ModAlt,
+	"shift":   vaxis.ModShift,
+	"super":   vaxis.ModSuper,
+}
+
+// ParseKey parses a key as written in the config, e.g. "x", "S",
+// "ctrl+s", "shift+tab" or "pgdn". Modifier and key names are
+// case-insensitive; single characters are taken as is.
+func ParseKey(s string) (Key, error) {
+	if s == "" {
+		return Key{}, errors.New("empty key")
+	}
+	// The last character is always the key, so "ctrl++" is Ctrl and +.
+	name, prefix := s, ""
+	if i := strings.LastIndex(s[:len(s)-1], "+"); i >= 0 {
+		name, prefix = s[i+1:], s[:i]
+	}
+
+	var k Key
+	if prefix != "" {
+		for _, m := range strings.Split(prefix, "+") {
+			mod, ok := modNames[strings.ToLower(m)]
+			if !ok {
+				return Key{}, fmt.Errorf("unknown modifier %q in %q", m, s)
+			}
+			k.Mods |= mod
+		}
+	}
+
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) {
+		k.Code = r
+	} else if r, ok := keyAliases[strings.ToLower(name)]; ok {
+		k.Code = r
+	} else {
+		for code, n := range keyNames {
+			if strings.EqualFold(n, name) {
+				k.Code, ok = code, true
+				break
+			}
+		}
+		if !ok {
+			return Key{}, fmt.Errorf("unknown key %q in %q", name, s)
+		}
+	}
+
+	// Write shifted letters the way the defaults do: S on its own, and
+	// Ctrl+Shift+s rather than Ctrl+S with a capital.
+	switch {
+	case k.Mods == vaxis.ModShift && unicode.IsLower(k.Code):
+		k.Code, k.Mods = unicode.ToUpper(k.Code), 0
+	case k.Mods != 0 && unicode.IsUpper(k.Code):
+		k.Code, k.Mods = unicode.ToLower(k.Code), k.Mods|vaxis.ModShift
+	}
+	return k, nil
+}
+
+// Keymap maps actions to the keys that trigger them, replacing their
+// default keys.
+type Keymap map[string][]Key
+
+// bindings returns every registered binding, keyed by action.
+func bindings() map[string]*Binding {
+	all := map[string]*Binding{}
+	for _, scope := range scopes {
+		for _, b := range scope.Bindings {
+			all[b.Action] = b
+		}
+	}
+	return all
+}
+
+// Actions returns the name of every action that can be remapped, sorted.
+func Actions() []string {
+	var actions []string
+	for action := range bindings() {
+		actions = append(actions, action)
+	}
+	slices.Sort(actions)
+	return actions
+}
+
+// defaults holds each binding's built-in keys, so Apply can start over.
+var defaults = func() map[*Binding][]Key {
+	m := map[*Binding][]Key{}
+	for _, b := range bindings() {
+		m[b] = b.Keys
+	}
+	return m
+}()
+
+// Resolve parses remapped keys from the config, keyed by action. It
+// reports unknown actions, keys it can't parse and keys that would trigger
+// two actions at once, all together.
+func Resolve(remap map[string][]string) (Keymap, error) {
+	all := bindings()
+	km := Keymap{}
+	var errs []error
+	for _, action := range sortedKeys(remap) {
+		if _, ok := all[action]; !ok {
+			errs = append(errs, fmt.Errorf("unknown action %q", action))
+			continue
+		}
+		parsed := []Key{}
+		for _, s := range remap[action] {
+			k, err := ParseKey(s)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", action, err))
+				continue
+			}
+			parsed = append(parsed, k)
+		}
+		km[action] = parsed
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if err := km.conflicts(); err != nil {
+		return nil, err
+	}
+	return km, nil
+}
+
+// keysOf returns the keys of b with km applied.
+func (km Keymap) keysOf(b *Binding) []Key {
+	if k, ok := km[b.Action]; ok {
+		return k
+	}
+	return defaults[b]
+}
+
+// conflicts reports keys bound to two actions that are live at the same
+// time, other than the pairs in shared.
+func (km Keymap) conflicts() error {
+	var errs []error
+	reported := map[string]bool{}
+	for _, ctx := range contexts {
+		owner := map[string]*Binding{}
+		for _, scope := range ctx.scopes {
+			for _, b := range scope.Bindings {
+				for _, k := range km.keysOf(b) {
+					key := k.String()
+					other, ok := owner[key]
+					if !ok {
+						owner[key] = b
+						continue
+					}
+					if other == b || isShared(other, b) {
+						continue
+					}
+					msg := fmt.Sprintf("%s is bound to both %s and %s (%s)", key, other.Action, b.Action, ctx.name)
+					id := key + " " + other.Action + " " + b.Action
+					if !reported[id] {
+						reported[id] = true
+						errs = append(errs, errors.New(msg))
+					}
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isShared(a, b *Binding) bool {
+	for _, pair := range shared {
+		if pair[0] == a && pair[1] == b || pair[0] == b && pair[1] == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply replaces the keys of the bindings in km and restores every other
+// binding to its defaults. Call it before the UI starts; Apply(nil) resets
+// everything.
+func Apply(km Keymap) {
+	for b := range defaults {
+		b.Keys = km.keysOf(b)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,134 @@
+package keys_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-tui/keys"
+)
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want keys.Key
+	}{
+		{"x", keys.Key{Code: 'x'}},
+		{"S", keys.Key{Code: 'S'}},
+		{"shift+s", keys.Key{Code: 'S'}},
+		{"ctrl+s", keys.Key{Code: 's', Mods: vaxis.ModCtrl}},
+		{"Ctrl+S", keys.Key{Code: 's', Mods: vaxis.ModCtrl | vaxis.ModShift}},
+		{"ctrl+alt+x", keys.Key{Code: 'x', Mods: vaxis.ModCtrl | vaxis.ModAlt}},
+		{"shift+tab", keys.Key{Code: vaxis.KeyTab, Mods: vaxis.ModShift}},
+		{"pgdn", keys.Key{Code: vaxis.KeyPgDown}},
+		{"PageDown", keys.Key{Code: vaxis.KeyPgDown}},
+		{"escape", keys.Key{Code: vaxis.KeyEsc}},
+		{"space", keys.Key{Code: vaxis.KeySpace}},
+		{"f5", keys.Key{Code: vaxis.KeyF05}},
+		{"+", keys.Key{Code: '+'}},
+		{"ctrl++", keys.Key{Code: '+', Mods: vaxis.ModCtrl}},
+	}
+	for _, tt := range tests {
+		got, err := keys.ParseKey(tt.in)
+		if err != nil {
+			t.Errorf("ParseKey(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseKey(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseKey_Invalid(t *testing.T) {
+	for _, in := range []string{"", "hyper+x", "ctrl+", "banana"} {
+		if _, err := keys.ParseKey(in); err == nil {
+			t.Errorf("ParseKey(%q): expected an error", in)
+		}
+	}
+}
+
+func TestResolve_Defaults(t *testing.T) {
+	if _, err := keys.Resolve(nil); err != nil {
+		t.Fatalf("expected the default bindings not to conflict, got: %v", err)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		remap map[string][]string
+		want  []string
+	}{
+		{"unknown action", map[string][]string{"launch_rockets": {"x"}}, []string{`unknown action "launch_rockets"`}},
+		{"bad key", map[string][]string{"quit": {"ctrl+banana"}}, []string{`quit: unknown key "banana"`}},
+		{"global and view", map[string][]string{"quit": {"x"}}, []string{"x is bound to both quit and start_scrub (Pools)"}},
+		{"two view actions", map[string][]string{"pause_scrub": {"x"}}, []string{"x is bound to both start_scrub and pause_scrub (Pools)"}},
+		{
+			"all reported",
+			map[string][]string{"nope": {"x"}, "refresh": {"hyper+r"}},
+			[]string{`unknown action "nope"`, `unknown modifier "hyper"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := keys.Resolve(tt.remap)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in error, got: %v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestResolve_NoConflictAcrossContexts(t *testing.T) {
+	// The log pane takes every key, so its bindings can reuse global keys
+	if _, err := keys.Resolve(map[string][]string{"log_follow": {"q"}}); err == nil {
+		t.Error("expected q to conflict with close_logs")
+	}
+	if _, err := keys.Resolve(map[string][]string{"log_follow": {"ctrl+s"}}); err != nil {
+		t.Errorf("expected no conflict with the global switch_server, got: %v", err)
+	}
+	// Esc clears the filter or closes the detail pane depending on state
+	if _, err := keys.Resolve(map[string][]string{"clear_filter": {"ctrl+g"}, "close_detail": {"ctrl+g"}}); err != nil {
+		t.Errorf("expected clear_filter and close_detail to share a key, got: %v", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Cleanup(func() { keys.Apply(nil) })
+	km, err := keys.Resolve(map[string][]string{"quit": {"ctrl+q"}, "down": {"j", "ctrl+n"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keys.Apply(km)
+
+	if keys.Quit.Matches(vaxis.Key{Keycode: 'q', Text: "q"}) {
+		t.Error("expected q to no longer quit")
+	}
+	if !keys.Quit.Matches(vaxis.Key{Keycode: 'q', Modifiers: vaxis.ModCtrl}) {
+		t.Error("expected Ctrl+Q to quit")
+	}
+	if got := keys.Down.Label(); got != "j / Ctrl+N" {
+		t.Errorf("Down.Label() = %q", got)
+	}
+
+	keys.Apply(nil)
+	if !keys.Quit.Matches(vaxis.Key{Keycode: 'q', Text: "q"}) {
+		t.Error("expected Apply(nil) to restore the defaults")
+	}
+}
+
+func TestActions(t *testing.T) {
+	actions := keys.Actions()
+	for _, want := range []string{"quit", "refresh", "next_tab", "tab_1", "tab_6", "down", "start_scrub"} {
+		if !slices.Contains(actions, want) {
+			t.Errorf("expected %q in Actions()", want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package keys is the registry of keybindings. Key handlers match events
+// through it and the help overlay lists it, so what the help says a key does
+// is always what the key does.
+package keys
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// Key is one key press, e.g. x, Shift+Tab or Ctrl+S.
+type Key struct {
+	Code rune
+	Mods vaxis.ModifierMask
+}
+
+// keyNames are the display names of non-printing keys.
+var keyNames = map[rune]string{
+	vaxis.KeyEnter:     "Enter",
+	vaxis.KeyEsc:       "Esc",
+	vaxis.KeyTab:       "Tab",
+	vaxis.KeySpace:     "Space",
+	vaxis.KeyBackspace: "Backspace",
+	vaxis.KeyUp:        "Up",
+	vaxis.KeyDown:      "Down",
+	vaxis.KeyLeft:      "Left",
+	vaxis.KeyRight:     "Right",
+	vaxis.KeyPgUp:      "PgUp",
+	vaxis.KeyPgDown:    "PgDn",
+	vaxis.KeyHome:      "Home",
+	vaxis.KeyEnd:       "End",
+	vaxis.KeyInsert:    "Insert",
+	vaxis.KeyDelete:    "Delete",
+}
+
+func init() {
+	for i := range 12 {
+		keyNames[vaxis.KeyF01+rune(i)] = fmt.Sprintf("F%d", i+1)
+	}
+}
+
+// String returns the key as shown in the help, e.g. "Ctrl+S". Shift with a
+// letter and no other modifier is shown as the capital letter.
+func (k Key) String() string {
+	code, mods := k.Code, k.Mods
+	if mods == vaxis.ModShift && unicode.IsLower(code) {
+		code, mods = unicode.ToUpper(code), mods&^vaxis.ModShift
+	}
+	var b strings.Builder
+	for _, m := range []struct {
+		mask vaxis.ModifierMask
+		name string
+	}{{vaxis.ModCtrl, "Ctrl+"}, {vaxis.ModAlt, "Alt+"}, {vaxis.ModSuper, "Super+"}, {vaxis.ModShift, "Shift+"}} {
+		if mods&m.mask != 0 {
+			b.WriteString(m.name)
+		}
+	}
+	switch name, ok := keyNames[code]; {
+	case ok:
+		b.WriteString(name)
+	case mods&vaxis.ModCtrl != 0 && unicode.IsLower(code):
+		b.WriteRune(unicode.ToUpper(code))
+	default:
+		b.WriteRune(code)
+	}
+	return b.String()
+}
+
+// Binding is an action and the keys that trigger it.
+type Binding struct {
+	Action string // identifier, e.g. "start_scrub"
+	Help   string // what the action does, for the help overlay
+	Keys   []Key
+}
+
+// Matches reports whether k triggers the binding.
+func (b *Binding) Matches(k vaxis.Key) bool {
+	for _, key := range b.Keys {
+		if k.Matches(key.Code, key.Mods) {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the binding's keys for display, e.g. "j / Down".
+func (b *Binding) Label() string {
+	var labels []string
+	for _, k := range b.Keys {
+		s := k.String()
+		if !slices.Contains(labels, s) {
+			labels = append(labels, s)
+		}
+	}
+	return strings.Join(labels, " / ")
+}
+
+// Short returns the binding's first key for compact hints, e.g. in the
+// command palette. It is empty if the binding has no keys.
+func (b *Binding) Short() string {
+	if len(b.Keys) == 0 {
+		return ""
+	}
+	return b.Keys[0].String()
+}
+
+// Scope groups the bindings of one part of the UI, e.g. the Pools tab.
+type Scope struct {
+	Name     string
+	Bindings []*Binding
+}
@@ -0,0 +1,86 @@
+package keys_test
+
+import (
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-tui/keys"
+)
+
+func TestKey_String(t *testing.T) {
+	tests := []struct {
+		key  keys.Key
+		want string
+	}{
+		{keys.Key{Code: 'x'}, "x"},
+		{keys.Key{Code: 'X'}, "X"},
+		{keys.Key{Code: 'l', Mods: vaxis.ModShift}, "L"},
+		{keys.Key{Code: 's', Mods: vaxis.ModCtrl}, "Ctrl+S"},
+		{keys.Key{Code: vaxis.KeyTab, Mods: vaxis.ModShift}, "Shift+Tab"},
+		{keys.Key{Code: vaxis.KeyPgDown}, "PgDn"},
+		{keys.Key{Code: vaxis.KeySpace}, "Space"},
+	}
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestBinding_Label(t *testing.T) {
+	if got := keys.Down.Label(); got != "j / Down" {
+		t.Errorf("Down.Label() = %q", got)
+	}
+	// L and Shift+l display the same and are listed once
+	if got := keys.ExpandAll.Label(); got != "L" {
+		t.Errorf("ExpandAll.Label() = %q", got)
+	}
+	if got := keys.Palette.Short(); got != ":" {
+		t.Errorf("Palette.Short() = %q", got)
+	}
+}
+
+func TestBinding_Matches(t *testing.T) {
+	if !keys.Down.Matches(vaxis.Key{Keycode: 'j', Text: "j"}) {
+		t.Error("expected j to match Down")
+	}
+	if !keys.Down.Matches(vaxis.Key{Keycode: vaxis.KeyDown}) {
+		t.Error("expected the down arrow to match Down")
+	}
+	if keys.Down.Matches(vaxis.Key{Keycode: 'k', Text: "k"}) {
+		t.Error("expected k not to match Down")
+	}
+	if !keys.SwitchServer.Matches(vaxis.Key{Keycode: 's', Modifiers: vaxis.ModCtrl}) {
+		t.Error("expected Ctrl+S to match SwitchServer")
+	}
+	if keys.SwitchServer.Matches(vaxis.Key{Keycode: 's', Text: "s"}) {
+		t.Error("expected plain s not to match SwitchServer")
+	}
+	if !keys.StopApp.Matches(vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"}) {
+		t.Error("expected S to match StopApp")
+	}
+}
+
+// Every scope lists bindings with keys, help text and unique actions, and
+// no two bindings in a scope share a key.
+func TestScopes(t *testing.T) {
+	scopes := []*keys.Scope{
+		keys.GlobalScope, keys.ListScope, keys.QueryScope, keys.DashboardScope, keys.LogsScope,
+		keys.PoolsScope, keys.DatasetsScope, keys.SnapshotsScope, keys.AlertsScope, keys.DisksScope,
+		keys.FleetScope, keys.ConsoleScope, keys.HelpScope, keys.ConfirmScope, keys.PickerScope,
+	}
+	for _, scope := range scopes {
+		seen := map[keys.Key]string{}
+		for _, b := range scope.Bindings {
+			if b.Action == "" || b.Help == "" || len(b.Keys) == 0 {
+				t.Errorf("%s: incomplete binding %+v", scope.Name, b)
+			}
+			for _, k := range b.Keys {
+				if other, ok := seen[k]; ok && other != b.Action {
+					t.Errorf("%s: %s bound to both %s and %s", scope.Name, k, other, b.Action)
+				}
+				seen[k] = b.Action
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import "sync"
+
+// Buffer keeps the most recent log entries in a ring.
+type Buffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	start   int // index of the oldest entry once the ring is full
+	size    int
+	notify  func()
+}
+
+// NewBuffer creates a Buffer holding up to size entries.
+func NewBuffer(size int) *Buffer {
+	return &Buffer{size: max(size, 1)}
+}
+
+// Add appends an entry, dropping the oldest one when the buffer is full.
+func (b *Buffer) Add(e Entry) {
+	b.mu.Lock()
+	if len(b.entries) < b.size {
+		b.entries = append(b.entries, e)
+	} else {
+		b.entries[b.start] = e
+		b.start = (b.start + 1) % b.size
+	}
+	notify := b.notify
+	b.mu.Unlock()
+	if notify != nil {
+		notify()
+	}
+}
+
+// Entries returns the entries at or above min, oldest first.
+func (b *Buffer) Entries(min Level) []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]Entry, 0, len(b.entries))
+	for i := range b.entries {
+		e := b.entries[(b.start+i)%len(b.entries)]
+		if e.Level >= min {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Len returns the number of entries held.
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+// SetNotify sets a function called after every Add, or clears it when fn is
+// nil. It is called without the buffer's lock held, from whichever
+// goroutine logged.
+func (b *Buffer) SetNotify(fn func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.notify = fn
+}
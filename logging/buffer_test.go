@@ -0,0 +1,53 @@
+package logging_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deevus/truenas-tui/logging"
+)
+
+func TestBuffer_Ring(t *testing.T) {
+	b := logging.NewBuffer(3)
+	for i := 1; i <= 5; i++ {
+		b.Add(logging.Entry{Message: fmt.Sprintf("line %d", i)})
+	}
+
+	entries := b.Entries(logging.LevelInfo)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, want := range []string{"line 3", "line 4", "line 5"} {
+		if entries[i].Message != want {
+			t.Errorf("entry %d: expected %q, got %q", i, want, entries[i].Message)
+		}
+	}
+}
+
+func TestBuffer_Entries_Level(t *testing.T) {
+	b := logging.NewBuffer(10)
+	b.Add(logging.Entry{Level: logging.LevelInfo, Message: "info"})
+	b.Add(logging.Entry{Level: logging.LevelWarn, Message: "warn"})
+	b.Add(logging.Entry{Level: logging.LevelError, Message: "error"})
+
+	if got := len(b.Entries(logging.LevelWarn)); got != 2 {
+		t.Errorf("expected 2 entries at WARN and above, got %d", got)
+	}
+	errs := b.Entries(logging.LevelError)
+	if len(errs) != 1 || errs[0].Message != "error" {
+		t.Errorf("expected only the error, got %+v", errs)
+	}
+}
+
+func TestBuffer_Notify(t *testing.T) {
+	b := logging.NewBuffer(10)
+	calls := 0
+	b.SetNotify(func() { calls++ })
+	b.Add(logging.Entry{Message: "one"})
+	b.SetNotify(nil)
+	b.Add(logging.Entry{Message: "two"})
+
+	if calls != 1 {
+		t.Errorf("expected 1 notification, got %d", calls)
+	}
+}
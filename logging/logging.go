@@ -0,0 +1,109 @@
+// Package logging records log lines for the in-app console and, optionally,
+// a log file. Nothing is written to stderr while the UI owns the terminal.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+// Levels, from least to most severe.
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+)
+
+// String returns the level's name as shown in the console and log file.
+func (l Level) String() string {
+	switch l {
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "INFO"
+}
+
+// Entry is one logged line.
+type Entry struct {
+	Time    time.Time
+	Level   Level
+	Message string
+}
+
+// DefaultSize is the number of entries kept by the default buffer.
+const DefaultSize = 1000
+
+var (
+	mu     sync.Mutex
+	out    io.Writer = os.Stderr
+	buffer           = NewBuffer(DefaultSize)
+)
+
+// SetOutput sets where log lines are written in addition to the buffer. A
+// nil writer discards them.
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if w == nil {
+		w = io.Discard
+	}
+	out = w
+}
+
+// Default returns the buffer every log line is recorded in.
+func Default() *Buffer {
+	return buffer
+}
+
+// Infof logs an informational message.
+func Infof(format string, args ...any) {
+	logf(LevelInfo, format, args...)
+}
+
+// Warnf logs a recoverable problem, such as a subscription being retried.
+func Warnf(format string, args ...any) {
+	logf(LevelWarn, format, args...)
+}
+
+// Errorf logs a failure the user may need to act on.
+func Errorf(format string, args ...any) {
+	logf(LevelError, format, args...)
+}
+
+func logf(level Level, format string, args ...any) {
+	record(Entry{Time: time.Now(), Level: level, Message: fmt.Sprintf(format, args...)})
+}
+
+func record(e Entry) {
+	mu.Lock()
+	fmt.Fprintf(out, "%s %-5s %s\n", e.Time.Format(time.RFC3339), e.Level, e.Message)
+	mu.Unlock()
+	buffer.Add(e)
+}
+
+// Writer returns an io.Writer that logs each line written to it at the given
+// level. It is meant for log.SetOutput, so that packages using the standard
+// logger end up in the console too.
+func Writer(level Level) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+			if line != "" {
+				record(Entry{Time: time.Now(), Level: level, Message: line})
+			}
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
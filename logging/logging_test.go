@@ -0,0 +1,48 @@
+package logging_test
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/deevus/truenas-tui/logging"
+)
+
+func TestLogf(t *testing.T) {
+	var out bytes.Buffer
+	logging.SetOutput(&out)
+	defer logging.SetOutput(nil)
+
+	logging.Errorf("home: error loading tab %d: %s", 2, "timeout")
+
+	if !strings.Contains(out.String(), "ERROR home: error loading tab 2: timeout\n") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	entries := logging.Default().Entries(logging.LevelError)
+	last := entries[len(entries)-1]
+	if last.Level != logging.LevelError || last.Message != "home: error loading tab 2: timeout" {
+		t.Errorf("unexpected entry %+v", last)
+	}
+	if last.Time.IsZero() {
+		t.Error("expected a timestamp")
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var out bytes.Buffer
+	logging.SetOutput(&out)
+	defer logging.SetOutput(nil)
+
+	l := log.New(logging.Writer(logging.LevelWarn), "", 0)
+	l.Print("from the standard logger")
+
+	entries := logging.Default().Entries(logging.LevelWarn)
+	last := entries[len(entries)-1]
+	if last.Level != logging.LevelWarn || last.Message != "from the standard logger" {
+		t.Errorf("unexpected entry %+v", last)
+	}
+	if !strings.Contains(out.String(), "WARN  from the standard logger") {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
@@ -1,13 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
@@ -17,7 +21,9 @@ import (
 	"github.com/deevus/truenas-tui/app"
 	"github.com/deevus/truenas-tui/config"
 	"github.com/deevus/truenas-tui/internal"
-	"golang.org/x/crypto/ssh"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 var version = "dev"
@@ -25,141 +31,226 @@ var version = "dev"
 func main() {
 	serverFlag := flag.String("server", "", "server profile name from config")
 	configFlag := flag.String("config", config.DefaultPath(), "path to config file")
+	fleetFlag := flag.Bool("fleet", false, "start on the fleet overview of all servers")
+	logFileFlag := flag.String("log-file", "", "append log output to this file (overrides log_file in config)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %[1]s [flags]\n       %[1]s [flags] init\n       %[1]s [flags] config check [--server name] [--timeout 10s]\n\nFlags:\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	switch flag.Arg(0) {
+	case "":
+		if _, err := offerInit(*configFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	case "init":
+		if err := runInit(&prompter{in: bufio.NewReader(os.Stdin), out: os.Stderr}, *configFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "config":
+		if flag.Arg(1) == "check" {
+			os.Exit(runConfigCheck(flag.Args()[2:], *configFlag, *serverFlag))
+		}
+		flag.Usage()
+		os.Exit(2)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	cfg, err := config.LoadFrom(*configFlag)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Error: %v\nRun %s init to set up a server.\n", err, os.Args[0])
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	serverName := *serverFlag
-	if serverName == "" {
-		names := cfg.ServerNames()
-		if len(names) == 1 {
-			serverName = names[0]
-		} else {
-			fmt.Fprintf(os.Stderr, "Multiple servers configured. Use --server flag.\nAvailable: %v\n", names)
-			os.Exit(1)
-		}
+	keys.Apply(cfg.Keymap)
+	colors := cfg.Colors
+	if os.Getenv("NO_COLOR") != "" {
+		colors = colors.NoColor()
 	}
+	theme.Set(colors)
 
-	serverCfg, ok := cfg.Servers[serverName]
-	if !ok {
-		fmt.Fprintf(os.Stderr, "Error: server %q not found in config\n", serverName)
+	serverName := *serverFlag
+	if _, ok := cfg.Servers[serverName]; serverName != "" && !ok {
+		fmt.Fprintf(os.Stderr, "Error: server %q not found in config\nAvailable: %v\n", serverName, cfg.ServerNames())
 		os.Exit(1)
 	}
 
-	wsCfg := client.WebSocketConfig{
-		Host:               serverCfg.Host,
-		Port:               serverCfg.Port,
-		Username:           serverCfg.Username,
-		APIKey:             serverCfg.APIKey,
-		InsecureSkipVerify: serverCfg.InsecureSkipVerify,
+	// The UI owns the terminal once it starts, so ask about host keys and
+	// key passphrases now, for the servers it will connect to first.
+	// Servers picked later report an unknown key or a missing passphrase as
+	// a connection error.
+	prompted := cfg.ServerNames()
+	if serverName != "" && !*fleetFlag {
+		prompted = []string{serverName}
+	}
+	checked, err := trustHostKeys(cfg, *configFlag, prompted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := promptPassphrases(cfg, prompted); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
-	// SSH config validation stays synchronous — these are config issues
-	// that require the user to fix their config and re-run.
-	var sshCfg *client.SSHConfig
-	if serverCfg.SSH != nil {
-		sshHost := serverCfg.SSH.Host
-		if sshHost == "" {
-			sshHost = serverCfg.Host
-		}
-
-		if serverCfg.SSH.HostKeyFingerprint == "" {
-			fingerprint, err := scanHostKey(sshHost, serverCfg.SSH.Port)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: host_key_fingerprint is required for SSH.\n")
-				fmt.Fprintf(os.Stderr, "Could not auto-detect: %v\n", err)
-				fmt.Fprintf(os.Stderr, "Get it with: ssh-keyscan -p %d %s 2>/dev/null | ssh-keygen -lf -\n", serverCfg.SSH.Port, sshHost)
-				os.Exit(1)
-			}
-			fmt.Fprintf(os.Stderr, "Error: host_key_fingerprint is required for SSH.\n")
-			fmt.Fprintf(os.Stderr, "Detected fingerprint for %s:\n\n", sshHost)
-			fmt.Fprintf(os.Stderr, "  host_key_fingerprint = %q\n\n", fingerprint)
-			fmt.Fprintf(os.Stderr, "Add this to [servers.%s.ssh] in your config.\n", serverName)
+	if serverName != "" {
+		serverCfg := cfg.Servers[serverName]
+		// SSH config validation stays synchronous for the server picked on
+		// the command line — these are config issues that require the user
+		// to fix their config and re-run. Servers picked at runtime report
+		// the same problems as a connection error. The host key was just
+		// looked up, so it isn't fetched a second time.
+		if _, err := sshConfig(serverName, serverCfg, checked); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
+	}
 
-		privateKey, err := os.ReadFile(serverCfg.SSH.PrivateKeyPath)
+	// Anything printed to stderr would corrupt the screen once the UI owns
+	// the terminal, so log lines only go to the console and the log file.
+	logFile := cfg.LogFile
+	if *logFileFlag != "" {
+		logFile = *logFileFlag
+	}
+	var logOut *os.File
+	if logFile != "" {
+		logOut, err = os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading SSH private key %s: %v\n", serverCfg.SSH.PrivateKeyPath, err)
+			fmt.Fprintf(os.Stderr, "Error: opening log file: %v\n", err)
 			os.Exit(1)
 		}
-
-		sshCfg = &client.SSHConfig{
-			Host:               sshHost,
-			Port:               serverCfg.SSH.Port,
-			User:               serverCfg.SSH.Username,
-			PrivateKey:         string(privateKey),
-			HostKeyFingerprint: serverCfg.SSH.HostKeyFingerprint,
-		}
+		defer logOut.Close()
+		logging.SetOutput(logOut)
+	} else {
+		logging.SetOutput(nil)
 	}
+	log.SetFlags(0)
+	log.SetOutput(logging.Writer(logging.LevelInfo))
 
 	root := app.New(app.Params{
 		ServerName: serverName,
+		Servers:    cfg.ServerNames(),
+		Fleet:      *fleetFlag,
 		StaleTTL:   30 * time.Second,
-		Connect: func(ctx context.Context) (*internal.Services, error) {
-			if sshCfg != nil {
-				sshClient, err := client.NewSSHClient(sshCfg)
-				if err != nil {
-					return nil, fmt.Errorf("SSH: %w", err)
-				}
-				wsCfg.Fallback = sshClient
-			}
-
-			wsClient, err := client.NewWebSocketClient(wsCfg)
-			if err != nil {
-				return nil, err
-			}
-
-			if err := wsClient.Connect(ctx); err != nil {
-				return nil, err
-			}
-
-			version := wsClient.Version()
-			return internal.NewServices(
-				truenas.NewDatasetService(wsClient, version),
-				truenas.NewSnapshotService(wsClient, version),
-				truenas.NewSystemService(wsClient, version),
-				truenas.NewReportingService(wsClient, version),
-				truenas.NewInterfaceService(wsClient, version),
-				truenas.NewAppService(wsClient, version),
-			), nil
+		Connect: func(ctx context.Context, server string) (*internal.Services, error) {
+			return connect(ctx, server, cfg.Servers[server])
 		},
 	})
 
 	vxApp, err := vxfw.NewApp(vaxis.Options{})
 	if err != nil {
-		log.Fatal(err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	root.SetPostEvent(vxApp.PostEvent)
 
 	if err := vxApp.Run(root); err != nil {
-		log.Fatal(err)
+		logging.Errorf("%v", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 }
 
-// scanHostKey connects to an SSH server and returns the host key fingerprint.
-func scanHostKey(host string, port int) (string, error) {
-	addr := net.JoinHostPort(host, strconv.Itoa(port))
-	var fingerprint string
-	cfg := &ssh.ClientConfig{
-		User: "probe",
-		HostKeyCallback: func(_ string, _ net.Addr, key ssh.PublicKey) error {
-			fingerprint = ssh.FingerprintSHA256(key)
-			return nil
-		},
-		Timeout: 5 * time.Second,
+// sshConfig builds the SSH fallback config for a server, or returns nil if
+// the server has no [ssh] section. The host key is looked up unless checked
+// already has the server's.
+func sshConfig(serverName string, serverCfg config.ServerConfig, checked map[string]hostKeyCheck) (*client.SSHConfig, error) {
+	if serverCfg.SSH == nil {
+		return nil, nil
 	}
-	conn, err := ssh.Dial("tcp", addr, cfg)
-	if conn != nil {
-		conn.Close()
+	host := sshHost(serverCfg)
+
+	check, ok := checked[serverName]
+	if !ok {
+		check.fingerprint, check.err = hostKeyFingerprint(serverName, serverCfg.SSH, host)
+	}
+	if check.err != nil {
+		return nil, check.err
+	}
+	fingerprint := check.fingerprint
+
+	privateKey, err := sshPrivateKey(serverName, serverCfg.SSH)
+	if err != nil {
+		return nil, err
 	}
-	if fingerprint != "" {
-		return fingerprint, nil
+
+	return &client.SSHConfig{
+		Host:               host,
+		Port:               serverCfg.SSH.Port,
+		User:               serverCfg.SSH.Username,
+		PrivateKey:         privateKey,
+		HostKeyFingerprint: fingerprint,
+	}, nil
+}
+
+// sshHost returns the host to connect to over SSH: the [ssh] section's
+// host, or the server's.
+func sshHost(serverCfg config.ServerConfig) string {
+	if serverCfg.SSH.Host != "" {
+		return serverCfg.SSH.Host
 	}
-	return "", fmt.Errorf("could not connect to %s: %v", addr, err)
+	return serverCfg.Host
+}
+
+// connect opens a connection to the named server and returns its services.
+func connect(ctx context.Context, serverName string, serverCfg config.ServerConfig) (*internal.Services, error) {
+	wsCfg := client.WebSocketConfig{
+		Host:               serverCfg.Host,
+		Port:               serverCfg.Port,
+		Username:           serverCfg.Username,
+		APIKey:             serverCfg.APIKey,
+		InsecureSkipVerify: serverCfg.InsecureSkipVerify,
+	}
+
+	sshCfg, err := sshConfig(serverName, serverCfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	if sshCfg != nil {
+		sshClient, err := client.NewSSHClient(sshCfg)
+		if err != nil {
+			return nil, fmt.Errorf("SSH: %w", err)
+		}
+		wsCfg.Fallback = sshClient
+	}
+
+	wsClient, err := client.NewWebSocketClient(wsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wsClient.Connect(ctx); err != nil {
+		// The SSH client only reports the rejection as text, so name the
+		// key that was turned down.
+		if sshCfg != nil && strings.Contains(err.Error(), "unable to authenticate") {
+			return nil, fmt.Errorf("SSH: %s@%s turned down the private key %s: %w",
+				sshCfg.User, net.JoinHostPort(sshCfg.Host, strconv.Itoa(sshCfg.Port)), serverCfg.SSH.PrivateKeyPath, err)
+		}
+		return nil, err
+	}
+
+	version := wsClient.Version()
+	return internal.NewServices(
+		truenas.NewDatasetService(wsClient, version),
+		internal.NewSnapshotService(wsClient, version),
+		truenas.NewSystemService(wsClient, version),
+		truenas.NewReportingService(wsClient, version),
+		truenas.NewInterfaceService(wsClient, version),
+		truenas.NewAppService(wsClient, version),
+		internal.NewPoolService(wsClient, version),
+		internal.NewAlertService(wsClient, version),
+		internal.NewDiskService(wsClient, version),
+		internal.NewConnService(wsClient),
+	), nil
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// passphraseAttempts is how many times the passphrase prompt asks before
+// giving up.
+const passphraseAttempts = 3
+
+// sshPrivateKey returns a server's SSH private key as unencrypted PEM, which
+// is what the SSH client takes. Keys are tried in order:
+//
+//  1. the private key file as it is, if it isn't encrypted
+//  2. decrypted with the passphrase from the config (passphrase,
+//     passphrase_file, passphrase_command or passphrase_env)
+//  3. decrypted with the passphrase typed at the prompt before the UI
+//     started
+//
+// truenas-go's SSH client only takes a private key, so keys held only by
+// ssh-agent can't be used. Errors say which step failed.
+func sshPrivateKey(serverName string, sc *config.SSHConfig) (string, error) {
+	if sc.PrivateKeyPath == "" {
+		msg := fmt.Sprintf("SSH private key: set private_key_path in [servers.%s.ssh]", serverName)
+		if os.Getenv("SSH_AUTH_SOCK") != "" {
+			msg += " (keys held only by ssh-agent can't be used; the SSH client needs the key file)"
+		}
+		return "", errors.New(msg)
+	}
+	pemBytes, err := os.ReadFile(sc.PrivateKeyPath)
+	if err != nil {
+		return "", fmt.Errorf("reading SSH private key %s: %v", sc.PrivateKeyPath, err)
+	}
+
+	_, err = ssh.ParseRawPrivateKey(pemBytes)
+	var missing *ssh.PassphraseMissingError
+	switch {
+	case err == nil:
+		return string(pemBytes), nil
+	case !errors.As(err, &missing):
+		return "", fmt.Errorf("SSH private key %s: %v", sc.PrivateKeyPath, err)
+	case sc.Passphrase == "":
+		return "", fmt.Errorf("SSH private key %s is passphrase-protected: set passphrase_command, passphrase_file or passphrase_env in [servers.%[2]s.ssh], or run truenas-tui --server %[2]s from a terminal to be asked for it",
+			sc.PrivateKeyPath, serverName)
+	}
+
+	key, err := decryptPrivateKey(pemBytes, sc.Passphrase)
+	if errors.Is(err, x509.IncorrectPasswordError) {
+		return "", fmt.Errorf("SSH private key %s: the passphrase from %s is incorrect", sc.PrivateKeyPath, sc.PassphraseFrom)
+	}
+	if err != nil {
+		return "", fmt.Errorf("SSH private key %s: decrypting with the passphrase from %s: %v", sc.PrivateKeyPath, sc.PassphraseFrom, err)
+	}
+	return key, nil
+}
+
+// decryptPrivateKey decrypts a passphrase-protected private key and returns
+// it as unencrypted PEM.
+func decryptPrivateKey(pemBytes []byte, passphrase string) (string, error) {
+	key, err := ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	if err != nil {
+		return "", err
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// promptPassphrases asks on the terminal for the passphrase of each named
+// server's encrypted SSH private key that has none in the config, before
+// the UI takes the terminal over. Keys that can't be read are left for the
+// connection to report. Without a terminal there is nothing to ask, and
+// connecting to those servers reports the missing passphrase instead.
+func promptPassphrases(cfg *config.Config, names []string) error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return nil
+	}
+	for _, name := range names {
+		sc := cfg.Servers[name].SSH
+		if sc == nil || sc.Passphrase != "" || sc.PrivateKeyPath == "" {
+			continue
+		}
+		pemBytes, err := os.ReadFile(sc.PrivateKeyPath)
+		if err != nil {
+			continue
+		}
+		var missing *ssh.PassphraseMissingError
+		if _, err := ssh.ParseRawPrivateKey(pemBytes); !errors.As(err, &missing) {
+			continue
+		}
+		passphrase, err := askPassphrase(name, sc.PrivateKeyPath, pemBytes)
+		if err != nil {
+			return err
+		}
+		sc.Passphrase, sc.PassphraseFrom = passphrase, "the prompt"
+	}
+	return nil
+}
+
+// askPassphrase prompts for a key's passphrase until it decrypts the key.
+func askPassphrase(serverName, path string, pemBytes []byte) (string, error) {
+	fd := int(os.Stdin.Fd())
+	for range passphraseAttempts {
+		fmt.Fprintf(os.Stderr, "Passphrase for %s (server %s): ", path, serverName)
+		input, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("reading passphrase for %s: %v", path, err)
+		}
+		_, err = ssh.ParseRawPrivateKeyWithPassphrase(pemBytes, input)
+		if err == nil {
+			return string(input), nil
+		}
+		if !errors.Is(err, x509.IncorrectPasswordError) {
+			return "", fmt.Errorf("SSH private key %s: %v", path, err)
+		}
+		fmt.Fprintln(os.Stderr, "Incorrect passphrase.")
+	}
+	return "", fmt.Errorf("SSH private key %s: no correct passphrase after %d attempts", path, passphraseAttempts)
+}
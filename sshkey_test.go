@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/deevus/truenas-tui/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// writeKey writes an ed25519 private key to dir, encrypted if passphrase
+// isn't empty, and returns its path.
+func writeKey(t *testing.T, dir, passphrase string) string {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeSSHConfig writes a config with one server, home, whose [ssh]
+// section has the given settings, and returns its path.
+func writeSSHConfig(t *testing.T, ssh string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[servers.home]\nhost = \"truenas.local\"\nusername = \"admin\"\napi_key = \"1-abc\"\n\n[servers.home.ssh]\n" + ssh
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadSSHConfig loads the config at path and returns home's [ssh] section.
+func loadSSHConfig(t *testing.T, path string) *config.SSHConfig {
+	t.Helper()
+	cfg, err := config.LoadFrom(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return cfg.Servers["home"].SSH
+}
+
+// assertKey checks that key is unencrypted PEM the SSH client can parse.
+func assertKey(t *testing.T, key string) {
+	t.Helper()
+	if _, err := ssh.ParsePrivateKey([]byte(key)); err != nil {
+		t.Errorf("expected an unencrypted key, got %v", err)
+	}
+}
+
+func TestSSHPrivateKey_Plain(t *testing.T) {
+	path := writeKey(t, t.TempDir(), "")
+	sc := loadSSHConfig(t, writeSSHConfig(t, "private_key_path = \""+path+"\"\n"))
+	key, err := sshPrivateKey("home", sc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertKey(t, key)
+}
+
+func TestSSHPrivateKey_Passphrase(t *testing.T) {
+	dir := t.TempDir()
+	path := writeKey(t, dir, "correct horse")
+	passphraseFile := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(passphraseFile, []byte("correct horse\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SSH_PASSPHRASE", "correct horse")
+	t.Setenv("TEST_WRONG_PASSPHRASE", "battery staple")
+
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{"config", `passphrase = "correct horse"`, ""},
+		{"command", `passphrase_command = "echo correct horse"`, ""},
+		{"file", `passphrase_file = "` + passphraseFile + `"`, ""},
+		{"env", `passphrase_env = "TEST_SSH_PASSPHRASE"`, ""},
+		{"wrong passphrase", `passphrase_env = "TEST_WRONG_PASSPHRASE"`, "the passphrase from passphrase_env is incorrect"},
+		{"no passphrase", "", "is passphrase-protected: set passphrase_command"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := loadSSHConfig(t, writeSSHConfig(t, "private_key_path = \""+path+"\"\n"+tt.source+"\n"))
+			key, err := sshPrivateKey("home", sc)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertKey(t, key)
+		})
+	}
+}
+
+func TestSSHPrivateKey_Missing(t *testing.T) {
+	sc := &config.SSHConfig{PrivateKeyPath: filepath.Join(t.TempDir(), "missing")}
+	if _, err := sshPrivateKey("home", sc); err == nil || !strings.Contains(err.Error(), "reading SSH private key") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+	if _, err := sshPrivateKey("home", &config.SSHConfig{}); err == nil || !strings.Contains(err.Error(), "set private_key_path in [servers.home.ssh]") {
+		t.Errorf("expected an error asking for private_key_path, got %v", err)
+	}
+}
+
+func TestDecryptPrivateKey(t *testing.T) {
+	pemBytes, err := os.ReadFile(writeKey(t, t.TempDir(), "correct horse"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := decryptPrivateKey(pemBytes, "correct horse")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertKey(t, key)
+
+	if _, err := decryptPrivateKey(pemBytes, "battery staple"); !errors.Is(err, x509.IncorrectPasswordError) {
+		t.Errorf("expected x509.IncorrectPasswordError, got %v", err)
+	}
+}
+
+func TestConnect_KeyTurnedDown(t *testing.T) {
+	server := startSSHServer(t)
+	keyPath := writeKey(t, t.TempDir(), "")
+	serverCfg := config.ServerConfig{
+		Host:     "127.0.0.1",
+		Username: "admin",
+		APIKey:   "1-abc",
+		SSH: &config.SSHConfig{
+			Port:               server.port,
+			Username:           "root",
+			PrivateKeyPath:     keyPath,
+			HostKeyFingerprint: server.fingerprint(),
+		},
+	}
+	_, err := connect(context.Background(), "home", serverCfg)
+	want := "SSH: root@127.0.0.1:" + strconv.Itoa(server.port) + " turned down the private key " + keyPath
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("expected an error containing %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,42 @@
+package theme
+
+import "git.sr.ht/~rockorager/vaxis"
+
+// current is the theme in use. It is set once at startup, before the UI
+// draws, so reads need no locking.
+var current = Default
+
+// Set makes t the theme in use.
+func Set(t Theme) {
+	current = t
+}
+
+// Current returns the theme in use.
+func Current() Theme {
+	return current
+}
+
+// OK returns the style for healthy state.
+func OK() vaxis.Style { return current.OK }
+
+// Warning returns the style for state that needs attention.
+func Warning() vaxis.Style { return current.Warning }
+
+// Critical returns the style for failures and errors.
+func Critical() vaxis.Style { return current.Critical }
+
+// Accent returns the style for neutral highlights.
+func Accent() vaxis.Style { return current.Accent }
+
+// Dim returns the style for secondary text.
+func Dim() vaxis.Style { return current.Dim }
+
+// Selection returns the style for the item under the cursor.
+func Selection() vaxis.Style { return current.Selection }
+
+// Bold returns s in bold, e.g. theme.Bold(theme.Critical()) for a failure
+// that must stand out.
+func Bold(s vaxis.Style) vaxis.Style {
+	s.Attribute |= vaxis.AttrBold
+	return s
+}
@@ -0,0 +1,237 @@
+// Package theme holds the colors of the UI as semantic roles, so views and
+// widgets say what a piece of text means (ok, critical, ...) and the theme
+// decides how it looks.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// Theme is the style of each role.
+type Theme struct {
+	Name string
+	// OK marks healthy state: online pools, running apps, passed tests.
+	OK vaxis.Style
+	// Warning marks state that needs attention soon: degraded pools,
+	// usage above 60%, paused streams.
+	Warning vaxis.Style
+	// Critical marks failures and errors.
+	Critical vaxis.Style
+	// Accent marks neutral highlights: progress bars and sparklines.
+	Accent vaxis.Style
+	// Dim marks secondary text: hints, headers and empty gauge cells.
+	Dim vaxis.Style
+	// Selection marks the item under the cursor and the active tab.
+	Selection vaxis.Style
+}
+
+// Roles are the role names used in the config, in display order.
+var Roles = []string{"ok", "warning", "critical", "accent", "dim", "selection"}
+
+// role returns a pointer to the style of the named role, or nil.
+func (t *Theme) role(name string) *vaxis.Style {
+	switch name {
+	case "ok":
+		return &t.OK
+	case "warning":
+		return &t.Warning
+	case "critical":
+		return &t.Critical
+	case "accent":
+		return &t.Accent
+	case "dim":
+		return &t.Dim
+	case "selection":
+		return &t.Selection
+	}
+	return nil
+}
+
+// NoColor returns the theme without colors, for NO_COLOR: roles keep their
+// attributes, and critical text is bold so it still stands out.
+func (t Theme) NoColor() Theme {
+	for _, name := range Roles {
+		s := t.role(name)
+		s.Foreground, s.Background, s.UnderlineColor = 0, 0, 0
+	}
+	t.Critical.Attribute |= vaxis.AttrBold
+	if t.Selection.Attribute == 0 {
+		t.Selection.Attribute = vaxis.AttrReverse
+	}
+	return t
+}
+
+// Default uses the terminal's own palette, so it follows the terminal's
+// color scheme.
+var Default = Theme{
+	Name:      "default",
+	OK:        vaxis.Style{Foreground: vaxis.IndexColor(2)},
+	Warning:   vaxis.Style{Foreground: vaxis.IndexColor(3)},
+	Critical:  vaxis.Style{Foreground: vaxis.IndexColor(1)},
+	Accent:    vaxis.Style{Foreground: vaxis.IndexColor(6)},
+	Dim:       vaxis.Style{Attribute: vaxis.AttrDim},
+	Selection: vaxis.Style{Attribute: vaxis.AttrReverse},
+}
+
+// builtins are the themes that can be picked by name. RGB colors fall back
+// to the nearest palette color on terminals without truecolor.
+var builtins = []Theme{
+	Default,
+	{
+		// Dark enough to read on a white background, where the palette's
+		// yellow and dimmed text wash out.
+		Name:      "light",
+		OK:        vaxis.Style{Foreground: vaxis.HexColor(0x1a7f37)},
+		Warning:   vaxis.Style{Foreground: vaxis.HexColor(0x9a6700)},
+		Critical:  vaxis.Style{Foreground: vaxis.HexColor(0xcf222e)},
+		Accent:    vaxis.Style{Foreground: vaxis.HexColor(0x0969da)},
+		Dim:       vaxis.Style{Foreground: vaxis.HexColor(0x6e7781)},
+		Selection: vaxis.Style{Foreground: vaxis.HexColor(0x1f2328), Background: vaxis.HexColor(0xb6e3ff)},
+	},
+	{
+		// Bright bold colors and no dimmed text.
+		Name:      "high-contrast",
+		OK:        vaxis.Style{Foreground: vaxis.IndexColor(10), Attribute: vaxis.AttrBold},
+		Warning:   vaxis.Style{Foreground: vaxis.IndexColor(11), Attribute: vaxis.AttrBold},
+		Critical:  vaxis.Style{Foreground: vaxis.IndexColor(9), Attribute: vaxis.AttrBold},
+		Accent:    vaxis.Style{Foreground: vaxis.IndexColor(14), Attribute: vaxis.AttrBold},
+		Dim:       vaxis.Style{Foreground: vaxis.IndexColor(15)},
+		Selection: vaxis.Style{Foreground: vaxis.IndexColor(0), Background: vaxis.IndexColor(11), Attribute: vaxis.AttrBold},
+	},
+	{
+		Name:      "nord",
+		OK:        vaxis.Style{Foreground: vaxis.HexColor(0xa3be8c)},
+		Warning:   vaxis.Style{Foreground: vaxis.HexColor(0xebcb8b)},
+		Critical:  vaxis.Style{Foreground: vaxis.HexColor(0xbf616a)},
+		Accent:    vaxis.Style{Foreground: vaxis.HexColor(0x88c0d0)},
+		Dim:       vaxis.Style{Foreground: vaxis.HexColor(0x4c566a)},
+		Selection: vaxis.Style{Foreground: vaxis.HexColor(0x2e3440), Background: vaxis.HexColor(0x88c0d0)},
+	},
+}
+
+// Names returns the names of the built-in themes.
+func Names() []string {
+	names := make([]string, len(builtins))
+	for i, t := range builtins {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Resolve returns the built-in theme called name ("" for the default) with
+// the given roles overridden. Overrides are style specs, see ParseStyle.
+func Resolve(name string, overrides map[string]string) (Theme, error) {
+	if name == "" {
+		name = Default.Name
+	}
+	i := slices.IndexFunc(builtins, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return Theme{}, fmt.Errorf("unknown theme %q (built in: %s)", name, strings.Join(Names(), ", "))
+	}
+	t := builtins[i]
+	var errs []error
+	for _, role := range slices.Sorted(maps.Keys(overrides)) {
+		s := t.role(role)
+		if s == nil {
+			errs = append(errs, fmt.Errorf("unknown role %q (roles: %s)", role, strings.Join(Roles, ", ")))
+			continue
+		}
+		style, err := ParseStyle(overrides[role])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", role, err))
+			continue
+		}
+		*s = style
+	}
+	if len(errs) > 0 {
+		return Theme{}, errors.Join(errs...)
+	}
+	return t, nil
+}
+
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+var attrNames = map[string]vaxis.AttributeMask{
+	"bold":          vaxis.AttrBold,
+	"dim":           vaxis.AttrDim,
+	"italic":        vaxis.AttrItalic,
+	"blink":         vaxis.AttrBlink,
+	"reverse":       vaxis.AttrReverse,
+	"strikethrough": vaxis.AttrStrikethrough,
+}
+
+// ParseStyle parses a style spec from the config: attributes, a foreground
+// color and "on" a background color, in any order, e.g. "red",
+// "bold #ff5555" or "black on yellow". Colors are names (red,
+// bright-red, ...), palette indexes (0-255) or #rrggbb.
+func ParseStyle(spec string) (vaxis.Style, error) {
+	var s vaxis.Style
+	words := strings.Fields(strings.ToLower(spec))
+	if len(words) == 0 {
+		return s, errors.New("empty style")
+	}
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if attr, ok := attrNames[w]; ok {
+			s.Attribute |= attr
+			continue
+		}
+		if w == "underline" {
+			s.UnderlineStyle = vaxis.UnderlineSingle
+			continue
+		}
+		if w == "on" {
+			if i+1 == len(words) {
+				return s, fmt.Errorf("missing color after \"on\" in %q", spec)
+			}
+			i++
+			c, err := ParseColor(words[i])
+			if err != nil {
+				return s, err
+			}
+			s.Background = c
+			continue
+		}
+		c, err := ParseColor(w)
+		if err != nil {
+			return s, err
+		}
+		s.Foreground = c
+	}
+	return s, nil
+}
+
+// ParseColor parses a color name, palette index or #rrggbb.
+func ParseColor(s string) (vaxis.Color, error) {
+	if hex, ok := strings.CutPrefix(s, "#"); ok {
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return 0, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+		}
+		return vaxis.HexColor(uint32(v)), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 255 {
+			return 0, fmt.Errorf("invalid color %q, palette colors are 0-255", s)
+		}
+		return vaxis.IndexColor(uint8(n)), nil
+	}
+	if s == "default" {
+		return 0, nil
+	}
+	name, bright := strings.CutPrefix(s, "bright-")
+	if i := slices.Index(colorNames, name); i >= 0 {
+		if bright {
+			i += 8
+		}
+		return vaxis.IndexColor(uint8(i)), nil
+	}
+	return 0, fmt.Errorf("unknown color %q", s)
+}
@@ -0,0 +1,112 @@
+package theme_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want vaxis.Color
+	}{
+		{"red", vaxis.IndexColor(1)},
+		{"bright-red", vaxis.IndexColor(9)},
+		{"244", vaxis.IndexColor(244)},
+		{"#88c0d0", vaxis.HexColor(0x88c0d0)},
+		{"default", 0},
+	}
+	for _, tt := range tests {
+		got, err := theme.ParseColor(tt.in)
+		if err != nil {
+			t.Errorf("ParseColor(%q): unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"purple", "256", "#88c0d", "#zzzzzz"} {
+		if _, err := theme.ParseColor(in); err == nil {
+			t.Errorf("ParseColor(%q): expected an error", in)
+		}
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	got, err := theme.ParseStyle("bold Black on #FFCC00")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := vaxis.Style{Foreground: vaxis.IndexColor(0), Background: vaxis.HexColor(0xffcc00), Attribute: vaxis.AttrBold}
+	if got != want {
+		t.Errorf("ParseStyle = %+v, want %+v", got, want)
+	}
+
+	for _, in := range []string{"", "red on", "sparkly"} {
+		if _, err := theme.ParseStyle(in); err == nil {
+			t.Errorf("ParseStyle(%q): expected an error", in)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	for _, name := range []string{"default", "light", "high-contrast"} {
+		if !slices.Contains(theme.Names(), name) {
+			t.Errorf("expected a built-in %s theme", name)
+		}
+	}
+
+	th, err := theme.Resolve("", nil)
+	if err != nil || th != theme.Default {
+		t.Errorf("expected the default theme, got %+v, %v", th, err)
+	}
+
+	th, err = theme.Resolve("light", map[string]string{"critical": "bold bright-red"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if th.Name != "light" || th.Critical != (vaxis.Style{Foreground: vaxis.IndexColor(9), Attribute: vaxis.AttrBold}) {
+		t.Errorf("expected light with critical overridden, got %+v", th)
+	}
+}
+
+func TestResolve_Errors(t *testing.T) {
+	if _, err := theme.Resolve("solarized-ultra", nil); err == nil || !strings.Contains(err.Error(), "high-contrast") {
+		t.Errorf("expected an unknown theme error listing the built-in themes, got: %v", err)
+	}
+	_, err := theme.Resolve("default", map[string]string{"okay": "green", "accent": "purple"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`unknown role "okay"`, `accent: unknown color "purple"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error, got: %v", want, err)
+		}
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	hc, err := theme.Resolve("high-contrast", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nc := hc.NoColor()
+	for _, s := range []vaxis.Style{nc.OK, nc.Warning, nc.Critical, nc.Accent, nc.Dim, nc.Selection} {
+		if s.Foreground != 0 || s.Background != 0 {
+			t.Errorf("expected no colors, got %+v", s)
+		}
+	}
+	if nc.Critical.Attribute&vaxis.AttrBold == 0 {
+		t.Error("expected critical to stay bold")
+	}
+
+	nc = theme.Default.NoColor()
+	if nc.Selection.Attribute&vaxis.AttrReverse == 0 || nc.Dim.Attribute&vaxis.AttrDim == 0 {
+		t.Errorf("expected attributes kept, got %+v", nc)
+	}
+}
@@ -0,0 +1,119 @@
+package views
+
+import (
+	"context"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// actions holds the modal dialog and status line shared by views that let
+// the user act on the selected row.
+type actions struct {
+	postEvent func(vaxis.Event)
+	modal     vxfw.Widget
+	status    string
+	statusErr bool
+}
+
+// open shows a modal dialog; it receives all input until closed.
+func (a *actions) open(w vxfw.Widget) (vxfw.Command, error) {
+	a.modal = w
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// close dismisses the modal dialog.
+func (a *actions) close() (vxfw.Command, error) {
+	a.modal = nil
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// setStatus replaces the status line.
+func (a *actions) setStatus(msg string, isErr bool) {
+	a.status = msg
+	a.statusErr = isErr
+}
+
+// withKey returns " with <key>" naming b's key for a status hint, or
+// nothing if b has been unbound.
+func withKey(b *keys.Binding) string {
+	if k := b.Short(); k != "" {
+		return " with " + k
+	}
+	return ""
+}
+
+// run closes any modal, shows pending in the status line and runs fn in the
+// background. The outcome is posted as an ActionCompleted for view.
+func (a *actions) run(view ActionView, pending string, fn func(ctx context.Context) (string, error)) (vxfw.Command, error) {
+	a.modal = nil
+	a.setStatus(pending, false)
+	go func() {
+		msg, err := fn(context.Background())
+		if a.postEvent != nil {
+			a.postEvent(ActionCompleted{View: view, Message: msg, Err: err})
+		}
+	}()
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// done records the outcome of a finished action in the status line.
+func (a *actions) done(ev ActionCompleted) {
+	if ev.Err != nil {
+		a.setStatus("Error: "+ev.Err.Error(), true)
+		return
+	}
+	a.setStatus(ev.Message, false)
+}
+
+// handleModal forwards an event to the open modal, if any.
+func (a *actions) handleModal(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, bool, error) {
+	if a.modal == nil {
+		return nil, false, nil
+	}
+	h, ok := a.modal.(vxfw.EventHandler)
+	if !ok {
+		return nil, true, nil
+	}
+	cmd, err := h.HandleEvent(ev, phase)
+	return cmd, true, err
+}
+
+// statusHeight returns the number of rows the status line takes up.
+func (a *actions) statusHeight() uint16 {
+	if a.status == "" {
+		return 0
+	}
+	return 1
+}
+
+// draw renders the status line on the last row of s and centers the modal
+// over it.
+func (a *actions) draw(ctx vxfw.DrawContext, s *vxfw.Surface) error {
+	if a.status != "" && ctx.Max.Height > 0 {
+		style := theme.Dim()
+		if a.statusErr {
+			style = theme.Critical()
+		}
+		status := richtext.New([]vaxis.Segment{{Text: a.status, Style: style}})
+		statusSurf, err := status.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+		if err != nil {
+			return err
+		}
+		s.AddChild(0, int(ctx.Max.Height)-1, statusSurf)
+	}
+	if a.modal == nil {
+		return nil
+	}
+	modalSurf, err := a.modal.Draw(ctx)
+	if err != nil {
+		return err
+	}
+	col := (int(ctx.Max.Width) - int(modalSurf.Size.Width)) / 2
+	row := (int(ctx.Max.Height) - int(modalSurf.Size.Height)) / 2
+	s.AddChild(max(col, 0), max(row, 0), modalSurf)
+	return nil
+}
@@ -0,0 +1,429 @@
+package views
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/list"
+	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// AlertsViewParams holds configuration for creating an AlertsView.
+type AlertsViewParams struct {
+	Service   internal.AlertServiceAPI
+	StaleTTL  time.Duration
+	PostEvent func(vaxis.Event)
+}
+
+// AlertsView lists active TrueNAS alerts: the contents of the web UI's bell
+// icon. Changes arrive through a subscription rather than polling.
+type AlertsView struct {
+	service   internal.AlertServiceAPI
+	alerts    []internal.Alert
+	rows      []*internal.Alert // alerts after filtering and sorting
+	seen      map[string]bool   // alert IDs the user has had on screen
+	query     listQuery
+	list      list.Dynamic
+	loaded    bool
+	loadedAt  time.Time
+	staleTTL  time.Duration
+	postEvent func(vaxis.Event)
+	act       actions
+	cancelSub context.CancelFunc
+
+	// RetryBaseDelay is the base delay for subscription retry backoff.
+	// Defaults to 1s; tests can set to a small value.
+	RetryBaseDelay time.Duration
+
+	loadError
+}
+
+// NewAlertsView creates an AlertsView backed by the given params.
+func NewAlertsView(p AlertsViewParams) *AlertsView {
+	av := &AlertsView{
+		service:   p.Service,
+		staleTTL:  p.StaleTTL,
+		postEvent: p.PostEvent,
+		seen:      make(map[string]bool),
+		query:     newListQuery("LEVEL", "SOURCE", "TIME"),
+		act:       actions{postEvent: p.PostEvent},
+	}
+	av.list.DrawCursor = true
+	av.list.Builder = av.buildItem
+	clickableRows(&av.list, nil)
+	return av
+}
+
+// Load fetches alerts from the service.
+func (av *AlertsView) Load(ctx context.Context) error {
+	alerts, err := av.service.List(ctx)
+	if err != nil {
+		return err
+	}
+	av.alerts = alerts
+	av.applyQuery()
+	av.loaded = true
+	av.loadedAt = time.Now()
+	return nil
+}
+
+// Loaded reports whether data has been successfully fetched.
+func (av *AlertsView) Loaded() bool {
+	return av.loaded
+}
+
+// Stale reports whether the cached data is older than the configured TTL.
+func (av *AlertsView) Stale() bool {
+	if !av.loaded {
+		return true
+	}
+	return time.Since(av.loadedAt) > av.staleTTL
+}
+
+// ItemCount returns the number of loaded alerts, including dismissed ones.
+func (av *AlertsView) ItemCount() int {
+	return len(av.alerts)
+}
+
+// RowCount returns the number of alerts shown after filtering.
+func (av *AlertsView) RowCount() int {
+	return len(av.rows)
+}
+
+// SelectedAlert returns the currently selected alert, or nil if empty.
+func (av *AlertsView) SelectedAlert() *internal.Alert {
+	idx := int(av.list.Cursor())
+	if idx >= len(av.rows) {
+		return nil
+	}
+	return av.rows[idx]
+}
+
+// Unread returns the number of active (not dismissed) alerts the user has
+// not seen on the Alerts tab.
+func (av *AlertsView) Unread() int {
+	n := 0
+	for _, a := range av.alerts {
+		if !a.Dismissed && !av.seen[a.ID] {
+			n++
+		}
+	}
+	return n
+}
+
+// MarkRead records every loaded alert as seen, clearing the unread count.
+func (av *AlertsView) MarkRead() {
+	for _, a := range av.alerts {
+		av.seen[a.ID] = true
+	}
+}
+
+// StartSubscription watches the server's alert list and posts AlertsChanged
+// whenever it changes. It is a no-op if the subscription is already running.
+func (av *AlertsView) StartSubscription(ctx context.Context) {
+	if av.cancelSub != nil {
+		return
+	}
+	subCtx, cancel := context.WithCancel(ctx)
+	av.cancelSub = cancel
+	go av.runSub(subCtx)
+}
+
+// StopSubscription terminates the alert subscription.
+func (av *AlertsView) StopSubscription() {
+	if av.cancelSub != nil {
+		av.cancelSub()
+		av.cancelSub = nil
+	}
+}
+
+func (av *AlertsView) runSub(ctx context.Context) {
+	for attempt := 0; ; attempt++ {
+		sub, err := av.service.Subscribe(ctx)
+		if err != nil {
+			logging.Warnf("alert subscription failed: %v (attempt %d)", err, attempt+1)
+			if !retryBackoff(ctx, av.RetryBaseDelay, attempt) {
+				return
+			}
+			continue
+		}
+		if sub == nil {
+			return // transport without subscriptions
+		}
+		if attempt > 0 {
+			// Changes may have been missed while disconnected.
+			av.post(AlertsChanged{View: av})
+		}
+
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case _, open = <-sub.C:
+				if open {
+					av.post(AlertsChanged{View: av})
+				}
+			}
+		}
+		logging.Warnf("alert subscription closed, reconnecting...")
+		attempt = 0 // the next attempt is a reconnect and resyncs
+	}
+}
+
+func (av *AlertsView) post(ev vaxis.Event) {
+	if av.postEvent != nil {
+		av.postEvent(ev)
+	}
+}
+
+// applyQuery rebuilds the visible rows from the loaded alerts, keeping the
+// selected alert under the cursor when it is still shown. The default order
+// puts active alerts first, most severe and most recent at the top.
+func (av *AlertsView) applyQuery() {
+	var selected string
+	if a := av.SelectedAlert(); a != nil {
+		selected = a.ID
+	}
+
+	rows := make([]*internal.Alert, 0, len(av.alerts))
+	for i := range av.alerts {
+		a := &av.alerts[i]
+		if av.query.matches(a.Level, a.Source, a.Text) {
+			rows = append(rows, a)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if av.query.sortCol >= 0 {
+			var c int
+			switch av.query.columns[av.query.sortCol] {
+			case "LEVEL":
+				c = cmp.Compare(a.Severity(), b.Severity())
+			case "SOURCE":
+				c = strings.Compare(a.Source, b.Source)
+			case "TIME":
+				c = a.Datetime.Compare(b.Datetime)
+			}
+			return av.query.ordered(c)
+		}
+		if a.Dismissed != b.Dismissed {
+			return !a.Dismissed
+		}
+		if a.Severity() != b.Severity() {
+			return a.Severity() > b.Severity()
+		}
+		return a.Datetime.After(b.Datetime)
+	})
+	av.rows = rows
+
+	av.list.SetCursor(0)
+	for i, a := range rows {
+		if a.ID == selected {
+			av.list.SetCursor(uint(i))
+			break
+		}
+	}
+}
+
+// alertLevelStyle colours an alert level: red for ERROR and above, yellow
+// for WARNING.
+func alertLevelStyle(a *internal.Alert) vaxis.Style {
+	switch {
+	case a.Severity() >= internal.AlertSeverity(internal.AlertLevelError):
+		return theme.Critical()
+	case a.Level == internal.AlertLevelWarning:
+		return theme.Warning()
+	}
+	return vaxis.Style{}
+}
+
+func (av *AlertsView) buildItem(i uint, cursor uint) vxfw.Widget {
+	if int(i) >= len(av.rows) {
+		return nil
+	}
+	a := av.rows[i]
+
+	levelStyle := alertLevelStyle(a)
+	textStyle := vaxis.Style{}
+	text := strings.Join(strings.Fields(a.Text), " ")
+	if a.Dismissed {
+		levelStyle = theme.Dim()
+		textStyle = levelStyle
+		text = "(dismissed) " + text
+	}
+
+	return richtext.New([]vaxis.Segment{
+		{Text: fmt.Sprintf("%-10s", a.Level), Style: levelStyle},
+		{Text: fmt.Sprintf("%-28s", truncate(a.Source, 27)), Style: textStyle},
+		{Text: fmt.Sprintf("%-18s", formatAlertTime(a.Datetime)), Style: textStyle},
+		{Text: text, Style: textStyle},
+	})
+}
+
+// formatAlertTime renders an alert timestamp in local time.
+func formatAlertTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// Draw renders the alerts list, or a loading or error state if data
+// hasn't arrived.
+func (av *AlertsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	if !av.loaded {
+		return drawLoadingState(ctx, av, &av.loadError)
+	}
+	return drawErrorBanner(ctx, av, &av.loadError, av.draw)
+}
+
+func (av *AlertsView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, av)
+
+	q := &av.query
+	header := richtext.New([]vaxis.Segment{
+		{Text: fmt.Sprintf("  %-10s%-28s%-18s%s",
+			q.column("LEVEL"), q.column("SOURCE"), q.column("TIME"), "MESSAGE"),
+			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: q.summary(len(av.rows), len(av.alerts)), Style: theme.Dim()},
+	})
+	headerSurf, err := header.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 0, headerSurf)
+
+	listHeight := ctx.Max.Height - 1 - min(av.act.statusHeight(), ctx.Max.Height-1)
+	if len(av.alerts) == 0 {
+		empty := richtext.New([]vaxis.Segment{
+			{Text: "  No alerts", Style: theme.Dim()},
+		})
+		emptySurf, err := empty.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, 1, emptySurf)
+	} else {
+		listSurf, err := av.list.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: listHeight}))
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, 1, listSurf)
+	}
+
+	if err := av.act.draw(ctx, &s); err != nil {
+		return vxfw.Surface{}, err
+	}
+	return s, nil
+}
+
+// CapturingInput reports whether the filter prompt is open.
+func (av *AlertsView) CapturingInput() bool {
+	return av.query.editing
+}
+
+// Status returns the status line text from the last action.
+func (av *AlertsView) Status() string {
+	return av.act.status
+}
+
+// ActionDone records the outcome of a dismiss or restore.
+func (av *AlertsView) ActionDone(ev ActionCompleted) {
+	av.act.done(ev)
+}
+
+// KeyScopes returns the bindings of the Alerts tab for the help.
+func (av *AlertsView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.AlertsScope, keys.QueryScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the Alerts tab.
+func (av *AlertsView) Commands() []PaletteCommand {
+	a := av.SelectedAlert()
+	return append([]PaletteCommand{
+		{Name: "Dismiss alert", Key: keys.DismissAlert.Short(), Disabled: a == nil || a.Dismissed, Run: av.dismiss},
+		{Name: "Restore alert", Key: keys.RestoreAlert.Short(), Disabled: a == nil || !a.Dismissed, Run: av.restore},
+	}, av.query.commands(av.applyQuery)...)
+}
+
+// HandleEvent handles the filter, sort, dismiss and restore keys and
+// otherwise delegates to the list widget for navigation.
+//
+//	d   dismiss the selected alert
+//	u   restore a dismissed alert
+func (av *AlertsView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	if key, ok := ev.(vaxis.Key); ok && av.loaded {
+		if handled, changed := av.query.handleKey(key); handled {
+			if changed {
+				av.applyQuery()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		switch {
+		case keys.DismissAlert.Matches(key):
+			return av.dismiss()
+		case keys.RestoreAlert.Matches(key):
+			return av.restore()
+		}
+	}
+	return handleListEvent(&av.list, ev, phase)
+}
+
+// dismiss dismisses the selected alert. Dismissing is reversible, so there
+// is no confirmation.
+func (av *AlertsView) dismiss() (vxfw.Command, error) {
+	a := av.SelectedAlert()
+	if a == nil {
+		return nil, nil
+	}
+	if a.Dismissed {
+		av.act.setStatus("Error: alert is already dismissed; restore it"+withKey(keys.RestoreAlert), true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id, source := a.ID, a.Source
+	return av.act.run(av, "Dismissing "+source+" alert...", func(ctx context.Context) (string, error) {
+		if err := av.service.Dismiss(ctx, id); err != nil {
+			return "", fmt.Errorf("dismiss %s alert: %w", source, err)
+		}
+		return "Dismissed " + source + " alert", nil
+	})
+}
+
+// restore brings the selected dismissed alert back.
+func (av *AlertsView) restore() (vxfw.Command, error) {
+	a := av.SelectedAlert()
+	if a == nil {
+		return nil, nil
+	}
+	if !a.Dismissed {
+		av.act.setStatus("Error: alert is not dismissed", true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id, source := a.ID, a.Source
+	return av.act.run(av, "Restoring "+source+" alert...", func(ctx context.Context) (string, error) {
+		if err := av.service.Restore(ctx, id); err != nil {
+			return "", fmt.Errorf("restore %s alert: %w", source, err)
+		}
+		return "Restored " + source + " alert", nil
+	})
+}
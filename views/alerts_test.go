@@ -0,0 +1,281 @@
+package views_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/views"
+)
+
+func testAlerts() []internal.Alert {
+	day := time.Date(2026, 10, 1, 12, 0, 0, 0, time.Local)
+	return []internal.Alert{
+		{ID: "a1", Level: internal.AlertLevelWarning, Source: "ZpoolCapacityWarning", Text: "Space usage for pool \"tank\" is 85%.", Datetime: day},
+		{ID: "a2", Level: internal.AlertLevelInfo, Source: "Update", Text: "An update is available.", Datetime: day, Dismissed: true},
+		{ID: "a3", Level: internal.AlertLevelCritical, Source: "SMART", Text: "Device sdb\nfailed a SMART test.", Datetime: day.Add(-time.Hour)},
+		{ID: "a4", Level: internal.AlertLevelWarning, Source: "ReplicationFailed", Text: "Replication task failed.", Datetime: day.Add(time.Hour)},
+	}
+}
+
+func newAlertsView(mock *internal.MockAlertService) (*views.AlertsView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 8)
+	av := views.NewAlertsView(views.AlertsViewParams{
+		Service:   mock,
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	return av, events
+}
+
+// alertIDs walks the list from the top and returns the alert IDs in row order.
+func alertIDs(t *testing.T, av *views.AlertsView) []string {
+	t.Helper()
+	for range av.RowCount() {
+		sendKey(t, av, vaxis.Key{Keycode: 'k', Text: "k"})
+	}
+	var ids []string
+	for i := range av.RowCount() {
+		if i > 0 {
+			sendKey(t, av, vaxis.Key{Keycode: 'j', Text: "j"})
+		}
+		ids = append(ids, av.SelectedAlert().ID)
+	}
+	return ids
+}
+
+func TestAlertsView_Load(t *testing.T) {
+	av, _ := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return testAlerts(), nil },
+	})
+	if err := av.Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !av.Loaded() || av.ItemCount() != 4 {
+		t.Fatalf("expected 4 loaded alerts, got %d", av.ItemCount())
+	}
+
+	// Active first, most severe first, then newest.
+	if got := strings.Join(alertIDs(t, av), ","); got != "a3,a4,a1,a2" {
+		t.Errorf("unexpected order %s", got)
+	}
+
+	text := drawText(t, av)
+	for _, want := range []string{"LEVEL", "CRITICAL", "SMART", "2026-10-01 11:00", "Device sdb failed a SMART test.", "(dismissed) An update"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+}
+
+func TestAlertsView_Load_Error(t *testing.T) {
+	av, _ := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return nil, errors.New("boom") },
+	})
+	if err := av.Load(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if av.Loaded() {
+		t.Error("expected Loaded to stay false on error")
+	}
+}
+
+func TestAlertsView_Empty(t *testing.T) {
+	av, _ := newAlertsView(&internal.MockAlertService{})
+	_ = av.Load(context.Background())
+	if !strings.Contains(drawText(t, av), "No alerts") {
+		t.Error("expected empty state")
+	}
+}
+
+func TestAlertsView_Unread(t *testing.T) {
+	alerts := testAlerts()
+	av, _ := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return alerts, nil },
+	})
+	_ = av.Load(context.Background())
+	if av.Unread() != 3 {
+		t.Errorf("expected 3 unread (dismissed excluded), got %d", av.Unread())
+	}
+	av.MarkRead()
+	if av.Unread() != 0 {
+		t.Errorf("expected 0 unread after MarkRead, got %d", av.Unread())
+	}
+
+	alerts = append(alerts, internal.Alert{ID: "a5", Level: internal.AlertLevelError})
+	_ = av.Load(context.Background())
+	if av.Unread() != 1 {
+		t.Errorf("expected the new alert to be unread, got %d", av.Unread())
+	}
+}
+
+func TestAlertsView_Dismiss(t *testing.T) {
+	var dismissed string
+	av, events := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return testAlerts(), nil },
+		DismissFunc: func(ctx context.Context, id string) error {
+			dismissed = id
+			return nil
+		},
+	})
+	_ = av.Load(context.Background())
+
+	sendKey(t, av, vaxis.Key{Keycode: 'd', Text: "d"})
+	av.ActionDone(waitActionCompleted(t, events))
+	if dismissed != "a3" {
+		t.Errorf("expected a3 dismissed, got %q", dismissed)
+	}
+	if av.Status() != "Dismissed SMART alert" {
+		t.Errorf("unexpected status %q", av.Status())
+	}
+}
+
+func TestAlertsView_Commands(t *testing.T) {
+	var dismissed string
+	av, events := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return testAlerts(), nil },
+		DismissFunc: func(ctx context.Context, id string) error {
+			dismissed = id
+			return nil
+		},
+	})
+	_ = av.Load(context.Background())
+
+	cmds := map[string]views.PaletteCommand{}
+	for _, c := range av.Commands() {
+		cmds[c.Name] = c
+	}
+	if c := cmds["Dismiss alert"]; c.Disabled || c.Key != "d" {
+		t.Errorf("expected Dismiss alert enabled on d, got %+v", c)
+	}
+	if !cmds["Restore alert"].Disabled {
+		t.Error("expected Restore alert disabled for an active alert")
+	}
+	if !cmds["Clear filter"].Disabled {
+		t.Error("expected Clear filter disabled without a filter")
+	}
+
+	if _, err := cmds["Dismiss alert"].Run(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	av.ActionDone(waitActionCompleted(t, events))
+	if dismissed != "a3" {
+		t.Errorf("expected a3 dismissed, got %q", dismissed)
+	}
+}
+
+func TestAlertsView_Restore(t *testing.T) {
+	var restored string
+	av, events := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return testAlerts(), nil },
+		RestoreFunc: func(ctx context.Context, id string) error {
+			restored = id
+			return errors.New("not found")
+		},
+	})
+	_ = av.Load(context.Background())
+
+	// Restoring an active alert is refused.
+	sendKey(t, av, vaxis.Key{Keycode: 'u', Text: "u"})
+	if !strings.Contains(av.Status(), "not dismissed") {
+		t.Errorf("unexpected status %q", av.Status())
+	}
+
+	for range 3 {
+		sendKey(t, av, vaxis.Key{Keycode: 'j', Text: "j"})
+	}
+	sendKey(t, av, vaxis.Key{Keycode: 'd', Text: "d"})
+	if !strings.Contains(av.Status(), "already dismissed; restore it with u") {
+		t.Errorf("unexpected status %q", av.Status())
+	}
+
+	sendKey(t, av, vaxis.Key{Keycode: 'u', Text: "u"})
+	av.ActionDone(waitActionCompleted(t, events))
+	if restored != "a2" {
+		t.Errorf("expected a2 restored, got %q", restored)
+	}
+	if av.Status() != "Error: restore Update alert: not found" {
+		t.Errorf("unexpected status %q", av.Status())
+	}
+}
+
+func TestAlertsView_FilterAndSort(t *testing.T) {
+	av, _ := newAlertsView(&internal.MockAlertService{
+		ListFunc: func(ctx context.Context) ([]internal.Alert, error) { return testAlerts(), nil },
+	})
+	_ = av.Load(context.Background())
+
+	sendKey(t, av, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, av, "replication")
+	sendKey(t, av, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if av.RowCount() != 1 || av.SelectedAlert().ID != "a4" {
+		t.Fatalf("expected only a4 to match, got %d rows", av.RowCount())
+	}
+	sendKey(t, av, vaxis.Key{Keycode: vaxis.KeyEsc})
+
+	s := vaxis.Key{Keycode: 's', Text: "s"}
+	sendKey(t, av, s) // LEVEL ascending
+	if got := strings.Join(alertIDs(t, av), ","); got != "a2,a1,a4,a3" {
+		t.Errorf("unexpected LEVEL order %s", got)
+	}
+	sendKey(t, av, s) // SOURCE
+	sendKey(t, av, s) // TIME
+	if got := strings.Join(alertIDs(t, av), ","); got != "a3,a1,a2,a4" {
+		t.Errorf("unexpected TIME order %s", got)
+	}
+}
+
+func TestAlertsView_Subscription(t *testing.T) {
+	ch := make(chan struct{}, 1)
+	av, events := newAlertsView(&internal.MockAlertService{
+		SubscribeFunc: func(ctx context.Context) (*truenas.Subscription[struct{}], error) {
+			return truenas.NewSubscription[struct{}](ch, func() {}), nil
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	av.StartSubscription(ctx)
+	av.StartSubscription(ctx) // second call is a no-op
+	defer av.StopSubscription()
+
+	ch <- struct{}{}
+	select {
+	case ev := <-events:
+		if _, ok := ev.(views.AlertsChanged); !ok {
+			t.Errorf("expected AlertsChanged, got %T", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for AlertsChanged")
+	}
+}
+
+func TestAlertsView_Subscription_Reconnects(t *testing.T) {
+	calls := make(chan struct{}, 4)
+	av, events := newAlertsView(&internal.MockAlertService{
+		SubscribeFunc: func(ctx context.Context) (*truenas.Subscription[struct{}], error) {
+			calls <- struct{}{}
+			if len(calls) == 1 {
+				return nil, errors.New("connection refused")
+			}
+			return truenas.NewSubscription[struct{}](make(chan struct{}), func() {}), nil
+		},
+	})
+	av.RetryBaseDelay = time.Millisecond
+	av.StartSubscription(context.Background())
+	defer av.StopSubscription()
+
+	// The retry resyncs, since changes may have been missed.
+	select {
+	case ev := <-events:
+		if _, ok := ev.(views.AlertsChanged); !ok {
+			t.Errorf("expected AlertsChanged, got %T", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for resync")
+	}
+}
@@ -0,0 +1,207 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// ConsoleViewParams holds configuration for creating a ConsoleView.
+type ConsoleViewParams struct {
+	Buffer    *logging.Buffer
+	PostEvent func(vaxis.Event)
+	// OnClose is called when the user closes the console.
+	OnClose func() (vxfw.Command, error)
+}
+
+// consoleFilters are the minimum levels the console cycles through with f.
+var consoleFilters = []logging.Level{logging.LevelInfo, logging.LevelWarn, logging.LevelError}
+
+// ConsoleView is an overlay showing recent log entries: load errors,
+// subscription retries, connection changes and anything else logged.
+//
+//	j/k      scroll (scrolling up stops following, G follows again)
+//	g/G      top / bottom
+//	f        cycle the filter: all, warnings and errors, errors only
+//	~, Esc   close the console
+type ConsoleView struct {
+	buffer    *logging.Buffer
+	postEvent func(vaxis.Event)
+	onClose   func() (vxfw.Command, error)
+
+	mu            sync.Mutex
+	redrawPending bool
+
+	filter int // index into consoleFilters
+	top    int
+	height int // body height from the last draw
+	follow bool
+}
+
+// NewConsoleView creates a ConsoleView over the buffer. Call Open to start
+// redrawing as entries arrive.
+func NewConsoleView(p ConsoleViewParams) *ConsoleView {
+	return &ConsoleView{
+		buffer:    p.Buffer,
+		postEvent: p.PostEvent,
+		onClose:   p.OnClose,
+		follow:    true,
+	}
+}
+
+// Open asks for a redraw whenever an entry is logged. Bursts of entries
+// post a single event.
+func (cv *ConsoleView) Open() {
+	cv.follow = true
+	cv.buffer.SetNotify(func() {
+		cv.mu.Lock()
+		post := !cv.redrawPending && cv.postEvent != nil
+		cv.redrawPending = true
+		cv.mu.Unlock()
+		if post {
+			// Entries are logged from any goroutine, including the UI
+			// thread, so never block on the event queue here.
+			go cv.postEvent(DashboardUpdated{})
+		}
+	})
+}
+
+// Close stops redrawing as entries arrive.
+func (cv *ConsoleView) Close() {
+	cv.buffer.SetNotify(nil)
+}
+
+// Filter returns the minimum level shown.
+func (cv *ConsoleView) Filter() logging.Level {
+	return consoleFilters[cv.filter]
+}
+
+// Following reports whether the console scrolls to new entries.
+func (cv *ConsoleView) Following() bool {
+	return cv.follow
+}
+
+func (cv *ConsoleView) close() (vxfw.Command, error) {
+	cv.Close()
+	if cv.onClose != nil {
+		return cv.onClose()
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// HandleEvent handles the console's keys and the mouse wheel. Everything else
+// is swallowed so keys don't act on the view underneath.
+func (cv *ConsoleView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	total := len(cv.buffer.Entries(cv.Filter()))
+	last := max(total-cv.height, 0)
+	page := max(cv.height-1, 1)
+	scroll := func(n int) {
+		cv.top = min(max(cv.top+n, 0), last)
+		if n < 0 {
+			cv.follow = false
+		}
+	}
+	if n, ok := wheelScroll(ev); ok {
+		scroll(n)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	switch {
+	case keys.CloseConsole.Matches(key):
+		return cv.close()
+	case keys.Down.Matches(key):
+		scroll(1)
+	case keys.Up.Matches(key):
+		scroll(-1)
+	case keys.PageDown.Matches(key):
+		scroll(page)
+	case keys.PageUp.Matches(key):
+		scroll(-page)
+	case keys.Top.Matches(key):
+		cv.top, cv.follow = 0, false
+	case keys.Bottom.Matches(key):
+		cv.follow = true
+	case keys.ConsoleFilter.Matches(key):
+		cv.filter = (cv.filter + 1) % len(consoleFilters)
+		cv.follow = true
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// consoleLevelStyle colours an entry's level.
+func consoleLevelStyle(l logging.Level) vaxis.Style {
+	switch l {
+	case logging.LevelWarn:
+		return theme.Warning()
+	case logging.LevelError:
+		return theme.Bold(theme.Critical())
+	}
+	return theme.Dim()
+}
+
+// Draw renders a header rule, the visible entries and the key hints, filling
+// the whole area so nothing underneath shows through.
+func (cv *ConsoleView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, cv)
+	width := int(ctx.Max.Width)
+	bodyHeight := int(ctx.Max.Height) - 2
+	if bodyHeight < 1 {
+		return s, nil
+	}
+
+	cv.mu.Lock()
+	cv.redrawPending = false
+	cv.mu.Unlock()
+
+	entries := cv.buffer.Entries(cv.Filter())
+	cv.height = bodyHeight
+	if cv.follow {
+		cv.top = max(len(entries)-bodyHeight, 0)
+	}
+	cv.top = min(cv.top, max(len(entries)-bodyHeight, 0))
+	visible := entries[cv.top:min(cv.top+bodyHeight, len(entries))]
+
+	// Header
+	bold := vaxis.Style{Attribute: vaxis.AttrBold}
+	dim := theme.Dim()
+	filter := "ALL"
+	if f := cv.Filter(); f > logging.LevelInfo {
+		filter = f.String() + "+"
+	}
+	header := []vaxis.Segment{
+		{Text: "── ", Style: dim},
+		{Text: "CONSOLE", Style: bold},
+		{Text: fmt.Sprintf("  %s  %d entries ", filter, len(entries)), Style: dim},
+	}
+	if cv.follow {
+		header = append(header, vaxis.Segment{Text: "FOLLOW ", Style: theme.OK()})
+	}
+	header = append(header, vaxis.Segment{Text: strings.Repeat("─", width), Style: dim})
+	writeSegments(&s, 0, width, header)
+
+	// Body
+	if len(entries) == 0 {
+		writeCell(&s, 1, 1, width-1, "Nothing logged yet", dim, false)
+	}
+	for i, e := range visible {
+		writeSegments(&s, uint16(i+1), width, []vaxis.Segment{
+			{Text: " " + e.Time.Format("15:04:05") + " ", Style: dim},
+			{Text: fmt.Sprintf("%-5s ", e.Level), Style: consoleLevelStyle(e.Level)},
+			{Text: e.Message},
+		})
+	}
+
+	hints := fmt.Sprintf(" %s filter · %s/%s scroll · %s/%s top/bottom · %s close",
+		keys.ConsoleFilter.Short(), keys.Down.Short(), keys.Up.Short(), keys.Top.Short(), keys.Bottom.Short(), keys.CloseConsole.Short())
+	writeCell(&s, 0, uint16(ctx.Max.Height-1), width, hints, dim, false)
+	return s, nil
+}
@@ -0,0 +1,133 @@
+package views_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/views"
+)
+
+func consoleEntry(level logging.Level, msg string) logging.Entry {
+	return logging.Entry{Time: time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local), Level: level, Message: msg}
+}
+
+func TestConsoleView_Draw(t *testing.T) {
+	buf := logging.NewBuffer(10)
+	buf.Add(consoleEntry(logging.LevelInfo, "home: connected"))
+	buf.Add(consoleEntry(logging.LevelError, "home: error loading tab 1: timeout"))
+	cv := views.NewConsoleView(views.ConsoleViewParams{Buffer: buf})
+
+	text := drawText(t, cv)
+	for _, want := range []string{"CONSOLE", "ALL", "2 entries", "12:00:00 INFO  home: connected", "ERROR home: error loading tab 1"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in console", want)
+		}
+	}
+}
+
+func TestConsoleView_Filter(t *testing.T) {
+	buf := logging.NewBuffer(10)
+	buf.Add(consoleEntry(logging.LevelInfo, "connected"))
+	buf.Add(consoleEntry(logging.LevelWarn, "subscription retrying"))
+	buf.Add(consoleEntry(logging.LevelError, "connection lost"))
+	cv := views.NewConsoleView(views.ConsoleViewParams{Buffer: buf})
+
+	sendKey(t, cv, vaxis.Key{Keycode: 'f', Text: "f"})
+	if cv.Filter() != logging.LevelWarn {
+		t.Fatalf("expected WARN filter, got %s", cv.Filter())
+	}
+	text := drawText(t, cv)
+	if strings.Contains(text, "connected") || !strings.Contains(text, "subscription retrying") || !strings.Contains(text, "WARN+") {
+		t.Error("expected only warnings and errors")
+	}
+
+	sendKey(t, cv, vaxis.Key{Keycode: 'f', Text: "f"})
+	text = drawText(t, cv)
+	if strings.Contains(text, "subscription retrying") || !strings.Contains(text, "connection lost") {
+		t.Error("expected only errors")
+	}
+
+	sendKey(t, cv, vaxis.Key{Keycode: 'f', Text: "f"})
+	if cv.Filter() != logging.LevelInfo {
+		t.Errorf("expected filter to wrap to ALL, got %s", cv.Filter())
+	}
+}
+
+func TestConsoleView_FollowAndScroll(t *testing.T) {
+	buf := logging.NewBuffer(100)
+	for i := range 50 {
+		buf.Add(consoleEntry(logging.LevelInfo, fmt.Sprintf("entry %02d", i)))
+	}
+	cv := views.NewConsoleView(views.ConsoleViewParams{Buffer: buf})
+
+	if text := drawText(t, cv); !strings.Contains(text, "entry 49") || strings.Contains(text, "entry 00") {
+		t.Fatal("expected the console to follow the newest entries")
+	}
+
+	sendKey(t, cv, vaxis.Key{Keycode: 'g', Text: "g"})
+	if cv.Following() {
+		t.Error("expected g to stop following")
+	}
+	if text := drawText(t, cv); !strings.Contains(text, "entry 00") {
+		t.Error("expected the oldest entry at the top")
+	}
+
+	sendKey(t, cv, vaxis.Key{Keycode: 'G', ShiftedCode: 'G', Modifiers: vaxis.ModShift, Text: "G"})
+	if !cv.Following() {
+		t.Error("expected G to follow again")
+	}
+}
+
+func TestConsoleView_RedrawsWhileOpen(t *testing.T) {
+	buf := logging.NewBuffer(10)
+	posted := make(chan vaxis.Event, 10)
+	cv := views.NewConsoleView(views.ConsoleViewParams{
+		Buffer:    buf,
+		PostEvent: func(ev vaxis.Event) { posted <- ev },
+	})
+	cv.Open()
+	t.Cleanup(cv.Close)
+
+	buf.Add(consoleEntry(logging.LevelWarn, "first"))
+	buf.Add(consoleEntry(logging.LevelWarn, "second"))
+	select {
+	case ev := <-posted:
+		if _, ok := ev.(views.DashboardUpdated); !ok {
+			t.Fatalf("expected DashboardUpdated, got %T", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a redraw")
+	}
+	select {
+	case <-posted:
+		t.Error("expected a burst of entries to post a single redraw")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cv.Close()
+	drawText(t, cv)
+	buf.Add(consoleEntry(logging.LevelWarn, "third"))
+	select {
+	case <-posted:
+		t.Error("expected no redraws once closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConsoleView_Close(t *testing.T) {
+	closed := 0
+	cv := views.NewConsoleView(views.ConsoleViewParams{
+		Buffer:  logging.NewBuffer(10),
+		OnClose: func() (vxfw.Command, error) { closed++; return nil, nil },
+	})
+	sendKey(t, cv, vaxis.Key{Keycode: '~', Text: "~"})
+	sendKey(t, cv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if closed != 2 {
+		t.Errorf("expected ~ and Esc to close, got %d calls", closed)
+	}
+}
@@ -3,7 +3,6 @@ package views
 import (
 	"context"
 	"fmt"
-	"log"
 	"sort"
 	"sync"
 	"time"
@@ -13,6 +12,9 @@ import (
 	"git.sr.ht/~rockorager/vaxis/vxfw/list"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
 	"github.com/deevus/truenas-tui/widgets"
 	"github.com/dustin/go-humanize"
 	"golang.org/x/sync/errgroup"
@@ -57,10 +59,18 @@ type DashboardView struct {
 	appRows   []appRow
 	loaded    bool
 	postEvent func(vaxis.Event)
+	act       actions
+	logs      *LogView // full-screen log pane, nil when closed
+
+	// pending maps an app name to the transitional state shown while a
+	// lifecycle job runs on it, e.g. "STOPPING" (protected by mu).
+	pending map[string]string
 
 	// RetryBaseDelay is the base delay for subscription retry backoff.
 	// Defaults to 1s; tests can set to a small value.
 	RetryBaseDelay time.Duration
+
+	loadError
 }
 
 type appRow struct {
@@ -80,9 +90,12 @@ func NewDashboardView(p DashboardViewParams) *DashboardView {
 		postEvent: p.PostEvent,
 		cpuSpark:  widgets.NewSparkline(60),
 		appStats:  make(map[string]truenas.AppStats),
+		act:       actions{postEvent: p.PostEvent},
+		pending:   make(map[string]string),
 	}
 	dv.appList.DrawCursor = true
 	dv.appList.Builder = dv.buildAppItem
+	clickableRows(&dv.appList, dv.appClicked)
 	return dv
 }
 
@@ -138,8 +151,10 @@ func (dv *DashboardView) Load(ctx context.Context) error {
 	dv.sysInfo = sysInfo
 	dv.sysVersion = version
 	dv.interfaces = ifaces
+	dv.mu.Lock()
 	dv.apps = apps
 	dv.rebuildAppRows()
+	dv.mu.Unlock()
 	dv.loaded = true
 	return nil
 }
@@ -149,8 +164,13 @@ func (dv *DashboardView) Loaded() bool {
 	return dv.loaded
 }
 
-// StartSubscriptions begins streaming realtime and app stats data.
+// StartSubscriptions begins streaming realtime and app stats data. It is a
+// no-op while the subscriptions are already running, so reloading the
+// dashboard does not start duplicates.
 func (dv *DashboardView) StartSubscriptions(ctx context.Context) {
+	if dv.cancelSubs != nil {
+		return
+	}
 	subCtx, cancel := context.WithCancel(ctx)
 	dv.cancelSubs = cancel
 
@@ -162,6 +182,7 @@ func (dv *DashboardView) StartSubscriptions(ctx context.Context) {
 func (dv *DashboardView) StopSubscriptions() {
 	if dv.cancelSubs != nil {
 		dv.cancelSubs()
+		dv.cancelSubs = nil
 	}
 	if dv.realtimeSub != nil {
 		dv.realtimeSub.Close()
@@ -173,7 +194,13 @@ func (dv *DashboardView) StopSubscriptions() {
 
 // retryBackoff sleeps with exponential backoff, returning false if ctx is cancelled.
 func (dv *DashboardView) retryBackoff(ctx context.Context, attempt int) bool {
-	base := dv.RetryBaseDelay
+	return retryBackoff(ctx, dv.RetryBaseDelay, attempt)
+}
+
+// retryBackoff sleeps base*2^attempt (capped at base*32) before a
+// subscription is retried, returning false if ctx is cancelled. A zero base
+// means one second.
+func retryBackoff(ctx context.Context, base time.Duration, attempt int) bool {
 	if base == 0 {
 		base = time.Second
 	}
@@ -190,7 +217,7 @@ func (dv *DashboardView) runRealtimeSub(ctx context.Context) {
 	for attempt := 0; ; attempt++ {
 		sub, err := dv.reportSvc.SubscribeRealtime(ctx)
 		if err != nil {
-			log.Printf("realtime subscription failed: %v (attempt %d)", err, attempt+1)
+			logging.Warnf("realtime subscription failed: %v (attempt %d)", err, attempt+1)
 			if !dv.retryBackoff(ctx, attempt) {
 				return
 			}
@@ -207,7 +234,7 @@ func (dv *DashboardView) runRealtimeSub(ctx context.Context) {
 				return
 			case update, ok := <-sub.C:
 				if !ok {
-					log.Printf("realtime subscription closed, reconnecting...")
+					logging.Warnf("realtime subscription closed, reconnecting...")
 					break
 				}
 				dv.mu.Lock()
@@ -237,7 +264,7 @@ func (dv *DashboardView) runStatsSub(ctx context.Context) {
 	for attempt := 0; ; attempt++ {
 		sub, err := dv.appsSvc.SubscribeStats(ctx)
 		if err != nil {
-			log.Printf("stats subscription failed: %v (attempt %d)", err, attempt+1)
+			logging.Warnf("stats subscription failed: %v (attempt %d)", err, attempt+1)
 			if !dv.retryBackoff(ctx, attempt) {
 				return
 			}
@@ -254,7 +281,7 @@ func (dv *DashboardView) runStatsSub(ctx context.Context) {
 				return
 			case stats, ok := <-sub.C:
 				if !ok {
-					log.Printf("stats subscription closed, reconnecting...")
+					logging.Warnf("stats subscription closed, reconnecting...")
 					break
 				}
 				dv.mu.Lock()
@@ -273,7 +300,14 @@ func (dv *DashboardView) runStatsSub(ctx context.Context) {
 	}
 }
 
+// rebuildAppRows recomputes the app rows from apps and appStats, busiest
+// first. The cursor stays on the same app when the order changes. Callers
+// must hold mu.
 func (dv *DashboardView) rebuildAppRows() {
+	selected := ""
+	if c := int(dv.appList.Cursor()); c < len(dv.appRows) {
+		selected = dv.appRows[c].Name
+	}
 	rows := make([]appRow, 0, len(dv.apps))
 	for _, a := range dv.apps {
 		row := appRow{
@@ -287,9 +321,18 @@ func (dv *DashboardView) rebuildAppRows() {
 		rows = append(rows, row)
 	}
 	sort.Slice(rows, func(i, j int) bool {
-		return rows[i].CPUUsage > rows[j].CPUUsage
+		if rows[i].CPUUsage != rows[j].CPUUsage {
+			return rows[i].CPUUsage > rows[j].CPUUsage
+		}
+		return rows[i].Name < rows[j].Name
 	})
 	dv.appRows = rows
+	for i, r := range rows {
+		if r.Name == selected {
+			dv.appList.SetCursor(uint(i))
+			break
+		}
+	}
 }
 
 // Fixed-width columns for the apps table (CPU%, MEM, STATE).
@@ -382,9 +425,13 @@ func (dv *DashboardView) buildAppItem(i uint, cursor uint) vxfw.Widget {
 	}
 	row := dv.appRows[i]
 
-	stateColor := vaxis.IndexColor(2) // green
-	if row.State != "RUNNING" {
-		stateColor = vaxis.IndexColor(1) // red
+	state := row.State
+	stateStyle := theme.OK()
+	if pending, ok := dv.pending[row.Name]; ok {
+		state = pending
+		stateStyle = theme.Warning()
+	} else if row.State != "RUNNING" {
+		stateStyle = theme.Critical()
 	}
 
 	memStr := ""
@@ -397,13 +444,13 @@ func (dv *DashboardView) buildAppItem(i uint, cursor uint) vxfw.Widget {
 			" " + row.Name,
 			fmt.Sprintf("%.2f%%", row.CPUUsage),
 			memStr,
-			row.State,
+			state,
 		},
 		styles: []vaxis.Style{
 			{},
 			{},
 			{},
-			{Foreground: stateColor},
+			stateStyle,
 		},
 	}
 }
@@ -411,9 +458,15 @@ func (dv *DashboardView) buildAppItem(i uint, cursor uint) vxfw.Widget {
 // Draw renders the dashboard.
 func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	if !dv.loaded {
-		return drawLoadingState(ctx, dv)
+		return drawLoadingState(ctx, dv, &dv.loadError)
+	}
+	if dv.logs != nil {
+		return dv.logs.Draw(ctx)
 	}
+	return drawErrorBanner(ctx, dv, &dv.loadError, dv.draw)
+}
 
+func (dv *DashboardView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	dv.mu.Lock()
 	rt := dv.realtime
 	sparkCount := dv.cpuSpark.Count()
@@ -430,12 +483,12 @@ func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	}
 	headerSegments := []vaxis.Segment{
 		{Text: " " + dv.sysInfo.Hostname + "  ", Style: vaxis.Style{Attribute: vaxis.AttrBold}},
-		{Text: dv.sysVersion + "  ", Style: vaxis.Style{Attribute: vaxis.AttrDim}},
+		{Text: dv.sysVersion + "  ", Style: theme.Dim()},
 		{Text: dv.sysInfo.Model + "  "},
 	}
 	if uptimeStr != "" {
 		headerSegments = append(headerSegments, vaxis.Segment{
-			Text: "Up " + uptimeStr, Style: vaxis.Style{Attribute: vaxis.AttrDim},
+			Text: "Up " + uptimeStr, Style: theme.Dim(),
 		})
 	}
 	header := richtext.New(headerSegments)
@@ -571,12 +624,12 @@ func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 
 		segments := []vaxis.Segment{
 			{Text: fmt.Sprintf(" NET  %-12s", iface.ID), Style: vaxis.Style{Attribute: vaxis.AttrBold}},
-			{Text: fmt.Sprintf("▼ %8s/s", humanize.Bytes(uint64(rxRate))), Style: vaxis.Style{Foreground: vaxis.IndexColor(2)}},
-			{Text: fmt.Sprintf("  ▲ %8s/s", humanize.Bytes(uint64(txRate))), Style: vaxis.Style{Foreground: vaxis.IndexColor(3)}},
+			{Text: fmt.Sprintf("▼ %8s/s", humanize.Bytes(uint64(rxRate))), Style: theme.OK()},
+			{Text: fmt.Sprintf("  ▲ %8s/s", humanize.Bytes(uint64(txRate))), Style: theme.Warning()},
 		}
 		if speedStr != "" {
 			segments = append(segments, vaxis.Segment{
-				Text: "  " + speedStr, Style: vaxis.Style{Attribute: vaxis.AttrDim},
+				Text: "  " + speedStr, Style: theme.Dim(),
 			})
 		}
 		netLabel := richtext.New(segments)
@@ -592,20 +645,22 @@ func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	row++
 
 	// === APPS header ===
-	running := 0
+	dv.mu.Lock()
+	running, total := 0, len(dv.apps)
 	for _, a := range dv.apps {
 		if a.State == "RUNNING" {
 			running++
 		}
 	}
-	appsTitle := fmt.Sprintf(" APPS (%d running / %d total)", running, len(dv.apps))
+	dv.mu.Unlock()
+	appsTitle := fmt.Sprintf(" APPS (%d running / %d total)", running, total)
 	cols := appCols(int(ctx.Max.Width))
 	colHeaders := []string{appsTitle, "CPU%", "MEM", "STATE"}
 	colHeaderStyles := []vaxis.Style{
 		{Attribute: vaxis.AttrBold},
-		{Attribute: vaxis.AttrDim},
-		{Attribute: vaxis.AttrDim},
-		{Attribute: vaxis.AttrDim},
+		theme.Dim(),
+		theme.Dim(),
+		theme.Dim(),
 	}
 
 	colHeaderSurf := vxfw.NewSurface(ctx.Max.Width, 1, dv)
@@ -620,7 +675,7 @@ func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	row++
 
 	// === App list ===
-	remaining := int(ctx.Max.Height) - row
+	remaining := int(ctx.Max.Height) - row - int(dv.act.statusHeight())
 	if remaining > 0 {
 		listCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: uint16(remaining)})
 		listSurf, err := dv.appList.Draw(listCtx)
@@ -630,12 +685,65 @@ func (dv *DashboardView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 		s.AddChild(0, row, listSurf)
 	}
 
+	if err := dv.act.draw(ctx, &s); err != nil {
+		return vxfw.Surface{}, err
+	}
 	return s, nil
 }
 
-// HandleEvent delegates navigation keys to the app list.
+// KeyScopes returns the bindings of the Dashboard tab for the help, or the
+// log pane's while it is open.
+func (dv *DashboardView) KeyScopes() []*keys.Scope {
+	if dv.logs != nil {
+		return []*keys.Scope{keys.LogsScope}
+	}
+	return []*keys.Scope{keys.DashboardScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the selected app.
+func (dv *DashboardView) Commands() []PaletteCommand {
+	app := dv.SelectedApp()
+	none := app == nil || dv.appsSvc == nil
+	running := app != nil && (app.State == "RUNNING" || app.State == "DEPLOYING")
+	return []PaletteCommand{
+		{Name: "Start app", Key: keys.StartApp.Short(), Disabled: none || app.State == "RUNNING", Run: dv.startApp},
+		{Name: "Stop app", Key: keys.StopApp.Short(), Disabled: none || !running, Run: dv.stopApp},
+		{Name: "Restart app", Key: keys.RestartApp.Short(), Disabled: none, Run: dv.restartApp},
+		{Name: "Redeploy app", Key: keys.RedeployApp.Short(), Disabled: none, Run: dv.redeployApp},
+		{Name: "Show app logs", Key: keys.AppLogs.Short(), Disabled: none, Run: dv.openLogs},
+	}
+}
+
+// HandleEvent handles the app lifecycle keys and otherwise delegates
+// navigation keys to the app list.
+//
+//	s  start the selected app
+//	S  stop it (after confirmation)
+//	R  restart it
+//	D  redeploy it
+//	L  open its container logs
 func (dv *DashboardView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
-	return dv.appList.HandleEvent(ev, phase)
+	if dv.logs != nil {
+		return dv.logs.HandleEvent(ev, phase)
+	}
+	if cmd, ok, err := dv.act.handleModal(ev, phase); ok {
+		return cmd, err
+	}
+	if key, ok := ev.(vaxis.Key); ok && dv.loaded {
+		switch {
+		case keys.StartApp.Matches(key):
+			return dv.startApp()
+		case keys.StopApp.Matches(key):
+			return dv.stopApp()
+		case keys.RestartApp.Matches(key):
+			return dv.restartApp()
+		case keys.RedeployApp.Matches(key):
+			return dv.redeployApp()
+		case keys.AppLogs.Matches(key):
+			return dv.openLogs()
+		}
+	}
+	return handleListEvent(&dv.appList, ev, phase)
 }
 
 // FormatUptime converts seconds to a human-readable duration.
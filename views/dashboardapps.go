@@ -0,0 +1,226 @@
+package views
+
+import (
+	"context"
+	"fmt"
+
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+// SelectedApp returns the app under the cursor in the dashboard app list,
+// or nil if the list is empty.
+func (dv *DashboardView) SelectedApp() *truenas.App {
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	c := int(dv.appList.Cursor())
+	if c >= len(dv.appRows) {
+		return nil
+	}
+	name := dv.appRows[c].Name
+	for i := range dv.apps {
+		if dv.apps[i].Name == name {
+			app := dv.apps[i]
+			return &app
+		}
+	}
+	return nil
+}
+
+// AppState returns the state shown for the named app: the transitional
+// state while a lifecycle job runs on it, otherwise the state reported by
+// the server.
+func (dv *DashboardView) AppState(name string) string {
+	dv.mu.Lock()
+	defer dv.mu.Unlock()
+	if pending, ok := dv.pending[name]; ok {
+		return pending
+	}
+	for _, a := range dv.apps {
+		if a.Name == name {
+			return a.State
+		}
+	}
+	return ""
+}
+
+// CapturingInput reports whether a confirmation dialog or the log pane is
+// open.
+func (dv *DashboardView) CapturingInput() bool {
+	return dv.act.modal != nil || dv.logs != nil
+}
+
+// Logs returns the open log pane, or nil.
+func (dv *DashboardView) Logs() *LogView {
+	return dv.logs
+}
+
+// openLogs opens the full-screen log pane for the selected app.
+func (dv *DashboardView) openLogs() (vxfw.Command, error) {
+	app := dv.SelectedApp()
+	if app == nil || dv.appsSvc == nil {
+		return nil, nil
+	}
+	dv.logs = NewLogView(LogViewParams{
+		Service:   dv.appsSvc,
+		App:       *app,
+		PostEvent: dv.postEvent,
+		OnClose:   dv.closeLogs,
+	})
+	dv.logs.Start()
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// appClicked opens the clicked app's logs on a double click.
+func (dv *DashboardView) appClicked(double bool) (vxfw.Command, error) {
+	if double {
+		if cmd, err := dv.openLogs(); cmd != nil || err != nil {
+			return cmd, err
+		}
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// closeLogs stops the log stream and returns to the dashboard.
+func (dv *DashboardView) closeLogs() (vxfw.Command, error) {
+	if dv.logs != nil {
+		dv.logs.Stop()
+		dv.logs = nil
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Status returns the status line text from the last app action.
+func (dv *DashboardView) Status() string {
+	return dv.act.status
+}
+
+// ActionDone records the outcome of an app action and shows the app list
+// the job refetched. It runs on the UI goroutine, so the rows and the
+// list cursor are only changed there.
+func (dv *DashboardView) ActionDone(ev ActionCompleted) {
+	dv.act.done(ev)
+	dv.mu.Lock()
+	dv.rebuildAppRows()
+	dv.mu.Unlock()
+}
+
+// startApp starts the selected app if it is not already running.
+func (dv *DashboardView) startApp() (vxfw.Command, error) {
+	app, ok := dv.actionTarget()
+	if !ok {
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	if app.State == "RUNNING" {
+		dv.act.setStatus("Error: "+app.Name+" is already running", true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	return dv.runAppJob(app.Name, "STARTING", "Starting "+app.Name+"...", func(ctx context.Context) (string, error) {
+		if err := dv.appsSvc.StartApp(ctx, app.Name); err != nil {
+			return "", fmt.Errorf("start %s: %w", app.Name, err)
+		}
+		return "Started " + app.Name, nil
+	})
+}
+
+// stopApp confirms and stops the selected app.
+func (dv *DashboardView) stopApp() (vxfw.Command, error) {
+	app, ok := dv.actionTarget()
+	if !ok {
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	if app.State != "RUNNING" && app.State != "DEPLOYING" {
+		dv.act.setStatus("Error: "+app.Name+" is not running", true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	name := app.Name
+	return dv.act.open(&widgets.Confirm{
+		Title: "Stop app",
+		Lines: []string{"Stop " + name + "?", "Its containers are stopped until it is started again."},
+		OnConfirm: func() (vxfw.Command, error) {
+			return dv.runAppJob(name, "STOPPING", "Stopping "+name+"...", func(ctx context.Context) (string, error) {
+				if err := dv.appsSvc.StopApp(ctx, name); err != nil {
+					return "", fmt.Errorf("stop %s: %w", name, err)
+				}
+				return "Stopped " + name, nil
+			})
+		},
+		OnCancel: dv.act.close,
+	})
+}
+
+// restartApp stops the selected app and starts it again. An app that is
+// not running is just started.
+func (dv *DashboardView) restartApp() (vxfw.Command, error) {
+	app, ok := dv.actionTarget()
+	if !ok {
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	name, running := app.Name, app.State == "RUNNING"
+	return dv.runAppJob(name, "RESTARTING", "Restarting "+name+"...", func(ctx context.Context) (string, error) {
+		if running {
+			if err := dv.appsSvc.StopApp(ctx, name); err != nil {
+				return "", fmt.Errorf("restart %s: stop: %w", name, err)
+			}
+		}
+		if err := dv.appsSvc.StartApp(ctx, name); err != nil {
+			return "", fmt.Errorf("restart %s: start: %w", name, err)
+		}
+		return "Restarted " + name, nil
+	})
+}
+
+// redeployApp redeploys the selected app, recreating its containers.
+func (dv *DashboardView) redeployApp() (vxfw.Command, error) {
+	app, ok := dv.actionTarget()
+	if !ok {
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	name := app.Name
+	return dv.runAppJob(name, "DEPLOYING", "Redeploying "+name+"...", func(ctx context.Context) (string, error) {
+		if err := dv.appsSvc.RedeployApp(ctx, name); err != nil {
+			return "", fmt.Errorf("redeploy %s: %w", name, err)
+		}
+		return "Redeployed " + name, nil
+	})
+}
+
+// actionTarget returns the selected app, refusing with a status message if
+// a job is already running on it.
+func (dv *DashboardView) actionTarget() (*truenas.App, bool) {
+	app := dv.SelectedApp()
+	if app == nil || dv.appsSvc == nil {
+		return nil, false
+	}
+	dv.mu.Lock()
+	pending, busy := dv.pending[app.Name]
+	dv.mu.Unlock()
+	if busy {
+		dv.act.setStatus(fmt.Sprintf("Error: %s is busy (%s)", app.Name, pending), true)
+		return nil, false
+	}
+	return app, true
+}
+
+// runAppJob shows state in the app's row while fn runs in the background.
+// Once the job finishes the app list is refetched so the row shows the
+// state reported by the server, and the transitional state is cleared. The
+// rows are rebuilt by ActionDone, not here, since the job doesn't run on
+// the UI goroutine.
+func (dv *DashboardView) runAppJob(name, state, pending string, fn func(ctx context.Context) (string, error)) (vxfw.Command, error) {
+	dv.mu.Lock()
+	dv.pending[name] = state
+	dv.mu.Unlock()
+	return dv.act.run(dv, pending, func(ctx context.Context) (string, error) {
+		msg, err := fn(ctx)
+		apps, listErr := dv.appsSvc.ListApps(ctx)
+		dv.mu.Lock()
+		if listErr == nil {
+			dv.apps = apps
+		}
+		delete(dv.pending, name)
+		dv.mu.Unlock()
+		return msg, err
+	})
+}
@@ -0,0 +1,242 @@
+package views_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/views"
+)
+
+// appStates is a fake app backend: lifecycle calls record themselves and
+// change the state that ListApps reports.
+type appStates struct {
+	mu    sync.Mutex
+	state map[string]string
+	calls []string
+	block chan struct{} // if set, every lifecycle call waits on it
+	err   error
+}
+
+func (s *appStates) service() *truenas.MockAppService {
+	act := func(call, name, state string) error {
+		if s.block != nil {
+			<-s.block
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.calls = append(s.calls, call+" "+name)
+		if s.err != nil {
+			return s.err
+		}
+		s.state[name] = state
+		return nil
+	}
+	return &truenas.MockAppService{
+		ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			apps := make([]truenas.App, 0, len(s.state))
+			for name, state := range s.state {
+				apps = append(apps, truenas.App{Name: name, State: state})
+			}
+			return apps, nil
+		},
+		StartAppFunc: func(ctx context.Context, name string) error {
+			return act("start", name, "RUNNING")
+		},
+		StopAppFunc: func(ctx context.Context, name string) error {
+			return act("stop", name, "STOPPED")
+		},
+		RedeployAppFunc: func(ctx context.Context, name string) error {
+			return act("redeploy", name, "RUNNING")
+		},
+	}
+}
+
+func (s *appStates) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func newDashboardWithApps(t *testing.T, s *appStates) (*views.DashboardView, chan vaxis.Event) {
+	t.Helper()
+	events := make(chan vaxis.Event, 10)
+	params := mockDashboardServices()
+	params.Apps = s.service()
+	params.PostEvent = func(ev vaxis.Event) { events <- ev }
+	dv := views.NewDashboardView(params)
+	if err := dv.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return dv, events
+}
+
+// selectApp moves the app list cursor to the named app.
+func selectApp(t *testing.T, dv *views.DashboardView, name string) {
+	t.Helper()
+	for range 10 {
+		if a := dv.SelectedApp(); a != nil && a.Name == name {
+			return
+		}
+		sendKey(t, dv, vaxis.Key{Keycode: 'j'})
+	}
+	t.Fatalf("app %q not in list", name)
+}
+
+func TestDashboardView_StartApp(t *testing.T) {
+	s := &appStates{state: map[string]string{"plex": "STOPPED", "sonarr": "RUNNING"}}
+	dv, events := newDashboardWithApps(t, s)
+	selectApp(t, dv, "plex")
+
+	sendKey(t, dv, vaxis.Key{Keycode: 's', Text: "s"})
+	ac := waitActionCompleted(t, events)
+	if ac.Err != nil {
+		t.Fatalf("unexpected error: %v", ac.Err)
+	}
+	if ac.Message != "Started plex" {
+		t.Errorf("expected message %q, got %q", "Started plex", ac.Message)
+	}
+	if got := s.Calls(); len(got) != 1 || got[0] != "start plex" {
+		t.Errorf("expected [start plex], got %v", got)
+	}
+	if got := dv.AppState("plex"); got != "RUNNING" {
+		t.Errorf("expected list refreshed to RUNNING, got %q", got)
+	}
+
+	// The rows are rebuilt on the UI goroutine once the event is handled.
+	dv.ActionDone(ac)
+	for _, line := range strings.Split(drawText(t, dv), "\n") {
+		if strings.Contains(line, "plex") && !strings.Contains(line, "RUNNING") {
+			t.Errorf("expected plex's row to show RUNNING, got %q", line)
+		}
+	}
+	if a := dv.SelectedApp(); a == nil || a.Name != "plex" {
+		t.Errorf("expected the cursor to stay on plex, got %v", a)
+	}
+}
+
+func TestDashboardView_StartApp_AlreadyRunning(t *testing.T) {
+	s := &appStates{state: map[string]string{"sonarr": "RUNNING"}}
+	dv, _ := newDashboardWithApps(t, s)
+	selectApp(t, dv, "sonarr")
+
+	sendKey(t, dv, vaxis.Key{Keycode: 's', Text: "s"})
+	if !strings.Contains(dv.Status(), "already running") {
+		t.Errorf("expected already running error, got %q", dv.Status())
+	}
+	if got := s.Calls(); len(got) != 0 {
+		t.Errorf("expected no calls, got %v", got)
+	}
+}
+
+func TestDashboardView_StopApp_Confirm(t *testing.T) {
+	s := &appStates{state: map[string]string{"sonarr": "RUNNING"}}
+	dv, events := newDashboardWithApps(t, s)
+	selectApp(t, dv, "sonarr")
+
+	stop := vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"}
+	sendKey(t, dv, stop)
+	if !dv.CapturingInput() {
+		t.Fatal("expected confirmation dialog")
+	}
+	if text := drawText(t, dv); !strings.Contains(text, "Stop sonarr?") {
+		t.Error("expected confirmation to name the app")
+	}
+	sendKey(t, dv, vaxis.Key{Keycode: 'n', Text: "n"})
+	if dv.CapturingInput() {
+		t.Fatal("expected dialog closed after n")
+	}
+	if got := s.Calls(); len(got) != 0 {
+		t.Fatalf("expected no calls after cancel, got %v", got)
+	}
+
+	sendKey(t, dv, stop)
+	sendKey(t, dv, vaxis.Key{Keycode: 'y', Text: "y"})
+	ac := waitActionCompleted(t, events)
+	if ac.Err != nil || ac.Message != "Stopped sonarr" {
+		t.Errorf("unexpected outcome: %q, %v", ac.Message, ac.Err)
+	}
+	if got := dv.AppState("sonarr"); got != "STOPPED" {
+		t.Errorf("expected STOPPED, got %q", got)
+	}
+}
+
+func TestDashboardView_StopApp_NotRunning(t *testing.T) {
+	s := &appStates{state: map[string]string{"plex": "STOPPED"}}
+	dv, _ := newDashboardWithApps(t, s)
+
+	sendKey(t, dv, vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"})
+	if dv.CapturingInput() {
+		t.Error("expected no dialog for a stopped app")
+	}
+	if !strings.Contains(dv.Status(), "not running") {
+		t.Errorf("expected not running error, got %q", dv.Status())
+	}
+}
+
+func TestDashboardView_RestartApp(t *testing.T) {
+	s := &appStates{state: map[string]string{"sonarr": "RUNNING"}}
+	dv, events := newDashboardWithApps(t, s)
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'r', ShiftedCode: 'R', Modifiers: vaxis.ModShift, Text: "R"})
+	ac := waitActionCompleted(t, events)
+	if ac.Err != nil || ac.Message != "Restarted sonarr" {
+		t.Errorf("unexpected outcome: %q, %v", ac.Message, ac.Err)
+	}
+	got := s.Calls()
+	if len(got) != 2 || got[0] != "stop sonarr" || got[1] != "start sonarr" {
+		t.Errorf("expected stop then start, got %v", got)
+	}
+}
+
+func TestDashboardView_RedeployApp_Error(t *testing.T) {
+	s := &appStates{state: map[string]string{"sonarr": "RUNNING"}, err: errors.New("image pull failed")}
+	dv, events := newDashboardWithApps(t, s)
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'd', ShiftedCode: 'D', Modifiers: vaxis.ModShift, Text: "D"})
+	ac := waitActionCompleted(t, events)
+	if ac.Err == nil || !strings.Contains(ac.Err.Error(), "redeploy sonarr: image pull failed") {
+		t.Fatalf("expected redeploy error, got %v", ac.Err)
+	}
+	dv.ActionDone(ac)
+	if !strings.HasPrefix(dv.Status(), "Error: redeploy sonarr") {
+		t.Errorf("expected error status, got %q", dv.Status())
+	}
+	if got := dv.AppState("sonarr"); got != "RUNNING" {
+		t.Errorf("expected transitional state cleared, got %q", got)
+	}
+}
+
+func TestDashboardView_AppJob_TransitionalState(t *testing.T) {
+	s := &appStates{state: map[string]string{"sonarr": "RUNNING"}, block: make(chan struct{})}
+	dv, events := newDashboardWithApps(t, s)
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'd', ShiftedCode: 'D', Modifiers: vaxis.ModShift, Text: "D"})
+	if got := dv.AppState("sonarr"); got != "DEPLOYING" {
+		t.Errorf("expected DEPLOYING while the job runs, got %q", got)
+	}
+	if text := drawText(t, dv); !strings.Contains(text, "DEPLOYING") || !strings.Contains(text, "Redeploying sonarr...") {
+		t.Error("expected row and status line to show the running job")
+	}
+
+	// A second action on the same app is refused while the job runs.
+	sendKey(t, dv, vaxis.Key{Keycode: 'r', ShiftedCode: 'R', Modifiers: vaxis.ModShift, Text: "R"})
+	if !strings.Contains(dv.Status(), "busy") {
+		t.Errorf("expected busy error, got %q", dv.Status())
+	}
+
+	close(s.block)
+	waitActionCompleted(t, events)
+	if got := dv.AppState("sonarr"); got != "RUNNING" {
+		t.Errorf("expected RUNNING after the job, got %q", got)
+	}
+	if got := s.Calls(); len(got) != 1 {
+		t.Errorf("expected only the redeploy call, got %v", got)
+	}
+}
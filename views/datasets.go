@@ -1,8 +1,10 @@
 package views
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
@@ -10,6 +12,8 @@ import (
 	"git.sr.ht/~rockorager/vaxis/vxfw/list"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
 	"github.com/dustin/go-humanize"
 )
 
@@ -19,14 +23,20 @@ type DatasetsViewParams struct {
 	StaleTTL time.Duration
 }
 
-// DatasetsView displays a list of TrueNAS datasets.
+// DatasetsView displays TrueNAS datasets as a collapsible pool/parent/child tree.
 type DatasetsView struct {
 	service  truenas.DatasetServiceAPI
 	datasets []truenas.Dataset
+	roots    []*datasetNode
+	rows     []datasetRow
+	expanded map[string]bool
+	query    listQuery
 	list     list.Dynamic
 	loaded   bool
 	loadedAt time.Time
 	staleTTL time.Duration
+
+	loadError
 }
 
 // NewDatasetsView creates a DatasetsView backed by the given params.
@@ -34,19 +44,38 @@ func NewDatasetsView(p DatasetsViewParams) *DatasetsView {
 	dv := &DatasetsView{
 		service:  p.Service,
 		staleTTL: p.StaleTTL,
+		expanded: make(map[string]bool),
+		query:    newListQuery("USED", "AVAIL"),
 	}
 	dv.list.DrawCursor = true
 	dv.list.Builder = dv.buildItem
+	clickableRows(&dv.list, dv.rowClicked)
 	return dv
 }
 
-// Load fetches datasets from the service.
+// Load fetches datasets from the service and rebuilds the tree, keeping the
+// expanded nodes and the cursor on the same dataset where it still exists.
 func (dv *DatasetsView) Load(ctx context.Context) error {
 	datasets, err := dv.service.ListDatasets(ctx)
 	if err != nil {
 		return err
 	}
+	selected := ""
+	if n := dv.selectedNode(); n != nil {
+		selected = n.id
+	}
+	firstLoad := !dv.loaded
+
 	dv.datasets = datasets
+	dv.roots = buildDatasetTree(datasets)
+	if firstLoad {
+		// Start with each pool opened one level.
+		for _, r := range dv.roots {
+			dv.expanded[r.id] = true
+		}
+	}
+	dv.rebuildRows()
+	dv.selectID(selected)
 	dv.loaded = true
 	dv.loadedAt = time.Now()
 	return nil
@@ -75,32 +104,196 @@ func (dv *DatasetsView) ItemCount() int {
 	return len(dv.datasets)
 }
 
+// RowCount returns the number of tree rows currently visible.
+func (dv *DatasetsView) RowCount() int {
+	return len(dv.rows)
+}
+
+// SelectedID returns the full ID of the node under the cursor, or "" if empty.
+func (dv *DatasetsView) SelectedID() string {
+	if n := dv.selectedNode(); n != nil {
+		return n.id
+	}
+	return ""
+}
+
+// Expand opens the node with the given ID.
+func (dv *DatasetsView) Expand(id string) {
+	dv.setExpanded(id, true)
+}
+
+// Collapse closes the node with the given ID.
+func (dv *DatasetsView) Collapse(id string) {
+	dv.setExpanded(id, false)
+}
+
+// ExpandAll opens every node in the tree.
+func (dv *DatasetsView) ExpandAll() {
+	selected := dv.SelectedID()
+	walkDatasetTree(dv.roots, func(n *datasetNode) {
+		if len(n.children) > 0 {
+			dv.expanded[n.id] = true
+		}
+	})
+	dv.rebuildRows()
+	dv.selectID(selected)
+}
+
+// CollapseAll closes every node, leaving only the pools visible. The cursor
+// moves to the pool containing the previous selection.
+func (dv *DatasetsView) CollapseAll() {
+	selected := dv.SelectedID()
+	clear(dv.expanded)
+	dv.rebuildRows()
+	if i := strings.Index(selected, "/"); i >= 0 {
+		selected = selected[:i]
+	}
+	dv.selectID(selected)
+}
+
+func (dv *DatasetsView) setExpanded(id string, open bool) {
+	selected := dv.SelectedID()
+	if open {
+		dv.expanded[id] = true
+	} else {
+		delete(dv.expanded, id)
+		// Keep the cursor visible if it was inside the collapsed subtree.
+		if strings.HasPrefix(selected, id+"/") {
+			selected = id
+		}
+	}
+	dv.rebuildRows()
+	dv.selectID(selected)
+}
+
+// rebuildRows flattens the tree using the expanded set and the current
+// filter and sort. A filter shows matching datasets with their ancestors,
+// regardless of what is expanded; sorting orders siblings within each parent.
+func (dv *DatasetsView) rebuildRows() {
+	var keep map[string]bool
+	if dv.query.filter != "" {
+		keep = filterDatasetTree(dv.roots, func(n *datasetNode) bool {
+			return dv.query.matches(n.id, n.name)
+		})
+	}
+	var less func(a, b *datasetNode) bool
+	if dv.query.sortCol >= 0 {
+		col := dv.query.columns[dv.query.sortCol]
+		less = func(a, b *datasetNode) bool {
+			var c int
+			switch col {
+			case "USED":
+				c = cmp.Compare(a.totalUsed, b.totalUsed)
+			case "AVAIL":
+				c = cmp.Compare(nodeAvailable(a), nodeAvailable(b))
+			}
+			return dv.query.ordered(c)
+		}
+	}
+	dv.rows = flattenDatasetTree(dv.roots, dv.expanded, keep, less)
+}
+
+// nodeAvailable returns the available space of a node, or 0 for synthesized
+// nodes.
+func nodeAvailable(n *datasetNode) int64 {
+	if n.dataset == nil {
+		return 0
+	}
+	return n.dataset.Available
+}
+
+func (dv *DatasetsView) selectedNode() *datasetNode {
+	idx := int(dv.list.Cursor())
+	if idx >= len(dv.rows) {
+		return nil
+	}
+	return dv.rows[idx].node
+}
+
+// selectID moves the cursor to the row for id, falling back to the nearest
+// visible ancestor, then clamping to the last row.
+func (dv *DatasetsView) selectID(id string) {
+	for id != "" {
+		for i, r := range dv.rows {
+			if r.node.id == id {
+				dv.list.SetCursor(uint(i))
+				return
+			}
+		}
+		i := strings.LastIndex(id, "/")
+		if i < 0 {
+			break
+		}
+		id = id[:i]
+	}
+	if n := len(dv.rows); n > 0 && int(dv.list.Cursor()) >= n {
+		dv.list.SetCursor(uint(n - 1))
+	}
+}
+
 func (dv *DatasetsView) buildItem(i uint, cursor uint) vxfw.Widget {
-	if int(i) >= len(dv.datasets) {
+	if int(i) >= len(dv.rows) {
 		return nil
 	}
-	d := dv.datasets[i]
+	row := dv.rows[i]
+	n := row.node
+
+	marker := "  "
+	if len(n.children) > 0 {
+		marker = "▸ "
+		if row.expanded {
+			marker = "▾ "
+		}
+	}
+	name := strings.Repeat("  ", n.depth) + marker + n.name
+	if len(n.children) > 0 && !row.expanded {
+		name += fmt.Sprintf(" (+%d)", len(n.children))
+	}
+
+	used := n.totalUsed
+	if row.expanded && n.dataset != nil {
+		used = n.dataset.Used
+	}
+
+	var compression, avail, mountpoint string
+	if d := n.dataset; d != nil {
+		compression = d.Compression
+		avail = humanize.IBytes(uint64(d.Available))
+		mountpoint = d.Mountpoint
+	}
+
+	nameStyle := vaxis.Style{}
+	if n.depth == 0 {
+		nameStyle.Attribute = vaxis.AttrBold
+	}
 
 	return richtext.New([]vaxis.Segment{
-		{Text: fmt.Sprintf("%-30s", d.ID)},
-		{Text: fmt.Sprintf("%-10s", d.Compression)},
-		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(d.Used)))},
-		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(d.Available)))},
-		{Text: fmt.Sprintf("  %s", d.Mountpoint)},
+		{Text: fmt.Sprintf("%-40s", name), Style: nameStyle},
+		{Text: fmt.Sprintf("%-10s", compression)},
+		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(used)))},
+		{Text: fmt.Sprintf("%10s", avail)},
+		{Text: fmt.Sprintf("  %s", mountpoint)},
 	})
 }
 
-// Draw renders the datasets list, or a loading state if data hasn't arrived.
+// Draw renders the datasets list, or a loading or error state if data
+// hasn't arrived.
 func (dv *DatasetsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	if !dv.loaded {
-		return drawLoadingState(ctx, dv)
+		return drawLoadingState(ctx, dv, &dv.loadError)
 	}
+	return drawErrorBanner(ctx, dv, &dv.loadError, dv.draw)
+}
 
+func (dv *DatasetsView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, dv)
 
+	q := &dv.query
 	header := richtext.New([]vaxis.Segment{
-		{Text: fmt.Sprintf("%-30s%-10s%10s%10s  %s", "NAME", "COMPRESS", "USED", "AVAIL", "MOUNTPOINT"),
+		{Text: fmt.Sprintf("%-40s%-10s%10s%10s  %s",
+			"NAME", "COMPRESS", q.column("USED"), q.column("AVAIL"), "MOUNTPOINT"),
 			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: q.summary(dv.matchCount(), len(dv.datasets)), Style: theme.Dim()},
 	})
 	headerSurf, err := header.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
 	if err != nil {
@@ -118,7 +311,113 @@ func (dv *DatasetsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	return s, nil
 }
 
-// HandleEvent delegates to the list widget for navigation.
+// matchCount returns how many datasets match the filter, not counting the
+// ancestors shown to keep them in context.
+func (dv *DatasetsView) matchCount() int {
+	if dv.query.filter == "" {
+		return len(dv.datasets)
+	}
+	var n int
+	for i := range dv.datasets {
+		ds := &dv.datasets[i]
+		name := ds.ID[strings.LastIndex(ds.ID, "/")+1:]
+		if dv.query.matches(ds.ID, name) {
+			n++
+		}
+	}
+	return n
+}
+
+// CapturingInput reports whether the filter prompt is open.
+func (dv *DatasetsView) CapturingInput() bool {
+	return dv.query.editing
+}
+
+// KeyScopes returns the bindings of the Datasets tab for the help.
+func (dv *DatasetsView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.DatasetsScope, keys.QueryScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the Datasets tab.
+func (dv *DatasetsView) Commands() []PaletteCommand {
+	n := dv.selectedNode()
+	redraw := func(fn func()) func() (vxfw.Command, error) {
+		return func() (vxfw.Command, error) {
+			fn()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+	}
+	return append([]PaletteCommand{
+		{Name: "Expand or collapse dataset", Key: keys.ToggleTree.Short(), Disabled: n == nil || len(n.children) == 0, Run: redraw(func() {
+			dv.setExpanded(n.id, !dv.expanded[n.id])
+		})},
+		{Name: "Expand all datasets", Key: keys.ExpandAll.Short(), Run: redraw(dv.ExpandAll)},
+		{Name: "Collapse all datasets", Key: keys.CollapseAll.Short(), Run: redraw(dv.CollapseAll)},
+	}, dv.query.commands(func() {
+		selected := dv.SelectedID()
+		dv.rebuildRows()
+		dv.selectID(selected)
+	})...)
+}
+
+// HandleEvent handles the filter and sort keys, tree expand/collapse keys and
+// delegates navigation to the list widget.
+//
+//	l / Right   expand, or move to the first child if already expanded
+//	h / Left    collapse, or move to the parent if already collapsed
+//	Enter       toggle
+//	L / H       expand all / collapse all
 func (dv *DatasetsView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
-	return dv.list.HandleEvent(ev, phase)
+	key, ok := ev.(vaxis.Key)
+	if !ok {
+		return handleListEvent(&dv.list, ev, phase)
+	}
+	if handled, changed := dv.query.handleKey(key); handled {
+		if changed {
+			selected := dv.SelectedID()
+			dv.rebuildRows()
+			dv.selectID(selected)
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	n := dv.selectedNode()
+	switch {
+	case keys.ExpandAll.Matches(key):
+		dv.ExpandAll()
+	case keys.CollapseAll.Matches(key):
+		dv.CollapseAll()
+	case n == nil:
+		return handleListEvent(&dv.list, ev, phase)
+	case keys.Expand.Matches(key):
+		if len(n.children) == 0 {
+			return nil, nil
+		}
+		if dv.expanded[n.id] {
+			dv.selectID(n.children[0].id)
+		} else {
+			dv.Expand(n.id)
+		}
+	case keys.Collapse.Matches(key):
+		if dv.expanded[n.id] {
+			dv.Collapse(n.id)
+		} else if n.parent != nil {
+			dv.selectID(n.parent.id)
+		}
+	case keys.ToggleTree.Matches(key):
+		if len(n.children) == 0 {
+			return nil, nil
+		}
+		dv.setExpanded(n.id, !dv.expanded[n.id])
+	default:
+		return handleListEvent(&dv.list, ev, phase)
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// rowClicked expands or collapses a dataset on a double click.
+func (dv *DatasetsView) rowClicked(double bool) (vxfw.Command, error) {
+	if n := dv.selectedNode(); double && n != nil && len(n.children) > 0 {
+		dv.setExpanded(n.id, !dv.expanded[n.id])
+	}
+	return vxfw.ConsumeAndRedraw(), nil
 }
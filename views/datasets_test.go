@@ -2,6 +2,7 @@ package views_test
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -187,3 +188,219 @@ func TestDatasetsView_HandleEvent(t *testing.T) {
 	}
 	_ = cmd
 }
+
+func nestedDatasets() []truenas.Dataset {
+	return []truenas.Dataset{
+		{ID: "tank", Name: "tank", Pool: "tank", Used: 300},
+		{ID: "tank/apps", Name: "apps", Pool: "tank", Used: 200},
+		{ID: "tank/apps/plex", Name: "plex", Pool: "tank", Used: 150},
+		{ID: "tank/apps/sonarr", Name: "sonarr", Pool: "tank", Used: 50},
+		{ID: "tank/data", Name: "data", Pool: "tank", Used: 100},
+		{ID: "backup/offsite/daily", Name: "daily", Pool: "backup", Used: 70},
+	}
+}
+
+func TestDatasetsView_Tree_InitialRows(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+
+	// Pools are expanded one level: backup, backup/offsite, tank, tank/apps, tank/data
+	if dv.RowCount() != 5 {
+		t.Fatalf("expected 5 visible rows, got %d", dv.RowCount())
+	}
+	if dv.SelectedID() != "backup" {
+		t.Errorf("expected cursor on backup, got %q", dv.SelectedID())
+	}
+}
+
+func TestDatasetsView_Tree_ExpandCollapse(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+
+	dv.Expand("tank/apps")
+	if dv.RowCount() != 7 {
+		t.Errorf("expected 7 rows after expanding tank/apps, got %d", dv.RowCount())
+	}
+
+	dv.Collapse("tank")
+	if dv.RowCount() != 3 {
+		t.Errorf("expected 3 rows after collapsing tank, got %d", dv.RowCount())
+	}
+
+	dv.ExpandAll()
+	if dv.RowCount() != 8 {
+		t.Errorf("expected 8 rows after expand all, got %d", dv.RowCount())
+	}
+
+	dv.CollapseAll()
+	if dv.RowCount() != 2 {
+		t.Errorf("expected 2 rows after collapse all, got %d", dv.RowCount())
+	}
+}
+
+func TestDatasetsView_Tree_Keys(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+
+	press := func(k vaxis.Key) {
+		t.Helper()
+		if _, err := dv.HandleEvent(k, vxfw.EventPhase(0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// backup is expanded; 'l' moves into its first child
+	press(vaxis.Key{Keycode: 'l'})
+	if dv.SelectedID() != "backup/offsite" {
+		t.Fatalf("expected cursor on backup/offsite, got %q", dv.SelectedID())
+	}
+
+	// 'h' on a collapsed child moves to the parent, then collapses it
+	press(vaxis.Key{Keycode: 'h'})
+	if dv.SelectedID() != "backup" {
+		t.Fatalf("expected cursor on backup, got %q", dv.SelectedID())
+	}
+	press(vaxis.Key{Keycode: 'h'})
+	if dv.RowCount() != 4 {
+		t.Errorf("expected 4 rows after collapsing backup, got %d", dv.RowCount())
+	}
+
+	// Enter toggles
+	press(vaxis.Key{Keycode: vaxis.KeyEnter})
+	if dv.RowCount() != 5 {
+		t.Errorf("expected 5 rows after toggling backup open, got %d", dv.RowCount())
+	}
+
+	press(vaxis.Key{Keycode: 'L'})
+	if dv.RowCount() != 8 {
+		t.Errorf("expected 8 rows after 'L', got %d", dv.RowCount())
+	}
+	press(vaxis.Key{Keycode: 'H'})
+	if dv.RowCount() != 2 {
+		t.Errorf("expected 2 rows after 'H', got %d", dv.RowCount())
+	}
+}
+
+func TestDatasetsView_Tree_CursorSurvivesReload(t *testing.T) {
+	datasets := nestedDatasets()
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return datasets, nil
+		},
+	}
+
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+	dv.Expand("tank/apps")
+
+	for dv.SelectedID() != "tank/apps/sonarr" {
+		if _, err := dv.HandleEvent(vaxis.Key{Keycode: 'j'}, vxfw.EventPhase(0)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// A new dataset sorted before the selection must not move the cursor off it.
+	datasets = append(datasets, truenas.Dataset{ID: "tank/apps/aaa", Name: "aaa", Pool: "tank"})
+	_ = dv.Load(context.Background())
+	if dv.SelectedID() != "tank/apps/sonarr" {
+		t.Errorf("expected cursor to stay on tank/apps/sonarr, got %q", dv.SelectedID())
+	}
+
+	// If the selected dataset disappears, the cursor falls back to its parent.
+	datasets = nestedDatasets()[:3]
+	_ = dv.Load(context.Background())
+	if dv.SelectedID() != "tank/apps" {
+		t.Errorf("expected cursor to fall back to tank/apps, got %q", dv.SelectedID())
+	}
+}
+
+func TestDatasetsView_Tree_DrawCollapsed(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+	dv.CollapseAll()
+
+	ctx := testDrawContext(100, 10)
+	if _, err := dv.Draw(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestDatasetsView_Filter_ShowsAncestors(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+
+	// plex sits under the collapsed tank/apps but is still found.
+	sendKey(t, dv, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, dv, "plex")
+	if dv.RowCount() != 3 {
+		t.Fatalf("expected tank, tank/apps, tank/apps/plex; got %d rows", dv.RowCount())
+	}
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !strings.Contains(drawText(t, dv), "/plex (1 of 6)") {
+		t.Error("expected header to show the filter and match count")
+	}
+
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if dv.RowCount() != 5 {
+		t.Errorf("expected the original tree after clearing, got %d rows", dv.RowCount())
+	}
+}
+
+func TestDatasetsView_Sort_Siblings(t *testing.T) {
+	mock := &truenas.MockDatasetService{
+		ListDatasetsFunc: func(ctx context.Context) ([]truenas.Dataset, error) {
+			return nestedDatasets(), nil
+		},
+	}
+	dv := newDatasetsView(mock)
+	_ = dv.Load(context.Background())
+	dv.ExpandAll()
+
+	sendKey(t, dv, vaxis.Key{Keycode: 's', Text: "s"}) // USED ascending
+	sendKey(t, dv, vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"})
+
+	var order []string
+	for i := 0; i < dv.RowCount(); i++ {
+		sendKey(t, dv, vaxis.Key{Keycode: 'k', Text: "k"})
+	}
+	for i := 0; i < dv.RowCount(); i++ {
+		order = append(order, dv.SelectedID())
+		sendKey(t, dv, vaxis.Key{Keycode: 'j', Text: "j"})
+	}
+	want := "tank,tank/apps,tank/apps/plex,tank/apps/sonarr,tank/data,backup,backup/offsite,backup/offsite/daily"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+	if !strings.Contains(drawText(t, dv), "USED▼") {
+		t.Error("expected header to mark the sort column")
+	}
+}
@@ -0,0 +1,141 @@
+package views
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/deevus/truenas-go"
+)
+
+// datasetNode is one entry in the dataset hierarchy. Nodes for intermediate
+// paths that were not returned by ListDatasets are synthesized with a nil
+// dataset so the tree stays connected.
+type datasetNode struct {
+	id       string
+	name     string
+	depth    int
+	dataset  *truenas.Dataset
+	parent   *datasetNode
+	children []*datasetNode
+	// totalUsed is the space shown when the node is collapsed. ZFS "used"
+	// already includes descendants, so for real datasets this is the larger of
+	// the node's own value and the sum of its children; synthesized nodes only
+	// have the sum.
+	totalUsed int64
+}
+
+// datasetRow is a visible line in the flattened tree.
+type datasetRow struct {
+	node     *datasetNode
+	expanded bool
+}
+
+// buildDatasetTree arranges datasets into a forest keyed by their slash-separated IDs.
+func buildDatasetTree(datasets []truenas.Dataset) []*datasetNode {
+	nodes := make(map[string]*datasetNode, len(datasets))
+	var roots []*datasetNode
+
+	var ensure func(id string) *datasetNode
+	ensure = func(id string) *datasetNode {
+		if n, ok := nodes[id]; ok {
+			return n
+		}
+		n := &datasetNode{id: id, name: id}
+		nodes[id] = n
+		if i := strings.LastIndex(id, "/"); i >= 0 {
+			n.name = id[i+1:]
+			n.parent = ensure(id[:i])
+			n.parent.children = append(n.parent.children, n)
+		} else {
+			roots = append(roots, n)
+		}
+		return n
+	}
+
+	for i := range datasets {
+		ensure(datasets[i].ID).dataset = &datasets[i]
+	}
+
+	var finish func(n *datasetNode, depth int) int64
+	finish = func(n *datasetNode, depth int) int64 {
+		n.depth = depth
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].name < n.children[j].name
+		})
+		var sum int64
+		for _, c := range n.children {
+			sum += finish(c, depth+1)
+		}
+		n.totalUsed = sum
+		if n.dataset != nil && n.dataset.Used > sum {
+			n.totalUsed = n.dataset.Used
+		}
+		return n.totalUsed
+	}
+
+	sort.Slice(roots, func(i, j int) bool { return roots[i].name < roots[j].name })
+	for _, r := range roots {
+		finish(r, 0)
+	}
+	return roots
+}
+
+// flattenDatasetTree returns the rows visible given the expanded set. When
+// keep is non-nil only the nodes in it are shown, and a node is drawn open
+// whenever it has kept children. Siblings are ordered by less, or left in
+// name order when less is nil.
+func flattenDatasetTree(roots []*datasetNode, expanded, keep map[string]bool, less func(a, b *datasetNode) bool) []datasetRow {
+	var rows []datasetRow
+	var walkLevel func(nodes []*datasetNode)
+	walk := func(n *datasetNode) {
+		if keep != nil && !keep[n.id] {
+			return
+		}
+		open := expanded[n.id]
+		if keep != nil {
+			open = false
+			for _, c := range n.children {
+				open = open || keep[c.id]
+			}
+		}
+		rows = append(rows, datasetRow{node: n, expanded: open})
+		if open {
+			walkLevel(n.children)
+		}
+	}
+	walkLevel = func(nodes []*datasetNode) {
+		if less != nil {
+			nodes = slices.Clone(nodes)
+			sort.SliceStable(nodes, func(i, j int) bool { return less(nodes[i], nodes[j]) })
+		}
+		for _, n := range nodes {
+			walk(n)
+		}
+	}
+	walkLevel(roots)
+	return rows
+}
+
+// filterDatasetTree returns the IDs of nodes for which match is true, plus
+// all of their ancestors so the matches stay reachable in the tree.
+func filterDatasetTree(roots []*datasetNode, match func(n *datasetNode) bool) map[string]bool {
+	keep := make(map[string]bool)
+	walkDatasetTree(roots, func(n *datasetNode) {
+		if !match(n) {
+			return
+		}
+		for p := n; p != nil && !keep[p.id]; p = p.parent {
+			keep[p.id] = true
+		}
+	})
+	return keep
+}
+
+// walkDatasetTree calls fn for every node in the forest.
+func walkDatasetTree(roots []*datasetNode, fn func(n *datasetNode)) {
+	for _, r := range roots {
+		fn(r)
+		walkDatasetTree(r.children, fn)
+	}
+}
@@ -0,0 +1,187 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
+	"github.com/dustin/go-humanize"
+)
+
+// Poll intervals for the disk detail pane: fast while a self-test runs so
+// its progress moves, slow otherwise.
+const (
+	diskDetailTestInterval = 5 * time.Second
+	diskDetailIdleInterval = 30 * time.Second
+)
+
+// Temperature thresholds in °C. Spinning disks run cooler than SSDs and
+// NVMe drives, which are rated for higher temperatures.
+const (
+	hddTempWarn     = 45
+	hddTempCritical = 55
+	ssdTempWarn     = 60
+	ssdTempCritical = 70
+)
+
+// diskDetail is the state of the detail pane opened from the disks list.
+type diskDetail struct {
+	disk       string
+	attributes []internal.SmartAttribute
+	tests      []internal.SmartTest
+	loaded     bool
+	err        error
+	cancel     context.CancelFunc
+}
+
+// pollDiskDetail fetches the disk's SMART data until ctx is cancelled,
+// posting each result to dv as a DiskDetailLoaded event.
+func pollDiskDetail(ctx context.Context, dv *DisksView, name string) {
+	svc := dv.service
+	for {
+		ev := DiskDetailLoaded{View: dv, Disk: name}
+		ev.Attributes, ev.Err = svc.SmartAttributes(ctx, name)
+		if ev.Err == nil {
+			var results map[string][]internal.SmartTest
+			results, ev.Err = svc.SmartTestResults(ctx, []string{name})
+			ev.Tests = results[name]
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if dv.postEvent != nil {
+			dv.postEvent(ev)
+		}
+
+		interval := diskDetailIdleInterval
+		if testRunning(ev.Tests) {
+			interval = diskDetailTestInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// testRunning reports whether the newest entry of a self-test log is still
+// in progress.
+func testRunning(tests []internal.SmartTest) bool {
+	return len(tests) > 0 && tests[0].Status == internal.SmartTestRunning
+}
+
+// tempStyle colors a disk temperature with the BarGauge thresholds.
+func tempStyle(diskType string, temp float64) vaxis.Style {
+	warn, critical := float64(hddTempWarn), float64(hddTempCritical)
+	if diskType != "HDD" {
+		warn, critical = ssdTempWarn, ssdTempCritical
+	}
+	return widgets.ThresholdStyle(temp, warn, critical)
+}
+
+// smartStatus summarizes a self-test log for the list: the outcome of the
+// newest test, or its progress while it runs.
+func smartStatus(tests []internal.SmartTest) (string, vaxis.Style) {
+	if len(tests) == 0 {
+		return "-", theme.Dim()
+	}
+	switch t := tests[0]; t.Status {
+	case internal.SmartTestRunning:
+		return fmt.Sprintf("testing %.0f%%", 100-t.Remaining), theme.Warning()
+	case internal.SmartTestSuccess:
+		return "PASSED", theme.OK()
+	case internal.SmartTestFailed:
+		return "FAILED", theme.Bold(theme.Critical())
+	default:
+		return strings.ToLower(t.Status), theme.Dim()
+	}
+}
+
+// diskDetailLines lays out the detail pane:
+//
+//	sda  WDC WD80EFAX-68KNBN0  serial VGH1234  7.3 TiB HDD  pool tank  38°C
+//	Short offline test in progress
+//	TEST [██████████░░░░░░░░░░░░░░░░░░░░]  40.0%
+//
+//	 ID  ATTRIBUTE                   VALUE  WORST  THRESH  RAW
+//	  5  Reallocated_Sector_Ct         100    100      10  0
+//
+//	  #  TEST              STATUS                          HOURS  FIRST ERROR LBA
+//	  1  Short offline     Completed without error         12000  -
+func diskDetailLines(d *internal.Disk, temp *float64, dd *diskDetail) []detailLine {
+	bold := vaxis.Style{Attribute: vaxis.AttrBold}
+	dim := theme.Dim()
+
+	header := []vaxis.Segment{
+		{Text: d.Name + "  ", Style: bold},
+		{Text: d.Model + "  "},
+		{Text: "serial " + d.Serial + "  ", Style: dim},
+		{Text: humanize.IBytes(uint64(d.Size)) + " " + d.Type + "  "},
+	}
+	if d.Pool != "" {
+		header = append(header, vaxis.Segment{Text: "pool " + d.Pool + "  "})
+	}
+	if temp != nil {
+		header = append(header, vaxis.Segment{Text: fmt.Sprintf("%.0f°C", *temp), Style: tempStyle(d.Type, *temp)})
+	}
+	lines := []detailLine{{segments: header}}
+
+	switch {
+	case dd.err != nil:
+		return append(lines, textLine("Error loading SMART data: "+dd.err.Error(), theme.Critical()))
+	case !dd.loaded:
+		return append(lines, textLine("Loading SMART data...", dim))
+	}
+
+	if testRunning(dd.tests) {
+		t := dd.tests[0]
+		lines = append(lines,
+			textLine(t.Description+" test in progress", theme.Warning()),
+			detailLine{gauge: &widgets.BarGauge{Label: "TEST", Value: 100 - t.Remaining, BarWidth: 30, Color: theme.Accent().Foreground}},
+		)
+	}
+
+	lines = append(lines, detailLine{})
+	if len(dd.attributes) == 0 {
+		lines = append(lines, textLine("No SMART attributes reported", dim))
+	} else {
+		lines = append(lines, textLine(fmt.Sprintf("%3s  %-26s%7s%7s%8s  %s", "ID", "ATTRIBUTE", "VALUE", "WORST", "THRESH", "RAW"), bold))
+		for _, a := range dd.attributes {
+			style := vaxis.Style{}
+			if a.Failing() {
+				style = theme.Bold(theme.Critical())
+			}
+			lines = append(lines, textLine(fmt.Sprintf("%3d  %-26s%7d%7d%8d  %s", a.ID, a.Name, a.Value, a.Worst, a.Threshold, a.Raw), style))
+		}
+	}
+
+	lines = append(lines, detailLine{})
+	if len(dd.tests) == 0 {
+		return append(lines, textLine("No self-tests have run on this disk", dim))
+	}
+	lines = append(lines, textLine(fmt.Sprintf("%3s  %-18s%-32s%7s  %s", "#", "TEST", "STATUS", "HOURS", "FIRST ERROR LBA"), bold))
+	for _, t := range dd.tests {
+		_, style := smartStatus([]internal.SmartTest{t})
+		if t.Status == internal.SmartTestSuccess {
+			style = vaxis.Style{}
+		}
+		lba := t.LBAOfError
+		if lba == "" {
+			lba = "-"
+		}
+		lines = append(lines, textLine(fmt.Sprintf("%3d  %-18s%-32s%7d  %s", t.Num, t.Description, t.StatusVerbose, t.Lifetime, lba), style))
+	}
+	return lines
+}
+
+// drawDiskDetail renders the detail pane into a surface of the given size.
+func drawDiskDetail(ctx vxfw.DrawContext, owner vxfw.Widget, d *internal.Disk, temp *float64, dd *diskDetail) (vxfw.Surface, error) {
+	return drawDetailLines(ctx, owner, diskDetailLines(d, temp, dd))
+}
@@ -0,0 +1,453 @@
+package views
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/list"
+	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
+	"github.com/dustin/go-humanize"
+)
+
+// DisksViewParams holds configuration for creating a DisksView.
+type DisksViewParams struct {
+	Service   internal.DiskServiceAPI
+	StaleTTL  time.Duration
+	PostEvent func(vaxis.Event)
+}
+
+// DisksView displays the physical disks with their temperature and SMART
+// health.
+type DisksView struct {
+	service   internal.DiskServiceAPI
+	postEvent func(vaxis.Event)
+	detail    *diskDetail // open detail pane, or nil
+	act       actions
+	disks     []internal.Disk
+	rows      []*internal.Disk // disks after filtering and sorting
+	temps     map[string]float64
+	tests     map[string][]internal.SmartTest
+	query     listQuery
+	list      list.Dynamic
+	loaded    bool
+	loadedAt  time.Time
+	staleTTL  time.Duration
+
+	loadError
+}
+
+// NewDisksView creates a DisksView backed by the given params.
+func NewDisksView(p DisksViewParams) *DisksView {
+	dv := &DisksView{
+		service:   p.Service,
+		postEvent: p.PostEvent,
+		staleTTL:  p.StaleTTL,
+		query:     newListQuery("NAME", "MODEL", "SIZE", "POOL", "TEMP"),
+		act:       actions{postEvent: p.PostEvent},
+	}
+	dv.list.DrawCursor = true
+	dv.list.Builder = dv.buildItem
+	clickableRows(&dv.list, dv.rowClicked)
+	return dv
+}
+
+// Load fetches disks from the service, along with their temperatures and
+// SMART self-test logs.
+func (dv *DisksView) Load(ctx context.Context) error {
+	disks, err := dv.service.ListDisks(ctx)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(disks))
+	for i, d := range disks {
+		names[i] = d.Name
+	}
+
+	// Temperatures and SMART status are extra; the list is still useful
+	// without them.
+	temps, err := dv.service.Temperatures(ctx, names)
+	if err != nil {
+		logging.Warnf("error loading disk temperatures: %v", err)
+	}
+	tests, err := dv.service.SmartTestResults(ctx, nil)
+	if err != nil {
+		logging.Warnf("error loading SMART test results: %v", err)
+	}
+
+	dv.disks = disks
+	dv.temps = temps
+	dv.tests = tests
+	dv.applyQuery()
+	dv.loaded = true
+	dv.loadedAt = time.Now()
+	return nil
+}
+
+// Loaded reports whether data has been successfully fetched.
+func (dv *DisksView) Loaded() bool {
+	return dv.loaded
+}
+
+// Stale reports whether the cached data is older than the configured TTL.
+func (dv *DisksView) Stale() bool {
+	if !dv.loaded {
+		return true
+	}
+	return time.Since(dv.loadedAt) > dv.staleTTL
+}
+
+// ItemCount returns the number of loaded disks.
+func (dv *DisksView) ItemCount() int {
+	return len(dv.disks)
+}
+
+// RowCount returns the number of disks shown after filtering.
+func (dv *DisksView) RowCount() int {
+	return len(dv.rows)
+}
+
+// SelectedDisk returns the currently selected disk, or nil if empty.
+func (dv *DisksView) SelectedDisk() *internal.Disk {
+	idx := int(dv.list.Cursor())
+	if idx >= len(dv.rows) {
+		return nil
+	}
+	return dv.rows[idx]
+}
+
+// temp returns the disk's temperature, or nil if it reports none.
+func (dv *DisksView) temp(name string) *float64 {
+	if t, ok := dv.temps[name]; ok {
+		return &t
+	}
+	return nil
+}
+
+// applyQuery rebuilds the visible rows from the loaded disks, keeping the
+// selected disk under the cursor when it is still shown.
+func (dv *DisksView) applyQuery() {
+	var selected string
+	if d := dv.SelectedDisk(); d != nil {
+		selected = d.Name
+	}
+
+	rows := make([]*internal.Disk, 0, len(dv.disks))
+	for i := range dv.disks {
+		d := &dv.disks[i]
+		if dv.query.matches(d.Name, d.Serial, d.Model, d.Pool) {
+			rows = append(rows, d)
+		}
+	}
+	if dv.query.sortCol >= 0 {
+		col := dv.query.columns[dv.query.sortCol]
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			var c int
+			switch col {
+			case "NAME":
+				c = strings.Compare(a.Name, b.Name)
+			case "MODEL":
+				c = strings.Compare(a.Model, b.Model)
+			case "SIZE":
+				c = cmp.Compare(a.Size, b.Size)
+			case "POOL":
+				c = strings.Compare(a.Pool, b.Pool)
+			case "TEMP":
+				c = cmp.Compare(dv.temps[a.Name], dv.temps[b.Name])
+			}
+			return dv.query.ordered(c)
+		})
+	}
+	dv.rows = rows
+
+	dv.list.SetCursor(0)
+	for i, d := range rows {
+		if d.Name == selected {
+			dv.list.SetCursor(uint(i))
+			break
+		}
+	}
+}
+
+func (dv *DisksView) buildItem(i uint, cursor uint) vxfw.Widget {
+	if int(i) >= len(dv.rows) {
+		return nil
+	}
+	d := dv.rows[i]
+
+	pool := d.Pool
+	if pool == "" {
+		pool = "-"
+	}
+	temp, tStyle := "-", theme.Dim()
+	if t := dv.temp(d.Name); t != nil {
+		temp, tStyle = fmt.Sprintf("%.0f°C", *t), tempStyle(d.Type, *t)
+	}
+	smart, sStyle := smartStatus(dv.tests[d.Name])
+
+	return richtext.New([]vaxis.Segment{
+		{Text: fmt.Sprintf("%-10s", d.Name)},
+		{Text: fmt.Sprintf("%-22s", truncate(d.Serial, 21))},
+		{Text: fmt.Sprintf("%-28s", truncate(d.Model, 27))},
+		{Text: fmt.Sprintf("%10s  ", humanize.IBytes(uint64(d.Size)))},
+		{Text: fmt.Sprintf("%-14s", truncate(pool, 13))},
+		{Text: fmt.Sprintf("%6s  ", temp), Style: tStyle},
+		{Text: smart, Style: sStyle},
+	})
+}
+
+// Draw renders the disks list and the detail pane when it is open, or a
+// loading or error state if data hasn't arrived.
+func (dv *DisksView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	if !dv.loaded {
+		return drawLoadingState(ctx, dv, &dv.loadError)
+	}
+	return drawErrorBanner(ctx, dv, &dv.loadError, dv.draw)
+}
+
+func (dv *DisksView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, dv)
+
+	q := &dv.query
+	header := richtext.New([]vaxis.Segment{
+		{Text: fmt.Sprintf("%-10s%-22s%-28s%10s  %-14s%6s  %s",
+			q.column("NAME"), "SERIAL", q.column("MODEL"), q.column("SIZE"), q.column("POOL"), q.column("TEMP"), "SMART"),
+			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: q.summary(len(dv.rows), len(dv.disks)), Style: theme.Dim()},
+	})
+	headerSurf, err := header.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 0, headerSurf)
+
+	// List, sharing the space with the detail pane when it is open
+	listHeight := ctx.Max.Height - 1 - min(dv.act.statusHeight(), ctx.Max.Height-1)
+	if dv.detail != nil {
+		listHeight = min(uint16(max(len(dv.rows), 1)), listHeight/3)
+	}
+	listSurf, err := dv.list.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: listHeight}))
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 1, listSurf)
+
+	if d := dv.SelectedDisk(); dv.detail != nil && d != nil && ctx.Max.Height > listHeight+2+dv.act.statusHeight() {
+		detailCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - listHeight - 2 - dv.act.statusHeight()})
+		detailSurf, err := drawDiskDetail(detailCtx, dv, d, dv.temp(d.Name), dv.detail)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, int(listHeight)+2, detailSurf)
+	}
+
+	if err := dv.act.draw(ctx, &s); err != nil {
+		return vxfw.Surface{}, err
+	}
+	return s, nil
+}
+
+// DetailOpen reports whether the detail pane is shown.
+func (dv *DisksView) DetailOpen() bool {
+	return dv.detail != nil
+}
+
+// OpenDetail shows the detail pane for the selected disk and starts polling
+// its SMART data.
+func (dv *DisksView) OpenDetail() {
+	d := dv.SelectedDisk()
+	if d == nil {
+		return
+	}
+	if dv.detail != nil && dv.detail.disk == d.Name {
+		return
+	}
+	dv.CloseDetail()
+	ctx, cancel := context.WithCancel(context.Background())
+	dv.detail = &diskDetail{disk: d.Name, cancel: cancel}
+	go pollDiskDetail(ctx, dv, d.Name)
+}
+
+// CloseDetail hides the detail pane and stops polling.
+func (dv *DisksView) CloseDetail() {
+	if dv.detail == nil {
+		return
+	}
+	dv.detail.cancel()
+	dv.detail = nil
+}
+
+// DetailLoaded applies a poll result to the detail pane. Results for a disk
+// that is no longer shown are dropped.
+func (dv *DisksView) DetailLoaded(ev DiskDetailLoaded) {
+	if dv.detail == nil || dv.detail.disk != ev.Disk {
+		return
+	}
+	dv.detail.loaded = true
+	dv.detail.err = ev.Err
+	if ev.Err == nil {
+		dv.detail.attributes = ev.Attributes
+		dv.detail.tests = ev.Tests
+	}
+}
+
+// followCursor moves an open detail pane to the selected disk.
+func (dv *DisksView) followCursor() {
+	if dv.detail == nil {
+		return
+	}
+	if d := dv.SelectedDisk(); d == nil {
+		dv.CloseDetail()
+	} else if d.Name != dv.detail.disk {
+		dv.OpenDetail()
+	}
+}
+
+// CapturingInput reports whether a dialog or the filter prompt is open.
+func (dv *DisksView) CapturingInput() bool {
+	return dv.act.modal != nil || dv.query.editing
+}
+
+// Status returns the status line text from the last action.
+func (dv *DisksView) Status() string {
+	return dv.act.status
+}
+
+// ActionDone records the outcome of a SMART test request and refreshes the
+// open detail pane so a started test shows up.
+func (dv *DisksView) ActionDone(ev ActionCompleted) {
+	dv.act.done(ev)
+	if dv.detail != nil {
+		dv.CloseDetail()
+		dv.OpenDetail()
+	}
+}
+
+// KeyScopes returns the bindings of the Disks tab for the help.
+func (dv *DisksView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.DisksScope, keys.QueryScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the Disks tab.
+func (dv *DisksView) Commands() []PaletteCommand {
+	d := dv.SelectedDisk()
+	busy := d == nil || testRunning(dv.tests[d.Name])
+	detail := "Open disk detail"
+	if dv.detail != nil {
+		detail = "Close disk detail"
+	}
+	return append([]PaletteCommand{
+		{Name: detail, Key: keys.ToggleDetail.Short(), Disabled: d == nil, Run: func() (vxfw.Command, error) {
+			if dv.detail != nil {
+				dv.CloseDetail()
+			} else {
+				dv.OpenDetail()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}},
+		{Name: "Start short SMART test", Key: keys.ShortTest.Short(), Disabled: busy, Run: func() (vxfw.Command, error) {
+			return dv.startTest(internal.SmartTestShort)
+		}},
+		{Name: "Start long SMART test", Key: keys.LongTest.Short(), Disabled: busy, Run: func() (vxfw.Command, error) {
+			return dv.startTest(internal.SmartTestLong)
+		}},
+	}, dv.query.commands(func() {
+		dv.applyQuery()
+		dv.followCursor()
+	})...)
+}
+
+// HandleEvent handles the detail pane, SMART test, filter and sort keys and
+// delegates navigation to the list widget. While the detail pane is open it
+// follows the cursor.
+//
+//	t   start a short SMART test
+//	T   start a long SMART test
+func (dv *DisksView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	if cmd, ok, err := dv.act.handleModal(ev, phase); ok {
+		return cmd, err
+	}
+	if key, ok := ev.(vaxis.Key); ok && dv.loaded {
+		switch {
+		case dv.query.editing:
+		case keys.LongTest.Matches(key):
+			return dv.startTest(internal.SmartTestLong)
+		case keys.ShortTest.Matches(key):
+			return dv.startTest(internal.SmartTestShort)
+		case keys.ToggleDetail.Matches(key):
+			if dv.detail != nil {
+				dv.CloseDetail()
+			} else {
+				dv.OpenDetail()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		case keys.CloseDetail.Matches(key) && dv.detail != nil:
+			dv.CloseDetail()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if handled, changed := dv.query.handleKey(key); handled {
+			if changed {
+				dv.applyQuery()
+				dv.followCursor()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+	}
+	cmd, err := handleListEvent(&dv.list, ev, phase)
+	dv.followCursor()
+	return cmd, err
+}
+
+// rowClicked moves an open detail pane to the clicked disk, or opens it on
+// a double click.
+func (dv *DisksView) rowClicked(double bool) (vxfw.Command, error) {
+	dv.followCursor()
+	if double {
+		dv.OpenDetail()
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// startTest confirms and starts a SMART self-test on the selected disk.
+func (dv *DisksView) startTest(kind internal.SmartTestType) (vxfw.Command, error) {
+	d := dv.SelectedDisk()
+	if d == nil {
+		return nil, nil
+	}
+	if testRunning(dv.tests[d.Name]) {
+		dv.act.setStatus("Error: a SMART test is already running on "+d.Name, true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	name, id := d.Name, d.Identifier
+	label, duration := "short", "a few minutes"
+	if kind == internal.SmartTestLong {
+		label, duration = "long", "several hours on large disks"
+	}
+	return dv.act.open(&widgets.Confirm{
+		Title: "SMART test",
+		Lines: []string{
+			fmt.Sprintf("Start a %s SMART test on %s?", label, name),
+			"The test runs on the disk in the background and takes " + duration + ".",
+		},
+		OnConfirm: func() (vxfw.Command, error) {
+			return dv.act.run(dv, fmt.Sprintf("Starting %s SMART test on %s...", label, name), func(ctx context.Context) (string, error) {
+				if err := dv.service.StartSmartTest(ctx, id, kind); err != nil {
+					return "", fmt.Errorf("start %s SMART test on %s: %w", label, name, err)
+				}
+				return fmt.Sprintf("Started %s SMART test on %s", label, name), nil
+			})
+		},
+		OnCancel: dv.act.close,
+	})
+}
@@ -0,0 +1,292 @@
+package views_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/views"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+func testDiskService() *internal.MockDiskService {
+	return &internal.MockDiskService{
+		ListDisksFunc: func(ctx context.Context) ([]internal.Disk, error) {
+			return []internal.Disk{
+				{Identifier: "{serial}A1", Name: "sda", Serial: "A1", Model: "WDC WD80EFAX", Size: 8 << 40, Type: "HDD", Pool: "tank"},
+				{Identifier: "{serial}B2", Name: "sdb", Serial: "B2", Model: "WDC WD80EFAX", Size: 8 << 40, Type: "HDD", Pool: "tank"},
+				{Identifier: "{serial}C3", Name: "nvme0n1", Serial: "C3", Model: "Samsung 980", Size: 1 << 40, Type: "SSD"},
+			}, nil
+		},
+		TemperaturesFunc: func(ctx context.Context, names []string) (map[string]float64, error) {
+			return map[string]float64{"sda": 38, "sdb": 57, "nvme0n1": 48}, nil
+		},
+		SmartTestResultsFunc: func(ctx context.Context, names []string) (map[string][]internal.SmartTest, error) {
+			all := map[string][]internal.SmartTest{
+				"sda": {{Num: 1, Description: "Short offline", Status: internal.SmartTestSuccess, StatusVerbose: "Completed without error", Lifetime: 12000}},
+				"sdb": {{Num: 1, Description: "Extended offline", Status: internal.SmartTestRunning, StatusVerbose: "Self-test routine in progress", Remaining: 60, Lifetime: 11000}},
+			}
+			if len(names) == 0 {
+				return all, nil
+			}
+			return map[string][]internal.SmartTest{names[0]: all[names[0]]}, nil
+		},
+		SmartAttributesFunc: func(ctx context.Context, name string) ([]internal.SmartAttribute, error) {
+			return []internal.SmartAttribute{
+				{ID: 5, Name: "Reallocated_Sector_Ct", Value: 100, Worst: 100, Threshold: 10, Raw: "0"},
+				{ID: 197, Name: "Current_Pending_Sector", Value: 1, Worst: 1, Threshold: 0, Raw: "24", WhenFailed: "now"},
+			}, nil
+		},
+	}
+}
+
+func newDisksView(mock *internal.MockDiskService) (*views.DisksView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 8)
+	dv := views.NewDisksView(views.DisksViewParams{
+		Service:   mock,
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	return dv, events
+}
+
+func TestDisksView_Load(t *testing.T) {
+	dv, _ := newDisksView(testDiskService())
+	if err := dv.Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !dv.Loaded() || dv.ItemCount() != 3 {
+		t.Fatalf("expected 3 disks, got %d", dv.ItemCount())
+	}
+	if dv.Stale() {
+		t.Error("expected fresh data not to be stale")
+	}
+
+	text := drawText(t, dv)
+	for _, want := range []string{"SERIAL", "SMART", "sda", "WDC WD80EFAX", "8.0 TiB", "tank", "38°C", "PASSED", "testing 40%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected list to contain %q", want)
+		}
+	}
+}
+
+func TestDisksView_Load_Error(t *testing.T) {
+	dv, _ := newDisksView(&internal.MockDiskService{
+		ListDisksFunc: func(ctx context.Context) ([]internal.Disk, error) { return nil, errors.New("boom") },
+	})
+	if err := dv.Load(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	if dv.Loaded() || !dv.Stale() {
+		t.Error("expected failed load to leave the view unloaded")
+	}
+}
+
+func TestDisksView_Load_ExtrasFail(t *testing.T) {
+	mock := testDiskService()
+	mock.TemperaturesFunc = func(ctx context.Context, names []string) (map[string]float64, error) {
+		return nil, errors.New("not supported")
+	}
+	mock.SmartTestResultsFunc = func(ctx context.Context, names []string) (map[string][]internal.SmartTest, error) {
+		return nil, errors.New("not supported")
+	}
+	dv, _ := newDisksView(mock)
+	if err := dv.Load(context.Background()); err != nil {
+		t.Fatalf("expected the list to load without temperatures and SMART, got %v", err)
+	}
+	if dv.RowCount() != 3 {
+		t.Errorf("expected 3 rows, got %d", dv.RowCount())
+	}
+}
+
+// cellColor returns the foreground color of the first cell of the surface
+// tree whose grapheme run starts with text.
+func cellColor(t *testing.T, w vxfw.Widget, text string) vaxis.Color {
+	t.Helper()
+	s, err := w.Draw(testDrawContext(120, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var find func(s vxfw.Surface) (vaxis.Color, bool)
+	find = func(s vxfw.Surface) (vaxis.Color, bool) {
+		for i := range s.Buffer {
+			var b strings.Builder
+			for j := i; j < len(s.Buffer) && b.Len() < len(text); j++ {
+				b.WriteString(s.Buffer[j].Grapheme)
+			}
+			if b.String() == text {
+				return s.Buffer[i].Style.Foreground, true
+			}
+		}
+		for _, c := range s.Children {
+			if color, ok := find(c.Surface); ok {
+				return color, true
+			}
+		}
+		return 0, false
+	}
+	color, ok := find(s)
+	if !ok {
+		t.Fatalf("%q not drawn", text)
+	}
+	return color
+}
+
+func TestDisksView_TemperatureColors(t *testing.T) {
+	dv, _ := newDisksView(testDiskService())
+	_ = dv.Load(context.Background())
+
+	// HDD thresholds put 38°C in the green and 57°C in the red; the same
+	// 48°C that would warn on an HDD is fine for an SSD.
+	for text, want := range map[string]vaxis.Color{
+		"38°C": widgets.ThresholdColor(0, 1, 2),
+		"57°C": widgets.ThresholdColor(2, 1, 2),
+		"48°C": widgets.ThresholdColor(0, 1, 2),
+	} {
+		if got := cellColor(t, dv, text); got != want {
+			t.Errorf("%s: got color %v, want %v", text, got, want)
+		}
+	}
+}
+
+func waitDiskDetail(t *testing.T, events chan vaxis.Event) views.DiskDetailLoaded {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if dd, ok := ev.(views.DiskDetailLoaded); ok {
+				return dd
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for DiskDetailLoaded")
+			return views.DiskDetailLoaded{}
+		}
+	}
+}
+
+func TestDisksView_Detail(t *testing.T) {
+	dv, events := newDisksView(testDiskService())
+	_ = dv.Load(context.Background())
+
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !dv.DetailOpen() {
+		t.Fatal("expected Enter to open the detail pane")
+	}
+	defer dv.CloseDetail()
+	if !strings.Contains(drawText(t, dv), "Loading SMART data...") {
+		t.Error("expected loading state before the first poll")
+	}
+
+	ev := waitDiskDetail(t, events)
+	if ev.Disk != "sda" {
+		t.Errorf("expected detail for sda, got %s", ev.Disk)
+	}
+	dv.DetailLoaded(ev)
+
+	text := drawText(t, dv)
+	for _, want := range []string{"serial A1", "Reallocated_Sector_Ct", "Current_Pending_Sector", "Short offline", "Completed without error", "12000"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected detail pane to contain %q", want)
+		}
+	}
+
+	// Following the cursor to the disk under test shows its progress.
+	sendKey(t, dv, vaxis.Key{Keycode: 'j', Text: "j"})
+	ev = waitDiskDetail(t, events)
+	dv.DetailLoaded(ev)
+	text = drawText(t, dv)
+	if !strings.Contains(text, "Extended offline test in progress") || !strings.Contains(text, "40.0%") {
+		t.Error("expected running test progress in the detail pane")
+	}
+
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if dv.DetailOpen() {
+		t.Error("expected Esc to close the detail pane")
+	}
+}
+
+func TestDisksView_Detail_Error(t *testing.T) {
+	mock := testDiskService()
+	mock.SmartAttributesFunc = func(ctx context.Context, name string) ([]internal.SmartAttribute, error) {
+		return nil, errors.New("smartctl failed")
+	}
+	dv, events := newDisksView(mock)
+	_ = dv.Load(context.Background())
+
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	defer dv.CloseDetail()
+	dv.DetailLoaded(waitDiskDetail(t, events))
+	if !strings.Contains(drawText(t, dv), "Error loading SMART data: smartctl failed") {
+		t.Error("expected error in the detail pane")
+	}
+}
+
+func TestDisksView_SmartTest(t *testing.T) {
+	mock := testDiskService()
+	var gotID string
+	var gotKind internal.SmartTestType
+	mock.StartSmartTestFunc = func(ctx context.Context, identifier string, kind internal.SmartTestType) error {
+		gotID, gotKind = identifier, kind
+		return nil
+	}
+	dv, events := newDisksView(mock)
+	_ = dv.Load(context.Background())
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'T', ShiftedCode: 'T', Modifiers: vaxis.ModShift, Text: "T"})
+	if !strings.Contains(drawText(t, dv), "Start a long SMART test on sda?") {
+		t.Fatal("expected confirmation dialog")
+	}
+	sendKey(t, dv, vaxis.Key{Keycode: 'y', Text: "y"})
+	dv.ActionDone(waitActionCompleted(t, events))
+	if gotID != "{serial}A1" || gotKind != internal.SmartTestLong {
+		t.Errorf("expected LONG test on {serial}A1, got %s on %s", gotKind, gotID)
+	}
+	if dv.Status() != "Started long SMART test on sda" {
+		t.Errorf("unexpected status %q", dv.Status())
+	}
+}
+
+func TestDisksView_SmartTest_AlreadyRunning(t *testing.T) {
+	dv, _ := newDisksView(testDiskService())
+	_ = dv.Load(context.Background())
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'j', Text: "j"}) // sdb is testing
+	sendKey(t, dv, vaxis.Key{Keycode: 't', Text: "t"})
+	if dv.CapturingInput() {
+		t.Error("expected no dialog while a test runs")
+	}
+	if !strings.Contains(dv.Status(), "already running on sdb") {
+		t.Errorf("unexpected status %q", dv.Status())
+	}
+}
+
+func TestDisksView_FilterAndSort(t *testing.T) {
+	dv, _ := newDisksView(testDiskService())
+	_ = dv.Load(context.Background())
+
+	sendKey(t, dv, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, dv, "samsung")
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if dv.RowCount() != 1 || dv.SelectedDisk().Name != "nvme0n1" {
+		t.Fatalf("expected only nvme0n1 to match, got %d rows", dv.RowCount())
+	}
+	sendKey(t, dv, vaxis.Key{Keycode: vaxis.KeyEsc})
+
+	s := vaxis.Key{Keycode: 's', Text: "s"}
+	for range 5 { // NAME, MODEL, SIZE, POOL, TEMP
+		sendKey(t, dv, s)
+	}
+	sendKey(t, dv, vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"})
+	for range dv.RowCount() {
+		sendKey(t, dv, vaxis.Key{Keycode: 'k', Text: "k"})
+	}
+	if got := dv.SelectedDisk().Name; got != "sdb" {
+		t.Errorf("expected the hottest disk first, got %s", got)
+	}
+}
@@ -0,0 +1,519 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/list"
+	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/dustin/go-humanize"
+	"golang.org/x/sync/errgroup"
+)
+
+// FleetViewParams holds configuration for creating a FleetView.
+type FleetViewParams struct {
+	Servers   []string
+	PostEvent func(vaxis.Event)
+	// OnSelect is called with the server name when a row is chosen.
+	OnSelect func(server string) (vxfw.Command, error)
+}
+
+// Fleet server states.
+const (
+	FleetConnecting = "CONNECTING"
+	FleetUp         = "UP"
+	FleetDown       = "DOWN"
+)
+
+// fleetServer is one row of the fleet overview. The App reports connection
+// state; the summary is fetched and the realtime stats streamed by the view.
+type fleetServer struct {
+	name     string
+	state    string
+	err      error // connection or summary error
+	svc      *internal.Services
+	ctx      context.Context // cancelled when the connection goes away
+	cancel   context.CancelFunc
+	info     *truenas.SystemInfo
+	version  string
+	pools    []truenas.Pool
+	alerts   int // active (not dismissed) alerts
+	realtime *truenas.RealtimeUpdate
+}
+
+// FleetView shows a one-row summary of every configured server: whether it
+// is reachable, its version and uptime, pool health and capacity, active
+// alerts and live CPU and memory usage.
+type FleetView struct {
+	mu        sync.Mutex
+	servers   []*fleetServer
+	list      list.Dynamic
+	postEvent func(vaxis.Event)
+	onSelect  func(server string) (vxfw.Command, error)
+
+	// RetryBaseDelay is the base delay for subscription retry backoff.
+	// Defaults to 1s; tests can set to a small value.
+	RetryBaseDelay time.Duration
+}
+
+// NewFleetView creates a FleetView with one row per server, in order.
+func NewFleetView(p FleetViewParams) *FleetView {
+	fv := &FleetView{
+		postEvent: p.PostEvent,
+		onSelect:  p.OnSelect,
+	}
+	for _, name := range p.Servers {
+		fv.servers = append(fv.servers, &fleetServer{name: name, state: FleetConnecting})
+	}
+	fv.list.DrawCursor = true
+	fv.list.Builder = fv.buildItem
+	clickableRows(&fv.list, fv.rowClicked)
+	return fv
+}
+
+// server returns the row for name, or nil. Callers must hold mu.
+func (fv *FleetView) server(name string) *fleetServer {
+	for _, s := range fv.servers {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Connecting marks the server as connecting.
+func (fv *FleetView) Connecting(name string) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	if s := fv.server(name); s != nil && s.state != FleetUp {
+		s.state = FleetConnecting
+		s.err = nil
+	}
+}
+
+// Connected marks the server as reachable and starts fetching its summary
+// and streaming its CPU and memory usage. It is a no-op if the server is
+// already up on the same services.
+func (fv *FleetView) Connected(name string, svc *internal.Services) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	s := fv.server(name)
+	if s == nil || (s.state == FleetUp && s.svc == svc) {
+		return
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.state = FleetUp
+	s.err = nil
+	s.svc = svc
+	s.ctx = ctx
+	s.cancel = cancel
+	go fv.load(ctx, s, svc)
+	go fv.runRealtimeSub(ctx, s, svc)
+}
+
+// Failed marks the server as unreachable.
+func (fv *FleetView) Failed(name string, err error) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	s := fv.server(name)
+	if s == nil {
+		return
+	}
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.state = FleetDown
+	s.err = err
+	s.svc = nil
+	s.realtime = nil
+}
+
+// Refresh fetches the summary of every reachable server again. The fetch
+// is tied to the server's connection, so Failed and Stop abort it.
+func (fv *FleetView) Refresh() {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	for _, s := range fv.servers {
+		if s.state == FleetUp && s.ctx.Err() == nil {
+			go fv.load(s.ctx, s, s.svc)
+		}
+	}
+}
+
+// Stop cancels every server's summary fetch and realtime subscription.
+func (fv *FleetView) Stop() {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	for _, s := range fv.servers {
+		if s.cancel != nil {
+			s.cancel()
+			s.cancel = nil
+		}
+	}
+}
+
+// State returns the connection state of the named server.
+func (fv *FleetView) State(name string) string {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	if s := fv.server(name); s != nil {
+		return s.state
+	}
+	return ""
+}
+
+// Selected returns the name of the server under the cursor.
+func (fv *FleetView) Selected() string {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	idx := int(fv.list.Cursor())
+	if idx >= len(fv.servers) {
+		return ""
+	}
+	return fv.servers[idx].name
+}
+
+// load fetches the server's system info, version, pools and alerts.
+func (fv *FleetView) load(ctx context.Context, s *fleetServer, svc *internal.Services) {
+	g, gctx := errgroup.WithContext(ctx)
+
+	var info *truenas.SystemInfo
+	var version string
+	var pools []truenas.Pool
+	var alerts []internal.Alert
+
+	g.Go(func() error {
+		i, err := svc.System.GetInfo(gctx)
+		if err != nil {
+			return fmt.Errorf("system.info: %w", err)
+		}
+		info = i
+		return nil
+	})
+	g.Go(func() error {
+		v, err := svc.System.GetVersion(gctx)
+		if err != nil {
+			return fmt.Errorf("system.version: %w", err)
+		}
+		version = v
+		return nil
+	})
+	g.Go(func() error {
+		p, err := svc.Datasets.ListPools(gctx)
+		if err != nil {
+			return fmt.Errorf("pool.query: %w", err)
+		}
+		pools = p
+		return nil
+	})
+	g.Go(func() error {
+		a, err := svc.Alerts.List(gctx)
+		if err != nil {
+			return fmt.Errorf("alert.list: %w", err)
+		}
+		alerts = a
+		return nil
+	})
+	err := g.Wait()
+
+	fv.mu.Lock()
+	if ctx.Err() != nil || s.svc != svc {
+		// Disconnected or reconnected while loading
+		fv.mu.Unlock()
+		return
+	}
+	if err != nil {
+		logging.Errorf("%s: fleet summary: %v", s.name, err)
+		s.err = err
+	} else {
+		s.err = nil
+		s.info = info
+		s.version = version
+		s.pools = pools
+		s.alerts = 0
+		for _, a := range alerts {
+			if !a.Dismissed {
+				s.alerts++
+			}
+		}
+	}
+	fv.mu.Unlock()
+	fv.redraw()
+}
+
+func (fv *FleetView) runRealtimeSub(ctx context.Context, s *fleetServer, svc *internal.Services) {
+	for attempt := 0; ; attempt++ {
+		sub, err := svc.Reporting.SubscribeRealtime(ctx)
+		if err != nil {
+			logging.Warnf("%s: realtime subscription failed: %v (attempt %d)", s.name, err, attempt+1)
+			if !retryBackoff(ctx, fv.RetryBaseDelay, attempt) {
+				return
+			}
+			continue
+		}
+		attempt = 0
+
+		for {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case update, ok := <-sub.C:
+				if !ok {
+					logging.Warnf("%s: realtime subscription closed, reconnecting...", s.name)
+					break
+				}
+				fv.mu.Lock()
+				if ctx.Err() != nil || s.svc != svc {
+					// Disconnected or reconnected since the update arrived
+					fv.mu.Unlock()
+					sub.Close()
+					return
+				}
+				s.realtime = &update
+				fv.mu.Unlock()
+				fv.redraw()
+				continue
+			}
+			break
+		}
+	}
+}
+
+func (fv *FleetView) redraw() {
+	if fv.postEvent != nil {
+		fv.postEvent(DashboardUpdated{})
+	}
+}
+
+// Fleet column layout: SERVER STATUS VERSION UPTIME POOLS CAPACITY ALERTS CPU MEM
+const fleetRowFormat = "%-14s%-8s%-10s%-9s%-16s%-24s%7s%6s%6s"
+
+// fleetVersion shortens a version string such as "TrueNAS-SCALE-24.04.2" to
+// the release number.
+func fleetVersion(v string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(v, "TrueNAS-"), "SCALE-")
+}
+
+// fleetPools summarises pool health: "ONLINE (2)" when every pool is
+// online, otherwise the first unhealthy status and how many pools share it.
+func fleetPools(pools []truenas.Pool) (string, bool) {
+	if len(pools) == 0 {
+		return "no pools", true
+	}
+	bad, status := 0, ""
+	for _, p := range pools {
+		if p.Status != "ONLINE" {
+			if status == "" {
+				status = p.Status
+			}
+			bad++
+		}
+	}
+	if bad == 0 {
+		return fmt.Sprintf("ONLINE (%d)", len(pools)), true
+	}
+	return fmt.Sprintf("%s (%d/%d)", status, bad, len(pools)), false
+}
+
+// fleetCapacity sums the used and total size of the server's pools.
+func fleetCapacity(pools []truenas.Pool) string {
+	var size, used int64
+	for _, p := range pools {
+		size += p.Size
+		used += p.Allocated
+	}
+	if size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s / %s (%.0f%%)",
+		humanize.IBytes(uint64(used)), humanize.IBytes(uint64(size)),
+		float64(used)/float64(size)*100)
+}
+
+// fleetUsage returns the average CPU and the memory usage percentages from
+// a realtime update, as cells.
+func fleetUsage(rt *truenas.RealtimeUpdate) (cpu, mem string) {
+	if rt == nil {
+		return "", ""
+	}
+	if len(rt.CPU) > 0 {
+		var total float64
+		for _, c := range rt.CPU {
+			total += c.Usage
+		}
+		cpu = fmt.Sprintf("%.1f%%", total/float64(len(rt.CPU)))
+	}
+	if rt.Memory.PhysicalTotal > 0 {
+		used := rt.Memory.PhysicalTotal - rt.Memory.PhysicalAvailable
+		mem = fmt.Sprintf("%.1f%%", float64(used)/float64(rt.Memory.PhysicalTotal)*100)
+	}
+	return cpu, mem
+}
+
+func (fv *FleetView) buildItem(i uint, cursor uint) vxfw.Widget {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	if int(i) >= len(fv.servers) {
+		return nil
+	}
+	s := fv.servers[i]
+
+	name := vaxis.Segment{Text: fmt.Sprintf("%-14s", truncate(s.name, 13)), Style: vaxis.Style{Attribute: vaxis.AttrBold}}
+	switch s.state {
+	case FleetConnecting:
+		return richtext.New([]vaxis.Segment{
+			name,
+			{Text: "connecting", Style: theme.Dim()},
+		})
+	case FleetDown:
+		msg := ""
+		if s.err != nil {
+			msg = s.err.Error()
+		}
+		return richtext.New([]vaxis.Segment{
+			name,
+			{Text: fmt.Sprintf("%-8s", "down"), Style: theme.Critical()},
+			{Text: msg, Style: theme.Dim()},
+		})
+	}
+
+	up := []vaxis.Segment{
+		name,
+		{Text: fmt.Sprintf("%-8s", "up"), Style: theme.OK()},
+	}
+	if s.info == nil {
+		msg := "loading..."
+		if s.err != nil {
+			msg = s.err.Error()
+		}
+		return richtext.New(append(up, vaxis.Segment{Text: msg, Style: theme.Dim()}))
+	}
+
+	uptime := ""
+	if s.info.UptimeSeconds > 0 {
+		uptime = FormatUptime(s.info.UptimeSeconds)
+	}
+	pools, healthy := fleetPools(s.pools)
+	poolStyle := theme.OK()
+	if !healthy {
+		poolStyle = theme.Critical()
+	}
+	alertStyle := theme.Dim()
+	if s.alerts > 0 {
+		alertStyle = theme.Warning()
+	}
+	cpu, mem := fleetUsage(s.realtime)
+
+	return richtext.New(append(up,
+		vaxis.Segment{Text: fmt.Sprintf("%-10s", truncate(fleetVersion(s.version), 9))},
+		vaxis.Segment{Text: fmt.Sprintf("%-9s", uptime)},
+		vaxis.Segment{Text: fmt.Sprintf("%-16s", truncate(pools, 15)), Style: poolStyle},
+		vaxis.Segment{Text: fmt.Sprintf("%-24s", fleetCapacity(s.pools))},
+		vaxis.Segment{Text: fmt.Sprintf("%7d", s.alerts), Style: alertStyle},
+		vaxis.Segment{Text: fmt.Sprintf("%6s", cpu)},
+		vaxis.Segment{Text: fmt.Sprintf("%6s", mem)},
+	))
+}
+
+// Draw renders a title row with the up/down counts, the column header and
+// one row per server.
+func (fv *FleetView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, fv)
+
+	fv.mu.Lock()
+	var up, down int
+	for _, srv := range fv.servers {
+		switch srv.state {
+		case FleetUp:
+			up++
+		case FleetDown:
+			down++
+		}
+	}
+	total := len(fv.servers)
+	fv.mu.Unlock()
+
+	counts := []string{fmt.Sprintf("%d servers", total), fmt.Sprintf("%d up", up)}
+	if down > 0 {
+		counts = append(counts, fmt.Sprintf("%d down", down))
+	}
+	title := richtext.New([]vaxis.Segment{
+		{Text: " FLEET  ", Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: strings.Join(counts, " · "), Style: theme.Dim()},
+	})
+	rowCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1})
+	titleSurf, err := title.Draw(rowCtx)
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 0, titleSurf)
+
+	header := richtext.New([]vaxis.Segment{
+		{Text: fmt.Sprintf(fleetRowFormat, "SERVER", "STATUS", "VERSION", "UPTIME", "POOLS", "CAPACITY", "ALERTS", "CPU", "MEM"),
+			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+	})
+	headerSurf, err := header.Draw(rowCtx)
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 1, headerSurf)
+
+	if ctx.Max.Height > 2 {
+		listSurf, err := fv.list.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - 2}))
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, 2, listSurf)
+	}
+	return s, nil
+}
+
+// KeyScopes returns the bindings of the fleet overview for the help.
+func (fv *FleetView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.FleetScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the fleet overview.
+func (fv *FleetView) Commands() []PaletteCommand {
+	name := fv.Selected()
+	return []PaletteCommand{
+		{Name: "Open server " + name, Key: keys.OpenServer.Short(), Disabled: name == "" || fv.onSelect == nil, Run: func() (vxfw.Command, error) {
+			return fv.onSelect(name)
+		}},
+	}
+}
+
+// HandleEvent opens the selected server on Enter and otherwise delegates
+// navigation keys to the list.
+func (fv *FleetView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	if key, ok := ev.(vaxis.Key); ok && keys.OpenServer.Matches(key) {
+		if name := fv.Selected(); name != "" && fv.onSelect != nil {
+			return fv.onSelect(name)
+		}
+		return nil, nil
+	}
+	return handleListEvent(&fv.list, ev, phase)
+}
+
+// rowClicked opens the clicked server on a double click.
+func (fv *FleetView) rowClicked(double bool) (vxfw.Command, error) {
+	if name := fv.Selected(); double && name != "" && fv.onSelect != nil {
+		return fv.onSelect(name)
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
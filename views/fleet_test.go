@@ -0,0 +1,233 @@
+package views_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/views"
+)
+
+// fleetServices returns services for one fleet row. Realtime updates sent
+// on rt are streamed to the view; done is closed when the subscription's
+// context is cancelled.
+func fleetServices(rt chan truenas.RealtimeUpdate, done chan struct{}) *internal.Services {
+	return internal.NewServices(
+		&truenas.MockDatasetService{
+			ListPoolsFunc: func(ctx context.Context) ([]truenas.Pool, error) {
+				return []truenas.Pool{
+					{ID: 1, Name: "tank", Status: "ONLINE", Size: 4 << 40, Allocated: 1 << 40},
+					{ID: 2, Name: "backup", Status: "DEGRADED", Size: 4 << 40, Allocated: 3 << 40},
+				}, nil
+			},
+		},
+		&internal.MockSnapshotService{},
+		&truenas.MockSystemService{
+			GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
+				return &truenas.SystemInfo{Hostname: "nas", UptimeSeconds: 90000}, nil
+			},
+			GetVersionFunc: func(ctx context.Context) (string, error) {
+				return "TrueNAS-25.04.0", nil
+			},
+		},
+		&truenas.MockReportingService{
+			SubscribeRealtimeFunc: func(ctx context.Context) (*truenas.Subscription[truenas.RealtimeUpdate], error) {
+				go func() {
+					<-ctx.Done()
+					if done != nil {
+						close(done)
+					}
+				}()
+				return truenas.NewSubscription((<-chan truenas.RealtimeUpdate)(rt), func() {}), nil
+			},
+		},
+		&truenas.MockInterfaceService{},
+		&truenas.MockAppService{},
+		&internal.MockPoolService{},
+		&internal.MockAlertService{
+			ListFunc: func(ctx context.Context) ([]internal.Alert, error) {
+				return []internal.Alert{
+					{ID: "a1", Level: internal.AlertLevelCritical},
+					{ID: "a2", Level: internal.AlertLevelWarning},
+					{ID: "a3", Level: internal.AlertLevelInfo, Dismissed: true},
+				}, nil
+			},
+		},
+		&internal.MockDiskService{},
+		&internal.MockConnService{},
+	)
+}
+
+func newFleetView(servers ...string) (*views.FleetView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 32)
+	fv := views.NewFleetView(views.FleetViewParams{
+		Servers:   servers,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	return fv, events
+}
+
+// waitForText redraws w until its text contains every string in want.
+func waitForText(t *testing.T, w vxfw.Widget, events chan vaxis.Event, want ...string) string {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		text := drawText(t, w)
+		missing := false
+		for _, s := range want {
+			if !strings.Contains(text, s) {
+				missing = true
+			}
+		}
+		if !missing {
+			return text
+		}
+		select {
+		case <-events:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %q in %q", want, text)
+		}
+	}
+}
+
+func TestFleetView_Connecting(t *testing.T) {
+	fv, _ := newFleetView("home", "office")
+	text := drawText(t, fv)
+	for _, want := range []string{"FLEET", "2 servers", "0 up", "home", "office", "connecting"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %q", want, text)
+		}
+	}
+	if fv.State("home") != views.FleetConnecting {
+		t.Errorf("expected home connecting, got %q", fv.State("home"))
+	}
+}
+
+func TestFleetView_Summary(t *testing.T) {
+	rt := make(chan truenas.RealtimeUpdate, 1)
+	fv, events := newFleetView("home")
+	fv.Connected("home", fleetServices(rt, nil))
+	defer fv.Stop()
+
+	rt <- truenas.RealtimeUpdate{
+		CPU:    map[string]truenas.RealtimeCPU{"0": {Usage: 40}, "1": {Usage: 60}},
+		Memory: truenas.RealtimeMemory{PhysicalTotal: 100, PhysicalAvailable: 75},
+	}
+
+	text := waitForText(t, fv, events, "25.04.0", "50.0%")
+	for _, want := range []string{
+		"1 up",
+		"1d 1h",             // uptime
+		"DEGRADED (1/2)",    // worst pool status
+		"4.0 TiB / 8.0 TiB", // capacity across pools
+		"25.0%",             // memory
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %q", want, text)
+		}
+	}
+	// Two active alerts; the dismissed one is not counted.
+	if _, after, _ := strings.Cut(text, "(50%)"); !strings.HasPrefix(strings.TrimSpace(after), "2 ") {
+		t.Errorf("expected 2 active alerts in %q", text)
+	}
+}
+
+func TestFleetView_FailedDoesNotBlockOthers(t *testing.T) {
+	rt := make(chan truenas.RealtimeUpdate)
+	fv, events := newFleetView("home", "office")
+	fv.Failed("office", errors.New("connection refused"))
+	fv.Connected("home", fleetServices(rt, nil))
+	defer fv.Stop()
+
+	text := waitForText(t, fv, events, "25.04.0")
+	for _, want := range []string{"down", "connection refused", "1 up", "1 down"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %q", want, text)
+		}
+	}
+	if fv.State("office") != views.FleetDown {
+		t.Errorf("expected office down, got %q", fv.State("office"))
+	}
+}
+
+func TestFleetView_FailedStopsStream(t *testing.T) {
+	rt := make(chan truenas.RealtimeUpdate)
+	done := make(chan struct{})
+	fv, events := newFleetView("home")
+	fv.Connected("home", fleetServices(rt, done))
+	waitForText(t, fv, events, "25.04.0")
+
+	fv.Failed("home", errors.New("connection lost"))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the realtime subscription to be cancelled")
+	}
+}
+
+func TestFleetView_Select(t *testing.T) {
+	var selected string
+	fv := views.NewFleetView(views.FleetViewParams{
+		Servers: []string{"home", "office"},
+		OnSelect: func(server string) (vxfw.Command, error) {
+			selected = server
+			return nil, nil
+		},
+	})
+
+	sendKey(t, fv, vaxis.Key{Keycode: 'j'})
+	if fv.Selected() != "office" {
+		t.Fatalf("expected office selected, got %q", fv.Selected())
+	}
+	sendKey(t, fv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if selected != "office" {
+		t.Errorf("expected OnSelect(office), got %q", selected)
+	}
+}
+
+func TestFleetView_StopAbortsRefresh(t *testing.T) {
+	svc := fleetServices(make(chan truenas.RealtimeUpdate), nil)
+	refreshing := make(chan struct{})
+	aborted := make(chan struct{})
+	var calls atomic.Int32
+	svc.System = &truenas.MockSystemService{
+		GetInfoFunc: func(ctx context.Context) (*truenas.SystemInfo, error) {
+			if calls.Add(1) == 1 {
+				return &truenas.SystemInfo{Hostname: "nas"}, nil
+			}
+			close(refreshing)
+			<-ctx.Done()
+			close(aborted)
+			return nil, ctx.Err()
+		},
+		GetVersionFunc: func(ctx context.Context) (string, error) {
+			return "TrueNAS-25.04.0", nil
+		},
+	}
+	fv, events := newFleetView("home")
+	fv.Connected("home", svc)
+	waitForText(t, fv, events, "25.04.0")
+
+	fv.Refresh()
+	<-refreshing
+	fv.Stop()
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Stop to cancel the refresh")
+	}
+
+	// A stopped view doesn't start new fetches.
+	fv.Refresh()
+	time.Sleep(50 * time.Millisecond)
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected no fetch after Stop, got %d GetInfo calls", n)
+	}
+}
@@ -0,0 +1,145 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// HelpViewParams holds configuration for creating a HelpView.
+type HelpViewParams struct {
+	// Scopes are listed in order, each under its name.
+	Scopes []*keys.Scope
+	// OnClose is called when the user closes the help.
+	OnClose func() (vxfw.Command, error)
+}
+
+// HelpView is an overlay listing keybindings, grouped by scope. It is built
+// from the keys registry the handlers match against, so it can't drift from
+// what the keys actually do.
+//
+//	j/k      scroll
+//	g/G      top / bottom
+//	?, Esc   close the help
+type HelpView struct {
+	onClose func() (vxfw.Command, error)
+	lines   []helpLine
+
+	top    int
+	height int // body height from the last draw
+}
+
+// helpLine is one row of the help: a scope heading, a binding or a blank
+// separator.
+type helpLine struct {
+	heading string
+	binding *keys.Binding
+}
+
+// NewHelpView creates a HelpView listing the given scopes.
+func NewHelpView(p HelpViewParams) *HelpView {
+	hv := &HelpView{onClose: p.OnClose}
+	for i, scope := range p.Scopes {
+		if i > 0 {
+			hv.lines = append(hv.lines, helpLine{})
+		}
+		hv.lines = append(hv.lines, helpLine{heading: scope.Name})
+		for _, b := range scope.Bindings {
+			hv.lines = append(hv.lines, helpLine{binding: b})
+		}
+	}
+	return hv
+}
+
+// HandleEvent scrolls and closes the help, and scrolls with the mouse wheel.
+// Everything else is swallowed so keys don't act on the view underneath.
+func (hv *HelpView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	last := max(len(hv.lines)-hv.height, 0)
+	page := max(hv.height-1, 1)
+	scroll := func(n int) {
+		hv.top = min(max(hv.top+n, 0), last)
+	}
+	if n, ok := wheelScroll(ev); ok {
+		scroll(n)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	switch {
+	case keys.CloseHelp.Matches(key):
+		if hv.onClose != nil {
+			return hv.onClose()
+		}
+	case keys.Down.Matches(key):
+		scroll(1)
+	case keys.Up.Matches(key):
+		scroll(-1)
+	case keys.PageDown.Matches(key):
+		scroll(page)
+	case keys.PageUp.Matches(key):
+		scroll(-page)
+	case keys.Top.Matches(key):
+		hv.top = 0
+	case keys.Bottom.Matches(key):
+		hv.top = last
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders a header rule, the visible lines and the key hints, filling
+// the whole area so nothing underneath shows through.
+func (hv *HelpView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, hv)
+	width := int(ctx.Max.Width)
+	bodyHeight := int(ctx.Max.Height) - 2
+	if bodyHeight < 1 {
+		return s, nil
+	}
+	hv.height = bodyHeight
+	hv.top = min(hv.top, max(len(hv.lines)-bodyHeight, 0))
+
+	bold := vaxis.Style{Attribute: vaxis.AttrBold}
+	dim := theme.Dim()
+	more := ""
+	if hv.top+bodyHeight < len(hv.lines) {
+		more = "  more below "
+	}
+	writeSegments(&s, 0, width, []vaxis.Segment{
+		{Text: "── ", Style: dim},
+		{Text: "HELP", Style: bold},
+		{Text: more + " ", Style: dim},
+		{Text: strings.Repeat("─", width), Style: dim},
+	})
+
+	// Pad labels to the widest one so the descriptions line up.
+	labelWidth := 0
+	for _, l := range hv.lines {
+		if l.binding != nil {
+			labelWidth = max(labelWidth, utf8.RuneCountInString(l.binding.Label()))
+		}
+	}
+	for i, l := range hv.lines[hv.top:min(hv.top+bodyHeight, len(hv.lines))] {
+		row := uint16(i + 1)
+		switch {
+		case l.heading != "":
+			writeCell(&s, 1, row, width-1, l.heading, bold, false)
+		case l.binding != nil:
+			writeSegments(&s, row, width, []vaxis.Segment{
+				{Text: fmt.Sprintf("   %-*s  ", labelWidth, l.binding.Label())},
+				{Text: l.binding.Help, Style: dim},
+			})
+		}
+	}
+
+	hints := fmt.Sprintf(" %s/%s scroll · %s/%s top/bottom · %s close",
+		keys.Down.Short(), keys.Up.Short(), keys.Top.Short(), keys.Bottom.Short(), keys.CloseHelp.Short())
+	writeCell(&s, 0, uint16(ctx.Max.Height-1), width, hints, dim, false)
+	return s, nil
+}
@@ -0,0 +1,82 @@
+package views_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/views"
+)
+
+func TestHelpView_Draw(t *testing.T) {
+	hv := views.NewHelpView(views.HelpViewParams{Scopes: []*keys.Scope{keys.PoolsScope, keys.ListScope}})
+
+	text := drawText(t, hv)
+	for _, want := range []string{"HELP", "Pools", "Lists", "x", "Start a scrub on the selected pool", "j / Down", "Move down"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in help, got:\n%s", want, text)
+		}
+	}
+	if strings.Index(text, "Pools") > strings.Index(text, "Lists") {
+		t.Error("expected scopes in the order given")
+	}
+}
+
+func TestHelpView_Scroll(t *testing.T) {
+	scopes := []*keys.Scope{keys.GlobalScope, keys.LogsScope}
+	hv := views.NewHelpView(views.HelpViewParams{Scopes: scopes})
+	draw := func() string { return drawTextSize(t, hv, 100, 10) }
+
+	if text := draw(); !strings.Contains(text, "Global") || strings.Contains(text, "Log pane") {
+		t.Fatalf("expected only the top of the help, got:\n%s", text)
+	}
+	sendKey(t, hv, vaxis.Key{Keycode: 'G', ShiftedCode: 'G', Modifiers: vaxis.ModShift, Text: "G"})
+	if text := draw(); strings.Contains(text, "Global") || !strings.Contains(text, "Close the log pane") {
+		t.Errorf("expected G to scroll to the bottom, got:\n%s", text)
+	}
+	sendKey(t, hv, vaxis.Key{Keycode: 'g', Text: "g"})
+	if text := draw(); !strings.Contains(text, "Global") {
+		t.Errorf("expected g to scroll to the top, got:\n%s", text)
+	}
+}
+
+func TestHelpView_Close(t *testing.T) {
+	closed := false
+	hv := views.NewHelpView(views.HelpViewParams{
+		Scopes: []*keys.Scope{keys.GlobalScope},
+		OnClose: func() (vxfw.Command, error) {
+			closed = true
+			return nil, nil
+		},
+	})
+	sendKey(t, hv, vaxis.Key{Keycode: 'x', Text: "x"})
+	if closed {
+		t.Fatal("expected other keys not to close the help")
+	}
+	sendKey(t, hv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if !closed {
+		t.Error("expected Esc to close the help")
+	}
+}
+
+func TestHelpView_Wheel(t *testing.T) {
+	hv := views.NewHelpView(views.HelpViewParams{Scopes: []*keys.Scope{keys.GlobalScope}})
+	draw := func() string { return drawTextSize(t, hv, 100, 10) }
+	draw()
+
+	sendKey(t, hv, vaxis.Key{Keycode: 'g', Text: "g"})
+	if _, err := hv.HandleEvent(vaxis.Mouse{Button: vaxis.MouseWheelDown}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := draw(); strings.Contains(text, "Global") {
+		t.Errorf("expected the wheel to scroll down, got:\n%s", text)
+	}
+	if _, err := hv.HandleEvent(vaxis.Mouse{Button: vaxis.MouseWheelUp}, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if text := draw(); !strings.Contains(text, "Global") {
+		t.Errorf("expected the wheel to scroll back up, got:\n%s", text)
+	}
+}
@@ -1,6 +1,9 @@
 package views_test
 
 import (
+	"strings"
+	"testing"
+
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 )
@@ -18,3 +21,84 @@ func testDrawContext(w, h uint16) vxfw.DrawContext {
 		},
 	}
 }
+
+func sendKey(t *testing.T, h vxfw.EventHandler, key vaxis.Key) {
+	t.Helper()
+	if _, err := h.HandleEvent(key, vxfw.EventPhase(0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func typeString(t *testing.T, h vxfw.EventHandler, text string) {
+	t.Helper()
+	for _, r := range text {
+		sendKey(t, h, vaxis.Key{Keycode: r, Text: string(r)})
+	}
+}
+
+// drawText draws w and returns the graphemes of the surface and its children
+// concatenated, for substring checks.
+func drawText(t *testing.T, w vxfw.Widget) string {
+	t.Helper()
+	return drawTextSize(t, w, 100, 30)
+}
+
+func drawTextSize(t *testing.T, w vxfw.Widget, width, height uint16) string {
+	t.Helper()
+	s, err := w.Draw(testDrawContext(width, height))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b strings.Builder
+	var collect func(s vxfw.Surface)
+	collect = func(s vxfw.Surface) {
+		for _, c := range s.Buffer {
+			b.WriteString(c.Grapheme)
+		}
+		for _, child := range s.Children {
+			collect(child.Surface)
+		}
+	}
+	collect(s)
+	return b.String()
+}
+
+// sendMouse draws w and delivers a mouse event the way vxfw does: to each
+// widget under the pointer, innermost first, until one handles it.
+func sendMouse(t *testing.T, w vxfw.Widget, m vaxis.Mouse) {
+	t.Helper()
+	s, err := w.Draw(testDrawContext(100, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var hits []vxfw.Widget
+	var hitTest func(s vxfw.Surface, col, row int)
+	hitTest = func(s vxfw.Surface, col, row int) {
+		hits = append(hits, s.Widget)
+		for _, child := range s.Children {
+			c, r := col-child.Origin.Col, row-child.Origin.Row
+			if c >= 0 && r >= 0 && c < int(child.Surface.Size.Width) && r < int(child.Surface.Size.Height) {
+				hitTest(child.Surface, c, r)
+			}
+		}
+	}
+	hitTest(s, m.Col, m.Row)
+	for i := len(hits) - 1; i >= 0; i-- {
+		h, ok := hits[i].(vxfw.EventHandler)
+		if !ok {
+			continue
+		}
+		cmd, err := h.HandleEvent(m, vxfw.BubblePhase)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cmd != nil {
+			return
+		}
+	}
+}
+
+func click(t *testing.T, w vxfw.Widget, col, row int) {
+	t.Helper()
+	sendMouse(t, w, vaxis.Mouse{Button: vaxis.MouseLeftButton, Col: col, Row: row})
+}
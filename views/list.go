@@ -0,0 +1,115 @@
+package views
+
+import (
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/list"
+	"github.com/deevus/truenas-tui/keys"
+)
+
+// doubleClickInterval is how soon a second click on the same row must follow
+// the first to count as a double click.
+const doubleClickInterval = 400 * time.Millisecond
+
+// wheelLines is how far one notch of the mouse wheel scrolls, matching
+// list.Dynamic.
+const wheelLines = 3
+
+// handleListEvent forwards navigation to a list.Dynamic. The list only acts on
+// keys from CaptureEvent, which it never receives because the App stays
+// focused, so key events are matched against the registry and the list
+// moved explicitly.
+func handleListEvent(l *list.Dynamic, ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	if key, ok := ev.(vaxis.Key); ok {
+		var cmd vxfw.Command
+		switch {
+		case keys.Down.Matches(key):
+			cmd = l.NextItem()
+		case keys.Up.Matches(key):
+			cmd = l.PrevItem()
+		}
+		if cmd == nil {
+			return nil, nil
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	return l.HandleEvent(ev, phase)
+}
+
+// wheelScroll returns how many lines a mouse wheel event scrolls by,
+// negative for up, for panes that scroll themselves.
+func wheelScroll(ev vaxis.Event) (int, bool) {
+	m, ok := ev.(vaxis.Mouse)
+	if !ok {
+		return 0, false
+	}
+	switch m.Button {
+	case vaxis.MouseWheelDown:
+		return wheelLines, true
+	case vaxis.MouseWheelUp:
+		return -wheelLines, true
+	}
+	return 0, false
+}
+
+// clickableRows makes the rows of a list clickable. A click moves the cursor
+// to the row and calls click, if set, with double true when it's the second
+// click on the row within doubleClickInterval. It wraps the list's Builder,
+// so it must be called after the Builder is set.
+func clickableRows(l *list.Dynamic, click func(double bool) (vxfw.Command, error)) {
+	build := l.Builder
+	var last time.Time
+	var lastIndex uint
+	onClick := func(i uint) (vxfw.Command, error) {
+		now := time.Now()
+		double := i == lastIndex && now.Sub(last) < doubleClickInterval
+		last, lastIndex = now, i
+		if double {
+			// A third click starts over rather than counting as another
+			// double click.
+			last = time.Time{}
+		}
+		l.SetCursor(i)
+		if click != nil {
+			return click(double)
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	l.Builder = func(i uint, cursor uint) vxfw.Widget {
+		w := build(i, cursor)
+		if w == nil {
+			return nil
+		}
+		return &listRow{Widget: w, index: i, click: onClick}
+	}
+}
+
+// listRow is a list item that reports clicks anywhere on it.
+type listRow struct {
+	vxfw.Widget
+	index uint
+	click func(i uint) (vxfw.Command, error)
+}
+
+// HandleEvent reports a left button press on the row.
+func (r *listRow) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	m, ok := ev.(vaxis.Mouse)
+	if !ok || m.Button != vaxis.MouseLeftButton || m.EventType != vaxis.EventPress {
+		return nil, nil
+	}
+	return r.click(r.index)
+}
+
+// Draw draws the item inside a surface owned by the row, so mouse events
+// anywhere on the item reach HandleEvent.
+func (r *listRow) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	inner, err := r.Widget.Draw(ctx)
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s := vxfw.NewSurface(inner.Size.Width, inner.Size.Height, r)
+	s.AddChild(0, 0, inner)
+	return s, nil
+}
@@ -1,21 +1,81 @@
 package views
 
 import (
+	"fmt"
+	"time"
+
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
 )
 
-// drawLoadingState renders a "Loading..." message in the view.
-func drawLoadingState(ctx vxfw.DrawContext, owner vxfw.Widget) (vxfw.Surface, error) {
+// loadError remembers why a view's last load failed. Tab views embed it and
+// the App sets it from ViewLoaded, so it is only touched on the UI thread.
+type loadError struct {
+	err error
+	at  time.Time
+}
+
+// SetLoadError records the outcome of the view's last load. nil clears the
+// error after a successful load.
+func (l *loadError) SetLoadError(err error) {
+	l.err = err
+	l.at = time.Now()
+}
+
+// LoadError returns the error from the view's last load, or nil if it
+// succeeded.
+func (l *loadError) LoadError() error {
+	return l.err
+}
+
+// drawLoadingState renders a "Loading..." message in the view, or the load
+// error if the first load failed.
+func drawLoadingState(ctx vxfw.DrawContext, owner vxfw.Widget, le *loadError) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, owner)
+	lines := [][]vaxis.Segment{{{Text: "Loading...", Style: theme.Dim()}}}
+	if le.err != nil {
+		lines = [][]vaxis.Segment{
+			{{Text: "Failed to load: " + le.err.Error(), Style: theme.Bold(theme.Critical())}},
+			{{Text: fmt.Sprintf("at %s · press %s to retry", le.at.Format("15:04:05"), keys.Refresh.Short()), Style: theme.Dim()}},
+		}
+	}
+	for i, segs := range lines {
+		if i >= int(ctx.Max.Height) {
+			break
+		}
+		labelSurf, err := richtext.New(segs).Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, i, labelSurf)
+	}
+	return s, nil
+}
+
+// drawErrorBanner draws a loaded view with draw, under a one-row banner if
+// its last reload failed. The data from the previous load stays visible.
+func drawErrorBanner(ctx vxfw.DrawContext, owner vxfw.Widget, le *loadError, draw func(vxfw.DrawContext) (vxfw.Surface, error)) (vxfw.Surface, error) {
+	if le.err == nil || ctx.Max.Height < 2 {
+		return draw(ctx)
+	}
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, owner)
-	label := richtext.New([]vaxis.Segment{
-		{Text: "Loading...", Style: vaxis.Style{Attribute: vaxis.AttrDim}},
-	})
-	labelSurf, err := label.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+	banner := richtext.New([]vaxis.Segment{{
+		Text:  fmt.Sprintf(" Refresh failed at %s: %v (%s to retry)", le.at.Format("15:04:05"), le.err, keys.Refresh.Short()),
+		Style: theme.Critical(),
+	}})
+	bannerSurf, err := banner.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
+	if err != nil {
+		return vxfw.Surface{}, err
+	}
+	s.AddChild(0, 0, bannerSurf)
+
+	viewSurf, err := draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - 1}))
 	if err != nil {
 		return vxfw.Surface{}, err
 	}
-	s.AddChild(0, 0, labelSurf)
+	s.AddChild(0, 1, viewSurf)
 	return s, nil
 }
@@ -0,0 +1,630 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+// Log pane limits: how many lines the server sends when a stream starts, and
+// how many the pane keeps before dropping the oldest.
+const (
+	logTailLines = 500
+	logMaxLines  = 10000
+)
+
+// ansiEscape matches terminal escape sequences, which container logs often
+// contain for colour and must not reach our own terminal.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b[@-_]`)
+
+// LogViewParams holds configuration for creating a LogView.
+type LogViewParams struct {
+	Service   truenas.AppServiceAPI
+	App       truenas.App
+	PostEvent func(vaxis.Event)
+	// OnClose is called when the user closes the pane. The pane has already
+	// stopped its stream by then.
+	OnClose func() (vxfw.Command, error)
+}
+
+// logLine is one line of the pane. Markers are status lines inserted by the
+// pane itself, e.g. when the stream ends.
+type logLine struct {
+	time   string
+	text   string
+	marker bool
+}
+
+// LogView is a full-screen pane that streams an app's container logs.
+//
+//	f        toggle follow (scrolling up turns it off, G turns it back on)
+//	p        pause / resume (lines arriving while paused are held back)
+//	/        search; n / N jump to the next / previous match
+//	c        choose another container
+//	r        reconnect after the stream ended
+//	Esc, q   close the pane
+type LogView struct {
+	service    truenas.AppServiceAPI
+	app        truenas.App
+	containers []truenas.AppContainerDetails
+	container  int // index into containers, -1 until one is chosen
+	postEvent  func(vaxis.Event)
+	onClose    func() (vxfw.Command, error)
+	picker     *widgets.Picker
+
+	// Stream state (protected by mu)
+	mu            sync.Mutex
+	lines         []logLine
+	held          []logLine // arrived while paused
+	dropped       int       // lines dropped from the front of lines
+	paused        bool
+	redrawPending bool
+
+	// Stream
+	sub    *truenas.Subscription[truenas.AppContainerLogEntry]
+	cancel context.CancelFunc
+
+	// UI
+	top       int // absolute index (counting dropped lines) of the first visible line
+	height    int // body height from the last draw
+	follow    bool
+	search    string
+	searching bool
+	match     int // absolute index of the current match, -1 for none
+}
+
+// NewLogView creates a LogView for the app. Call Start to begin streaming.
+func NewLogView(p LogViewParams) *LogView {
+	return &LogView{
+		service:    p.Service,
+		app:        p.App,
+		containers: p.App.ActiveWorkloads.ContainerDetails,
+		container:  -1,
+		postEvent:  p.PostEvent,
+		onClose:    p.OnClose,
+		follow:     true,
+		match:      -1,
+	}
+}
+
+// Start streams the app's only container, or asks which one to stream when
+// the app has several.
+func (lv *LogView) Start() {
+	switch len(lv.containers) {
+	case 0:
+		lv.appendMarker(context.Background(), "No containers found for "+lv.app.Name)
+	case 1:
+		lv.stream(0)
+	default:
+		lv.openPicker()
+	}
+}
+
+// Stop cancels the log stream. It is safe to call more than once.
+func (lv *LogView) Stop() {
+	if lv.cancel != nil {
+		lv.cancel()
+		lv.cancel = nil
+	}
+	lv.mu.Lock()
+	sub := lv.sub
+	lv.sub = nil
+	lv.mu.Unlock()
+	if sub != nil {
+		sub.Close()
+	}
+}
+
+// Streaming reports whether a log stream is running.
+func (lv *LogView) Streaming() bool {
+	return lv.cancel != nil
+}
+
+// Container returns the service name of the container being streamed, or ""
+// if none has been chosen.
+func (lv *LogView) Container() string {
+	if lv.container < 0 {
+		return ""
+	}
+	return containerName(lv.containers[lv.container])
+}
+
+// Following reports whether the pane scrolls to new lines as they arrive.
+func (lv *LogView) Following() bool {
+	return lv.follow
+}
+
+// Paused reports whether new lines are being held back.
+func (lv *LogView) Paused() bool {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	return lv.paused
+}
+
+// Lines returns the messages currently shown in the pane, oldest first.
+func (lv *LogView) Lines() []string {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	out := make([]string, len(lv.lines))
+	for i, l := range lv.lines {
+		out[i] = l.text
+	}
+	return out
+}
+
+// Top returns the index into Lines of the first visible line.
+func (lv *LogView) Top() int {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	return max(lv.top-lv.dropped, 0)
+}
+
+func containerName(c truenas.AppContainerDetails) string {
+	if c.ServiceName != "" {
+		return c.ServiceName
+	}
+	return c.ID
+}
+
+// stream switches the pane to the i-th container, starting a fresh stream
+// with the last logTailLines lines.
+func (lv *LogView) stream(i int) {
+	lv.Stop()
+	lv.container = i
+	lv.mu.Lock()
+	lv.lines, lv.held, lv.dropped = nil, nil, 0
+	lv.mu.Unlock()
+	lv.top, lv.follow, lv.match = 0, true, -1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lv.cancel = cancel
+	opts := truenas.ContainerLogOpts{
+		AppName:     lv.app.Name,
+		ContainerID: lv.containers[i].ID,
+		TailLines:   logTailLines,
+	}
+	go lv.run(ctx, opts)
+}
+
+// run reads the stream until it ends or ctx is cancelled.
+func (lv *LogView) run(ctx context.Context, opts truenas.ContainerLogOpts) {
+	sub, err := lv.service.SubscribeContainerLogs(ctx, opts)
+	if err != nil {
+		if ctx.Err() == nil {
+			lv.appendMarker(ctx, fmt.Sprintf("Error streaming logs: %v (%s to retry)", err, keys.LogReconnect.Short()))
+		}
+		return
+	}
+	// Stop cancels ctx before it takes the lock, so checking ctx under the
+	// lock means either Stop finds the subscription to close or run closes
+	// it here. Subscriptions outlive ctx, so one must.
+	lv.mu.Lock()
+	if ctx.Err() != nil {
+		lv.mu.Unlock()
+		sub.Close()
+		return
+	}
+	lv.sub = sub
+	lv.mu.Unlock()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-sub.C:
+			if !ok {
+				if ctx.Err() == nil {
+					lv.appendMarker(ctx, fmt.Sprintf("Log stream ended (%s to reconnect)", keys.LogReconnect.Short()))
+				}
+				return
+			}
+			lv.append(ctx, logLine{time: formatLogTime(entry.Timestamp), text: cleanLogText(entry.Message)})
+		}
+	}
+}
+
+func (lv *LogView) appendMarker(ctx context.Context, text string) {
+	lv.append(ctx, logLine{text: "-- " + text + " --", marker: true})
+}
+
+// append adds a line, holding it back while paused, and asks for a redraw.
+// Lines from a stream whose ctx has been cancelled are dropped, so a late
+// line from the previous container never lands in the new one's log.
+func (lv *LogView) append(ctx context.Context, l logLine) {
+	lv.mu.Lock()
+	if ctx.Err() != nil {
+		lv.mu.Unlock()
+		return
+	}
+	if lv.paused && !l.marker {
+		lv.held = append(lv.held, l)
+		if n := len(lv.held) - logMaxLines; n > 0 {
+			lv.held = lv.held[n:]
+		}
+	} else {
+		lv.lines = append(lv.lines, l)
+		lv.trim()
+	}
+	post := !lv.redrawPending && lv.postEvent != nil
+	lv.redrawPending = true
+	lv.mu.Unlock()
+
+	// Coalesce redraws: a burst of lines posts a single event.
+	if post {
+		lv.postEvent(DashboardUpdated{})
+	}
+}
+
+// trim drops the oldest lines beyond logMaxLines. Callers must hold mu.
+func (lv *LogView) trim() {
+	if n := len(lv.lines) - logMaxLines; n > 0 {
+		lv.lines = lv.lines[n:]
+		lv.dropped += n
+	}
+}
+
+// formatLogTime shortens an RFC 3339 container log timestamp to local
+// month, day and time. Anything else is shown as is.
+func formatLogTime(ts string) string {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(ts))
+	if err != nil {
+		return ts
+	}
+	return t.Local().Format("01-02 15:04:05")
+}
+
+// cleanLogText strips escape sequences and control characters and expands
+// tabs so a message draws on one row.
+func cleanLogText(s string) string {
+	s = ansiEscape.ReplaceAllString(s, "")
+	s = strings.TrimRight(s, "\r\n")
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func (lv *LogView) openPicker() {
+	items := make([]string, len(lv.containers))
+	for i, c := range lv.containers {
+		items[i] = containerName(c)
+		if c.State != "" {
+			items[i] += " (" + string(c.State) + ")"
+		}
+	}
+	lv.picker = &widgets.Picker{
+		Title:  "Container",
+		Items:  items,
+		Cursor: max(lv.container, 0),
+		OnSelect: func(i int) (vxfw.Command, error) {
+			lv.picker = nil
+			if i != lv.container || !lv.Streaming() {
+				lv.stream(i)
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+		OnCancel: func() (vxfw.Command, error) {
+			lv.picker = nil
+			if lv.container < 0 {
+				return lv.close()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		},
+	}
+}
+
+func (lv *LogView) close() (vxfw.Command, error) {
+	lv.Stop()
+	if lv.onClose != nil {
+		return lv.onClose()
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+func (lv *LogView) togglePause() {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	lv.paused = !lv.paused
+	if !lv.paused {
+		lv.lines = append(lv.lines, lv.held...)
+		lv.held = nil
+		lv.trim()
+	}
+}
+
+// scroll moves the view by n lines. Scrolling up stops following.
+func (lv *LogView) scroll(n int) {
+	lv.mu.Lock()
+	last := lv.dropped + max(len(lv.lines)-lv.height, 0)
+	lv.top = min(max(lv.top+n, lv.dropped), last)
+	lv.mu.Unlock()
+	if n < 0 {
+		lv.follow = false
+	}
+}
+
+// findMatch returns the absolute index of the next line matching the search
+// after (dir > 0) or before (dir < 0) from, wrapping around, or -1.
+func (lv *LogView) findMatch(from, dir int) int {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+	n := len(lv.lines)
+	if n == 0 || lv.search == "" {
+		return -1
+	}
+	start := min(max(from-lv.dropped, -1), n)
+	for step := 1; step <= n; step++ {
+		i := ((start+dir*step)%n + n) % n
+		if matchIndex(lv.lines[i].text, lv.search) >= 0 {
+			return lv.dropped + i
+		}
+	}
+	return -1
+}
+
+// jumpToMatch runs the search from the current match (or the top of the
+// view) and scrolls the match into view.
+func (lv *LogView) jumpToMatch(dir int) {
+	from := lv.match
+	if from < 0 {
+		from = lv.top - 1
+		if dir < 0 {
+			from = lv.top + lv.height
+		}
+	}
+	m := lv.findMatch(from, dir)
+	if m < 0 {
+		return
+	}
+	lv.match = m
+	lv.follow = false
+	lv.mu.Lock()
+	if m < lv.top || m >= lv.top+lv.height {
+		lv.top = max(m-lv.height/2, lv.dropped)
+	}
+	lv.mu.Unlock()
+}
+
+// matchIndex returns the byte offset of the first case-insensitive match of
+// pattern in s, or -1.
+func matchIndex(s, pattern string) int {
+	if pattern == "" {
+		return -1
+	}
+	return strings.Index(strings.ToLower(s), strings.ToLower(pattern))
+}
+
+// countMatches returns how many lines match the search. Callers must hold mu.
+func (lv *LogView) countMatches() int {
+	n := 0
+	for _, l := range lv.lines {
+		if matchIndex(l.text, lv.search) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// HandleEvent handles the pane's keys and the mouse wheel. The search
+// prompt and container picker take every key while open.
+func (lv *LogView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	if lv.picker != nil {
+		return lv.picker.HandleEvent(ev, phase)
+	}
+	if n, ok := wheelScroll(ev); ok {
+		lv.scroll(n)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	if lv.searching {
+		switch {
+		case key.Matches(vaxis.KeyEsc):
+			lv.search, lv.searching, lv.match = "", false, -1
+		case key.Matches(vaxis.KeyEnter):
+			lv.searching = false
+			lv.match = -1
+			lv.jumpToMatch(1)
+		case key.Matches(vaxis.KeyBackspace):
+			if lv.search != "" {
+				_, size := utf8.DecodeLastRuneInString(lv.search)
+				lv.search = lv.search[:len(lv.search)-size]
+			}
+		case key.Matches('u', vaxis.ModCtrl):
+			lv.search = ""
+		case key.Text != "":
+			lv.search += key.Text
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+
+	page := max(lv.height-1, 1)
+	switch {
+	case keys.CloseLogs.Matches(key):
+		// Esc clears an active search before it closes the pane.
+		if key.Matches(vaxis.KeyEsc) && lv.search != "" {
+			lv.search, lv.match = "", -1
+			break
+		}
+		return lv.close()
+	case keys.Down.Matches(key):
+		lv.scroll(1)
+	case keys.Up.Matches(key):
+		lv.scroll(-1)
+	case keys.PageDown.Matches(key):
+		lv.scroll(page)
+	case keys.PageUp.Matches(key):
+		lv.scroll(-page)
+	case keys.Top.Matches(key):
+		lv.mu.Lock()
+		lv.top = lv.dropped
+		lv.mu.Unlock()
+		lv.follow = false
+	case keys.Bottom.Matches(key):
+		lv.follow = true
+	case keys.LogFollow.Matches(key):
+		lv.follow = !lv.follow
+	case keys.LogPause.Matches(key):
+		lv.togglePause()
+	case keys.LogSearch.Matches(key):
+		lv.searching = true
+		lv.search = ""
+		lv.match = -1
+	case keys.NextMatch.Matches(key):
+		lv.jumpToMatch(1)
+	case keys.PrevMatch.Matches(key):
+		lv.jumpToMatch(-1)
+	case keys.LogContainer.Matches(key):
+		if len(lv.containers) > 1 {
+			lv.openPicker()
+		}
+	case keys.LogReconnect.Matches(key):
+		if lv.container >= 0 {
+			lv.stream(lv.container)
+		}
+	default:
+		// The pane is full-screen: swallow everything so keys don't act on
+		// the dashboard underneath.
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders the header, the visible log lines and the prompt or key hints
+// on the last row.
+func (lv *LogView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, lv)
+	width := int(ctx.Max.Width)
+	bodyHeight := int(ctx.Max.Height) - 2
+	if bodyHeight < 1 {
+		return s, nil
+	}
+
+	lv.mu.Lock()
+	lv.redrawPending = false
+	lv.height = bodyHeight
+	if lv.follow {
+		lv.top = lv.dropped + max(len(lv.lines)-bodyHeight, 0)
+	}
+	lv.top = max(lv.top, lv.dropped)
+	start := lv.top - lv.dropped
+	end := min(start+bodyHeight, len(lv.lines))
+	visible := append([]logLine(nil), lv.lines[min(start, end):end]...)
+	held, paused := len(lv.held), lv.paused
+	matches := 0
+	if lv.search != "" {
+		matches = lv.countMatches()
+	}
+	matchRow := lv.match - lv.top
+	lv.mu.Unlock()
+
+	// Header
+	bold := vaxis.Style{Attribute: vaxis.AttrBold}
+	dim := theme.Dim()
+	header := []vaxis.Segment{{Text: " LOGS  ", Style: bold}, {Text: lv.app.Name, Style: bold}}
+	if c := lv.Container(); c != "" {
+		header = append(header, vaxis.Segment{Text: " / " + c})
+	}
+	if lv.follow {
+		header = append(header, vaxis.Segment{Text: "  FOLLOW", Style: theme.OK()})
+	}
+	if paused {
+		header = append(header, vaxis.Segment{Text: fmt.Sprintf("  PAUSED +%d", held), Style: theme.Warning()})
+	}
+	if lv.search != "" && !lv.searching {
+		header = append(header, vaxis.Segment{Text: fmt.Sprintf("  /%s (%d matches)", lv.search, matches), Style: dim})
+	}
+	writeSegments(&s, 0, width, header)
+
+	// Body
+	for i, l := range visible {
+		row := uint16(i + 1)
+		if l.marker {
+			writeCell(&s, 1, row, width-1, l.text, dim, false)
+			continue
+		}
+		segs := []vaxis.Segment{{Text: " " + l.time + "  ", Style: dim}}
+		segs = append(segs, highlightMatch(l.text, lv.search, i == matchRow)...)
+		writeSegments(&s, row, width, segs)
+	}
+
+	// Prompt or hints
+	last := uint16(ctx.Max.Height - 1)
+	if lv.searching {
+		writeCell(&s, 0, last, width, " /"+lv.search+"█", vaxis.Style{}, false)
+	} else {
+		hint := fmt.Sprintf(" %s follow · %s pause · %s search · %s/%s next/prev · %s/%s top/bottom · %s close",
+			keys.LogFollow.Short(), keys.LogPause.Short(), keys.LogSearch.Short(), keys.NextMatch.Short(),
+			keys.PrevMatch.Short(), keys.Top.Short(), keys.Bottom.Short(), keys.CloseLogs.Short())
+		if len(lv.containers) > 1 {
+			hint = fmt.Sprintf(" %s container ·", keys.LogContainer.Short()) + hint
+		}
+		writeCell(&s, 0, last, width, hint, dim, false)
+	}
+
+	if lv.picker != nil {
+		pickerSurf, err := lv.picker.Draw(ctx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		col := (width - int(pickerSurf.Size.Width)) / 2
+		row := (int(ctx.Max.Height) - int(pickerSurf.Size.Height)) / 2
+		s.AddChild(max(col, 0), max(row, 0), pickerSurf)
+	}
+	return s, nil
+}
+
+// highlightMatch splits text into segments with every match of pattern
+// highlighted; the current match is highlighted more strongly.
+func highlightMatch(text, pattern string, current bool) []vaxis.Segment {
+	// Matches are shown reversed in the warning style, and the current
+	// one bold but not reversed, which stands out from the others.
+	hl := theme.Warning()
+	hl.Attribute |= vaxis.AttrReverse
+	if current {
+		hl = theme.Bold(theme.Warning())
+	}
+	if pattern == "" || len(strings.ToLower(text)) != len(text) {
+		// Offsets into the lowered text would not line up with text.
+		return []vaxis.Segment{{Text: text}}
+	}
+	var segs []vaxis.Segment
+	for {
+		i := matchIndex(text, pattern)
+		if i < 0 || i+len(pattern) > len(text) {
+			return append(segs, vaxis.Segment{Text: text})
+		}
+		end := i + len(pattern)
+		segs = append(segs, vaxis.Segment{Text: text[:i]}, vaxis.Segment{Text: text[i:end], Style: hl})
+		text = text[end:]
+	}
+}
+
+// writeSegments writes segments left to right on one row, clipped to width.
+func writeSegments(s *vxfw.Surface, row uint16, width int, segs []vaxis.Segment) {
+	col := 0
+	for _, seg := range segs {
+		if col >= width {
+			return
+		}
+		writeCell(s, uint16(col), row, width-col, seg.Text, seg.Style, false)
+		for _, ch := range vaxis.Characters(seg.Text) {
+			col += ch.Width
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package views_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/views"
+)
+
+// logStreams fakes app.container_log_follow: each subscription gets its own
+// channel, and closing it records the cancel.
+type logStreams struct {
+	mu     sync.Mutex
+	opts   []truenas.ContainerLogOpts
+	chans  []chan truenas.AppContainerLogEntry
+	ctxs   []context.Context
+	closed []bool
+	// gate, if set, holds each subscribe call until it is closed.
+	gate chan struct{}
+}
+
+func (l *logStreams) subscribe(ctx context.Context, opts truenas.ContainerLogOpts) (*truenas.Subscription[truenas.AppContainerLogEntry], error) {
+	if l.gate != nil {
+		<-l.gate
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ch := make(chan truenas.AppContainerLogEntry, 100)
+	i := len(l.chans)
+	l.opts = append(l.opts, opts)
+	l.chans = append(l.chans, ch)
+	l.ctxs = append(l.ctxs, ctx)
+	l.closed = append(l.closed, false)
+	return truenas.NewSubscription(ch, func() {
+		l.mu.Lock()
+		l.closed[i] = true
+		l.mu.Unlock()
+	}), nil
+}
+
+func (l *logStreams) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.chans)
+}
+
+func (l *logStreams) send(i int, msgs ...string) {
+	l.mu.Lock()
+	ch := l.chans[i]
+	l.mu.Unlock()
+	for _, m := range msgs {
+		ch <- truenas.AppContainerLogEntry{Timestamp: "2026-10-16T12:00:00.123456789Z", Message: m}
+	}
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func logApp(containers ...string) truenas.App {
+	app := truenas.App{Name: "plex", State: "RUNNING"}
+	for _, c := range containers {
+		app.ActiveWorkloads.ContainerDetails = append(app.ActiveWorkloads.ContainerDetails,
+			truenas.AppContainerDetails{ID: c + "-id", ServiceName: c})
+	}
+	return app
+}
+
+func newLogView(t *testing.T, streams *logStreams, app truenas.App) (*views.LogView, *int) {
+	t.Helper()
+	closed := 0
+	lv := views.NewLogView(views.LogViewParams{
+		Service: &truenas.MockAppService{SubscribeContainerLogsFunc: streams.subscribe},
+		App:     app,
+		OnClose: func() (vxfw.Command, error) { closed++; return nil, nil },
+	})
+	t.Cleanup(lv.Stop)
+	return lv, &closed
+}
+
+func TestLogView_Streams(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+
+	opts := streams.opts[0]
+	if opts.AppName != "plex" || opts.ContainerID != "plex-id" || opts.TailLines <= 0 {
+		t.Errorf("unexpected opts: %+v", opts)
+	}
+	if lv.Container() != "plex" {
+		t.Errorf("expected container plex, got %q", lv.Container())
+	}
+
+	streams.send(0, "starting server", "\x1b[32mlistening\x1b[0m on :32400\n")
+	waitFor(t, "lines", func() bool { return len(lv.Lines()) == 2 })
+	if got := lv.Lines()[1]; got != "listening on :32400" {
+		t.Errorf("expected escape codes stripped, got %q", got)
+	}
+	if text := drawText(t, lv); !strings.Contains(text, "starting server") || !strings.Contains(text, "FOLLOW") {
+		t.Error("expected lines and follow indicator in the pane")
+	}
+}
+
+// A stream stopped while it is still subscribing closes the subscription
+// once it arrives.
+func TestLogView_StopWhileSubscribing(t *testing.T) {
+	streams := &logStreams{gate: make(chan struct{})}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	lv.Stop()
+	close(streams.gate)
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	waitFor(t, "subscription closed", func() bool {
+		streams.mu.Lock()
+		defer streams.mu.Unlock()
+		return streams.closed[0]
+	})
+}
+
+func TestLogView_CloseCancelsStream(t *testing.T) {
+	streams := &logStreams{}
+	lv, closed := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	waitFor(t, "stream registered", func() bool {
+		streams.send(0, "x")
+		return len(lv.Lines()) > 0
+	})
+
+	sendKey(t, lv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if *closed != 1 {
+		t.Fatalf("expected OnClose, got %d calls", *closed)
+	}
+	if lv.Streaming() {
+		t.Error("expected stream stopped")
+	}
+	if streams.ctxs[0].Err() == nil {
+		t.Error("expected subscription context cancelled")
+	}
+	waitFor(t, "subscription closed", func() bool {
+		streams.mu.Lock()
+		defer streams.mu.Unlock()
+		return streams.closed[0]
+	})
+}
+
+func TestLogView_ContainerChoice(t *testing.T) {
+	streams := &logStreams{}
+	lv, closed := newLogView(t, streams, logApp("plex", "plex-db"))
+	lv.Start()
+	if streams.count() != 0 || lv.Streaming() {
+		t.Fatal("expected no stream before a container is chosen")
+	}
+	if text := drawText(t, lv); !strings.Contains(text, "› plex") || !strings.Contains(text, "plex-db") {
+		t.Fatal("expected container picker")
+	}
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'j', Text: "j"})
+	sendKey(t, lv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	if streams.opts[0].ContainerID != "plex-db-id" {
+		t.Errorf("expected plex-db, got %q", streams.opts[0].ContainerID)
+	}
+	streams.send(0, "db ready")
+	waitFor(t, "db line", func() bool { return len(lv.Lines()) == 1 })
+
+	// Switching containers cancels the old stream and starts over.
+	sendKey(t, lv, vaxis.Key{Keycode: 'c', Text: "c"})
+	sendKey(t, lv, vaxis.Key{Keycode: 'k', Text: "k"})
+	sendKey(t, lv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	waitFor(t, "second subscription", func() bool { return streams.count() == 2 })
+	if streams.ctxs[0].Err() == nil {
+		t.Error("expected first stream cancelled")
+	}
+	if streams.opts[1].ContainerID != "plex-id" || lv.Container() != "plex" {
+		t.Errorf("expected plex, got %q", streams.opts[1].ContainerID)
+	}
+	if len(lv.Lines()) != 0 {
+		t.Errorf("expected log cleared on switch, got %v", lv.Lines())
+	}
+	if *closed != 0 {
+		t.Error("picker should not close the pane once a container is chosen")
+	}
+}
+
+func TestLogView_PickerCancelClosesPane(t *testing.T) {
+	streams := &logStreams{}
+	lv, closed := newLogView(t, streams, logApp("plex", "plex-db"))
+	lv.Start()
+	sendKey(t, lv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if *closed != 1 {
+		t.Errorf("expected cancelling the first pick to close the pane, got %d", *closed)
+	}
+}
+
+func TestLogView_Pause(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	streams.send(0, "one")
+	waitFor(t, "first line", func() bool { return len(lv.Lines()) == 1 })
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'p', Text: "p"})
+	if !lv.Paused() {
+		t.Fatal("expected paused")
+	}
+	streams.send(0, "two", "three")
+	time.Sleep(50 * time.Millisecond)
+	if n := len(lv.Lines()); n != 1 {
+		t.Errorf("expected lines held back while paused, got %d", n)
+	}
+	if text := drawText(t, lv); !strings.Contains(text, "PAUSED +2") {
+		t.Error("expected paused indicator with held count")
+	}
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'p', Text: "p"})
+	if got := lv.Lines(); len(got) != 3 || got[2] != "three" {
+		t.Errorf("expected held lines appended on resume, got %v", got)
+	}
+}
+
+func TestLogView_FollowAndScroll(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	for i := range 50 {
+		streams.send(0, fmt.Sprintf("line %d", i))
+	}
+	waitFor(t, "lines", func() bool { return len(lv.Lines()) == 50 })
+
+	// 30 rows: header, 28 lines, hints.
+	drawText(t, lv)
+	if lv.Top() != 22 {
+		t.Fatalf("expected following to show the last 28 lines, top=%d", lv.Top())
+	}
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'k', Text: "k"})
+	if lv.Following() || lv.Top() != 21 {
+		t.Errorf("expected scrolling up to stop following at 21, got follow=%v top=%d", lv.Following(), lv.Top())
+	}
+	streams.send(0, "new")
+	waitFor(t, "new line", func() bool { return len(lv.Lines()) == 51 })
+	drawText(t, lv)
+	if lv.Top() != 21 {
+		t.Errorf("expected view to stay put while not following, top=%d", lv.Top())
+	}
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'g', ShiftedCode: 'G', Modifiers: vaxis.ModShift, Text: "G"})
+	drawText(t, lv)
+	if !lv.Following() || lv.Top() != 23 {
+		t.Errorf("expected G to follow again at 23, got follow=%v top=%d", lv.Following(), lv.Top())
+	}
+}
+
+func TestLogView_Search(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	for i := range 100 {
+		msg := fmt.Sprintf("line %d", i)
+		if i == 10 || i == 60 {
+			msg = "ERROR database locked"
+		}
+		streams.send(0, msg)
+	}
+	waitFor(t, "lines", func() bool { return len(lv.Lines()) == 100 })
+	drawText(t, lv)
+
+	sendKey(t, lv, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, lv, "error")
+	sendKey(t, lv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if lv.Following() {
+		t.Error("expected search to stop following")
+	}
+	top := lv.Top()
+	if top > 10 || top+28 <= 10 {
+		t.Fatalf("expected match at line 10 in view, top=%d", top)
+	}
+	if text := drawText(t, lv); !strings.Contains(text, "/error (2 matches)") {
+		t.Error("expected match count in the header")
+	}
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'n', Text: "n"})
+	if top := lv.Top(); top > 60 || top+28 <= 60 {
+		t.Errorf("expected n to jump to line 60, top=%d", top)
+	}
+	sendKey(t, lv, vaxis.Key{Keycode: 'n', Text: "n"})
+	if top := lv.Top(); top > 10 || top+28 <= 10 {
+		t.Errorf("expected n to wrap to line 10, top=%d", top)
+	}
+}
+
+func TestLogView_StreamEnded(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	close(streams.chans[0])
+	waitFor(t, "end marker", func() bool {
+		lines := lv.Lines()
+		return len(lines) == 1 && strings.Contains(lines[0], "Log stream ended (r to reconnect)")
+	})
+
+	sendKey(t, lv, vaxis.Key{Keycode: 'r', Text: "r"})
+	waitFor(t, "reconnect", func() bool { return streams.count() == 2 })
+}
+
+func TestLogView_StreamEnded_RemappedKey(t *testing.T) {
+	km, err := keys.Resolve(map[string][]string{"log_reconnect": {"ctrl+r"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Apply(km)
+	t.Cleanup(func() { keys.Apply(nil) })
+
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp("plex"))
+	lv.Start()
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	close(streams.chans[0])
+	waitFor(t, "end marker", func() bool {
+		lines := lv.Lines()
+		return len(lines) == 1 && strings.Contains(lines[0], "Log stream ended (Ctrl+R to reconnect)")
+	})
+}
+
+func TestLogView_NoContainers(t *testing.T) {
+	streams := &logStreams{}
+	lv, _ := newLogView(t, streams, logApp())
+	lv.Start()
+	if got := lv.Lines(); len(got) != 1 || !strings.Contains(got[0], "No containers") {
+		t.Errorf("expected no containers message, got %v", got)
+	}
+}
+
+func TestDashboardView_Logs(t *testing.T) {
+	streams := &logStreams{}
+	params := mockDashboardServices()
+	params.Apps = &truenas.MockAppService{
+		ListAppsFunc: func(ctx context.Context) ([]truenas.App, error) {
+			return []truenas.App{logApp("plex")}, nil
+		},
+		SubscribeContainerLogsFunc: streams.subscribe,
+	}
+	dv := views.NewDashboardView(params)
+	if err := dv.Load(context.Background()); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'l', ShiftedCode: 'L', Modifiers: vaxis.ModShift, Text: "L"})
+	lv := dv.Logs()
+	if lv == nil || !dv.CapturingInput() {
+		t.Fatal("expected log pane open and capturing input")
+	}
+	waitFor(t, "subscription", func() bool { return streams.count() == 1 })
+	if text := drawText(t, dv); !strings.Contains(text, "LOGS  plex / plex") {
+		t.Error("expected dashboard to draw the log pane")
+	}
+
+	sendKey(t, dv, vaxis.Key{Keycode: 'q', Text: "q"})
+	if dv.Logs() != nil || dv.CapturingInput() {
+		t.Error("expected log pane closed")
+	}
+	if lv.Streaming() || streams.ctxs[0].Err() == nil {
+		t.Error("expected stream cancelled on close")
+	}
+}
@@ -0,0 +1,247 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
+	"github.com/dustin/go-humanize"
+)
+
+// Poll intervals for the pool detail pane: fast while a scrub or resilver is
+// running so the progress bar moves, slow otherwise to pick up new scans and
+// error counters.
+const (
+	poolDetailScanInterval = 2 * time.Second
+	poolDetailIdleInterval = 15 * time.Second
+)
+
+// poolDetail is the state of the detail pane opened from the pools list.
+type poolDetail struct {
+	poolID int64
+	detail *internal.PoolDetail
+	err    error
+	cancel context.CancelFunc
+}
+
+// pollPoolDetail fetches the pool's detail until ctx is cancelled, posting
+// each result to pv as a PoolDetailLoaded event.
+func pollPoolDetail(ctx context.Context, pv *PoolsView, id int64) {
+	for {
+		detail, err := pv.poolSvc.GetPool(ctx, id)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil && detail == nil {
+			err = fmt.Errorf("pool %d not found", id)
+		}
+		if pv.postEvent != nil {
+			pv.postEvent(PoolDetailLoaded{View: pv, PoolID: id, Detail: detail, Err: err})
+		}
+
+		interval := poolDetailIdleInterval
+		if err == nil && detail.Scan.Running() {
+			interval = poolDetailScanInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// detailLine is one row of a detail pane: text segments, or a progress bar
+// when gauge is set.
+type detailLine struct {
+	segments []vaxis.Segment
+	gauge    *widgets.BarGauge
+}
+
+func textLine(text string, style vaxis.Style) detailLine {
+	return detailLine{segments: []vaxis.Segment{{Text: text, Style: style}}}
+}
+
+// poolDetailLines lays out the detail pane:
+//
+//	tank  ONLINE
+//	Scrub finished 2026-10-01 03:12 after 2h13m, 0 errors
+//
+//	VDEV                              STATE         READ  WRITE  CKSUM
+//	data
+//	  mirror-0                        ONLINE           0      0      0
+//	    sda                           ONLINE           0      0      0
+func poolDetailLines(d *internal.PoolDetail) []detailLine {
+	bold := vaxis.Style{Attribute: vaxis.AttrBold}
+	dim := theme.Dim()
+
+	lines := []detailLine{{segments: []vaxis.Segment{
+		{Text: d.Name + "  ", Style: bold},
+		{Text: d.Status, Style: vdevStateStyle(d.Status)},
+	}}}
+	if d.StatusDetail != "" {
+		lines = append(lines, textLine(d.StatusDetail, dim))
+	}
+	lines = append(lines, scanLines(d.Scan)...)
+	lines = append(lines, detailLine{})
+	lines = append(lines, textLine(fmt.Sprintf("%-34s%-10s%7s%7s%7s", "VDEV", "STATE", "READ", "WRITE", "CKSUM"), bold))
+
+	groups := []struct {
+		name  string
+		vdevs []internal.Vdev
+	}{
+		{"data", d.Topology.Data},
+		{"special", d.Topology.Special},
+		{"dedup", d.Topology.Dedup},
+		{"log", d.Topology.Log},
+		{"cache", d.Topology.Cache},
+		{"spare", d.Topology.Spare},
+	}
+	for _, g := range groups {
+		if len(g.vdevs) == 0 {
+			continue
+		}
+		lines = append(lines, textLine(g.name, dim))
+		for _, v := range g.vdevs {
+			lines = appendVdevLines(lines, v, 1)
+		}
+	}
+	return lines
+}
+
+func appendVdevLines(lines []detailLine, v internal.Vdev, depth int) []detailLine {
+	name := strings.Repeat("  ", depth) + v.DisplayName()
+	if v.Type != "" && v.Type != "DISK" && !strings.Contains(strings.ToUpper(v.Name), v.Type) {
+		name += " (" + strings.ToLower(v.Type) + ")"
+	}
+	errStyle := func(n int64) vaxis.Style {
+		if n > 0 {
+			return theme.Bold(theme.Critical())
+		}
+		return vaxis.Style{}
+	}
+	lines = append(lines, detailLine{segments: []vaxis.Segment{
+		{Text: fmt.Sprintf("%-34s", name)},
+		{Text: fmt.Sprintf("%-10s", v.Status), Style: vdevStateStyle(v.Status)},
+		{Text: fmt.Sprintf("%7d", v.ReadErrors), Style: errStyle(v.ReadErrors)},
+		{Text: fmt.Sprintf("%7d", v.WriteErrors), Style: errStyle(v.WriteErrors)},
+		{Text: fmt.Sprintf("%7d", v.ChecksumErrors), Style: errStyle(v.ChecksumErrors)},
+	}})
+	for _, c := range v.Children {
+		lines = appendVdevLines(lines, c, depth+1)
+	}
+	return lines
+}
+
+// vdevStateStyle colors ZFS health states: green when online, yellow when
+// degraded, red otherwise.
+func vdevStateStyle(state string) vaxis.Style {
+	switch state {
+	case "ONLINE":
+		return theme.OK()
+	case "DEGRADED":
+		return theme.Warning()
+	default:
+		return theme.Critical()
+	}
+}
+
+// scanLines describes the last scan, with a progress bar while one runs.
+func scanLines(scan *internal.PoolScan) []detailLine {
+	dim := theme.Dim()
+	if scan == nil {
+		return []detailLine{textLine("No scrub has run on this pool", dim)}
+	}
+	kind := "Scrub"
+	if scan.Function == internal.ScanFunctionResilver {
+		kind = "Resilver"
+	}
+
+	switch scan.State {
+	case internal.ScanStateScanning:
+		status := fmt.Sprintf("%s in progress since %s", kind, scan.StartTime.Format("2006-01-02 15:04"))
+		if scan.Paused {
+			status += " (paused)"
+		}
+		suffix := fmt.Sprintf("%s of %s", humanize.IBytes(uint64(scan.BytesProcessed)), humanize.IBytes(uint64(scan.BytesToProcess)))
+		if scan.SecondsLeft > 0 && !scan.Paused {
+			suffix += ", " + formatDuration(time.Duration(scan.SecondsLeft)*time.Second) + " left"
+		}
+		return []detailLine{
+			textLine(status, theme.Warning()),
+			{gauge: &widgets.BarGauge{
+				Label:    strings.ToUpper(kind[:4]),
+				Value:    scan.Percentage,
+				Suffix:   suffix,
+				BarWidth: 30,
+				Color:    theme.Accent().Foreground,
+			}},
+		}
+	case internal.ScanStateCanceled:
+		return []detailLine{textLine(fmt.Sprintf("%s canceled %s", kind, scan.EndTime.Format("2006-01-02 15:04")), dim)}
+	default:
+		style := vaxis.Style{}
+		if scan.Errors > 0 {
+			style = theme.Critical()
+		}
+		return []detailLine{textLine(fmt.Sprintf("%s finished %s after %s, %d errors",
+			kind, scan.EndTime.Format("2006-01-02 15:04"), formatDuration(scan.EndTime.Sub(scan.StartTime)), scan.Errors), style)}
+	}
+}
+
+// formatDuration renders a duration to the minute, e.g. "2h13m" or "45m".
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "<1m"
+	}
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	if h == 0 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+// drawPoolDetail renders the detail pane into a surface of the given size.
+func drawPoolDetail(ctx vxfw.DrawContext, owner vxfw.Widget, pd *poolDetail) (vxfw.Surface, error) {
+	var lines []detailLine
+	switch {
+	case pd.err != nil:
+		lines = []detailLine{textLine("Error loading pool: "+pd.err.Error(), theme.Critical())}
+	case pd.detail == nil:
+		lines = []detailLine{textLine("Loading pool details...", theme.Dim())}
+	default:
+		lines = poolDetailLines(pd.detail)
+	}
+
+	return drawDetailLines(ctx, owner, lines)
+}
+
+// drawDetailLines renders detail pane rows top to bottom, clipped to the
+// surface height.
+func drawDetailLines(ctx vxfw.DrawContext, owner vxfw.Widget, lines []detailLine) (vxfw.Surface, error) {
+	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, owner)
+	lineCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1})
+	for i, line := range lines {
+		if i >= int(ctx.Max.Height) {
+			break
+		}
+		var w vxfw.Widget = richtext.New(line.segments)
+		if line.gauge != nil {
+			w = line.gauge
+		}
+		surf, err := w.Draw(lineCtx)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, i, surf)
+	}
+	return s, nil
+}
@@ -1,8 +1,12 @@
 package views
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
@@ -10,43 +14,89 @@ import (
 	"git.sr.ht/~rockorager/vaxis/vxfw/list"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/logging"
+	"github.com/deevus/truenas-tui/theme"
 	"github.com/dustin/go-humanize"
 )
 
 // PoolsViewParams holds configuration for creating a PoolsView.
 type PoolsViewParams struct {
-	Service  truenas.DatasetServiceAPI
-	StaleTTL time.Duration
+	Service   truenas.DatasetServiceAPI
+	Pools     internal.PoolServiceAPI // topology and scan status for the detail pane
+	StaleTTL  time.Duration
+	PostEvent func(vaxis.Event)
 }
 
 // PoolsView displays a list of TrueNAS storage pools.
 type PoolsView struct {
-	service  truenas.DatasetServiceAPI
-	pools    []truenas.Pool
-	list     list.Dynamic
-	loaded   bool
-	loadedAt time.Time
-	staleTTL time.Duration
+	service   truenas.DatasetServiceAPI
+	poolSvc   internal.PoolServiceAPI
+	postEvent func(vaxis.Event)
+	detail    *poolDetail // open detail pane, or nil
+	scans     map[int64]*internal.PoolScan
+	trackMu   sync.Mutex
+	trackers  map[int64]bool // pools with a running scan tracker
+	// pollCtx bounds the scan trackers and the detail pane's poller;
+	// StopPolling cancels it when the connection goes away.
+	pollCtx   context.Context
+	stopPolls context.CancelFunc
+	act       actions
+	pools     []truenas.Pool
+	rows      []*truenas.Pool // pools after filtering and sorting
+	query     listQuery
+	list      list.Dynamic
+	loaded    bool
+	loadedAt  time.Time
+	staleTTL  time.Duration
+
+	loadError
 }
 
 // NewPoolsView creates a PoolsView backed by the given params.
 func NewPoolsView(p PoolsViewParams) *PoolsView {
 	pv := &PoolsView{
-		service:  p.Service,
-		staleTTL: p.StaleTTL,
+		service:   p.Service,
+		poolSvc:   p.Pools,
+		postEvent: p.PostEvent,
+		staleTTL:  p.StaleTTL,
+		query:     newListQuery("NAME", "STATUS", "SIZE", "ALLOC", "FREE"),
+		scans:     make(map[int64]*internal.PoolScan),
+		trackers:  make(map[int64]bool),
+		act:       actions{postEvent: p.PostEvent},
 	}
+	pv.pollCtx, pv.stopPolls = context.WithCancel(context.Background())
 	pv.list.DrawCursor = true
 	pv.list.Builder = pv.buildItem
+	clickableRows(&pv.list, pv.rowClicked)
 	return pv
 }
 
-// Load fetches pools from the service.
+// Load fetches pools from the service, along with their scan status when a
+// pool service is configured. Running scans are tracked until they finish.
 func (pv *PoolsView) Load(ctx context.Context) error {
 	pools, err := pv.service.ListPools(ctx)
 	if err != nil {
 		return err
 	}
+	if pv.poolSvc != nil {
+		// Scan status is extra; the list is still useful without it.
+		if details, err := pv.poolSvc.ListPools(ctx); err != nil {
+			logging.Warnf("error loading pool scan status: %v", err)
+		} else {
+			scans := make(map[int64]*internal.PoolScan, len(details))
+			for _, d := range details {
+				scans[d.ID] = d.Scan
+				if d.Scan.Running() {
+					pv.trackScan(d.ID, false)
+				}
+			}
+			pv.scans = scans
+		}
+	}
 	pv.pools = pools
+	pv.applyQuery()
 	pv.loaded = true
 	pv.loadedAt = time.Now()
 	return nil
@@ -75,38 +125,105 @@ func (pv *PoolsView) ItemCount() int {
 	return len(pv.pools)
 }
 
+// RowCount returns the number of pools shown after filtering.
+func (pv *PoolsView) RowCount() int {
+	return len(pv.rows)
+}
+
+// SelectedPool returns the pool under the cursor, or nil if none are shown.
+func (pv *PoolsView) SelectedPool() *truenas.Pool {
+	idx := int(pv.list.Cursor())
+	if idx >= len(pv.rows) {
+		return nil
+	}
+	return pv.rows[idx]
+}
+
+// applyQuery rebuilds the visible rows from the loaded pools, keeping the
+// selected pool under the cursor when it is still shown.
+func (pv *PoolsView) applyQuery() {
+	var selected string
+	if p := pv.SelectedPool(); p != nil {
+		selected = p.Name
+	}
+
+	rows := make([]*truenas.Pool, 0, len(pv.pools))
+	for i := range pv.pools {
+		if pv.query.matches(pv.pools[i].Name) {
+			rows = append(rows, &pv.pools[i])
+		}
+	}
+	if pv.query.sortCol >= 0 {
+		col := pv.query.columns[pv.query.sortCol]
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			var c int
+			switch col {
+			case "NAME":
+				c = strings.Compare(a.Name, b.Name)
+			case "STATUS":
+				c = strings.Compare(a.Status, b.Status)
+			case "SIZE":
+				c = cmp.Compare(a.Size, b.Size)
+			case "ALLOC":
+				c = cmp.Compare(a.Allocated, b.Allocated)
+			case "FREE":
+				c = cmp.Compare(a.Free, b.Free)
+			}
+			return pv.query.ordered(c)
+		})
+	}
+	pv.rows = rows
+
+	pv.list.SetCursor(0)
+	for i, p := range rows {
+		if p.Name == selected {
+			pv.list.SetCursor(uint(i))
+			break
+		}
+	}
+}
+
 func (pv *PoolsView) buildItem(i uint, cursor uint) vxfw.Widget {
-	if int(i) >= len(pv.pools) {
+	if int(i) >= len(pv.rows) {
 		return nil
 	}
-	p := pv.pools[i]
+	p := pv.rows[i]
 
-	statusStyle := vaxis.Style{Foreground: vaxis.IndexColor(2)} // green
+	statusStyle := theme.OK()
 	if p.Status != "ONLINE" {
-		statusStyle.Foreground = vaxis.IndexColor(1) // red
+		statusStyle = theme.Critical()
 	}
 
-	return richtext.New([]vaxis.Segment{
+	segments := []vaxis.Segment{
 		{Text: fmt.Sprintf("%-20s", p.Name)},
 		{Text: fmt.Sprintf("%-10s", p.Status), Style: statusStyle},
 		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(p.Size)))},
 		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(p.Allocated)))},
 		{Text: fmt.Sprintf("%10s", humanize.IBytes(uint64(p.Free)))},
-	})
+	}
+	return richtext.New(append(segments, scanCell(pv.scanOf(p.ID))...))
 }
 
-// Draw renders the pools list, or a loading state if data hasn't arrived.
+// Draw renders the pools list, or a loading or error state if data
+// hasn't arrived.
 func (pv *PoolsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	if !pv.loaded {
-		return drawLoadingState(ctx, pv)
+		return drawLoadingState(ctx, pv, &pv.loadError)
 	}
+	return drawErrorBanner(ctx, pv, &pv.loadError, pv.draw)
+}
 
+func (pv *PoolsView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, pv)
 
 	// Header row
+	q := &pv.query
 	header := richtext.New([]vaxis.Segment{
-		{Text: fmt.Sprintf("%-20s%-10s%10s%10s%10s", "NAME", "STATUS", "SIZE", "ALLOC", "FREE"),
+		{Text: fmt.Sprintf("%-20s%-10s%10s%10s%10s",
+			q.column("NAME"), q.column("STATUS"), q.column("SIZE"), q.column("ALLOC"), q.column("FREE")),
 			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: q.summary(len(pv.rows), len(pv.pools)), Style: theme.Dim()},
 	})
 	headerSurf, err := header.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
 	if err != nil {
@@ -114,18 +231,209 @@ func (pv *PoolsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	}
 	s.AddChild(0, 0, headerSurf)
 
-	// List
-	listCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - 1})
+	// List, sharing the space with the detail pane when it is open
+	listHeight := ctx.Max.Height - 1 - min(pv.act.statusHeight(), ctx.Max.Height-1)
+	if pv.detail != nil {
+		listHeight = min(uint16(max(len(pv.rows), 1)), listHeight/3)
+	}
+	listCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: listHeight})
 	listSurf, err := pv.list.Draw(listCtx)
 	if err != nil {
 		return vxfw.Surface{}, err
 	}
 	s.AddChild(0, 1, listSurf)
 
+	if pv.detail != nil && ctx.Max.Height > listHeight+2+pv.act.statusHeight() {
+		detailCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - listHeight - 2 - pv.act.statusHeight()})
+		detailSurf, err := drawPoolDetail(detailCtx, pv, pv.detail)
+		if err != nil {
+			return vxfw.Surface{}, err
+		}
+		s.AddChild(0, int(listHeight)+2, detailSurf)
+	}
+
+	if err := pv.act.draw(ctx, &s); err != nil {
+		return vxfw.Surface{}, err
+	}
 	return s, nil
 }
 
-// HandleEvent delegates to the list widget for navigation.
+// DetailOpen reports whether the detail pane is showing.
+func (pv *PoolsView) DetailOpen() bool {
+	return pv.detail != nil
+}
+
+// Detail returns the latest detail for the pool in the detail pane, or nil.
+func (pv *PoolsView) Detail() *internal.PoolDetail {
+	if pv.detail == nil {
+		return nil
+	}
+	return pv.detail.detail
+}
+
+// OpenDetail shows the detail pane for the selected pool and starts polling
+// its topology and scan status.
+func (pv *PoolsView) OpenDetail() {
+	p := pv.SelectedPool()
+	if p == nil || pv.poolSvc == nil {
+		return
+	}
+	if pv.detail != nil && pv.detail.poolID == p.ID {
+		return
+	}
+	pv.CloseDetail()
+	ctx, cancel := context.WithCancel(pv.pollCtx)
+	pv.detail = &poolDetail{poolID: p.ID, cancel: cancel}
+	go pollPoolDetail(ctx, pv, p.ID)
+}
+
+// CloseDetail hides the detail pane and stops polling.
+func (pv *PoolsView) CloseDetail() {
+	if pv.detail == nil {
+		return
+	}
+	pv.detail.cancel()
+	pv.detail = nil
+}
+
+// StopPolling stops the scan trackers and the detail pane's poller for
+// good, for when the connection they poll is closed. The view keeps
+// showing what it last loaded.
+func (pv *PoolsView) StopPolling() {
+	pv.stopPolls()
+}
+
+// DetailLoaded applies a poll result to the pool's row and to the detail
+// pane. Detail results for a pool that is no longer shown are dropped.
+func (pv *PoolsView) DetailLoaded(ev PoolDetailLoaded) {
+	if ev.Err == nil && ev.Detail != nil {
+		pv.scans[ev.PoolID] = ev.Detail.Scan
+	}
+	if pv.detail == nil || pv.detail.poolID != ev.PoolID {
+		return
+	}
+	pv.detail.err = ev.Err
+	if ev.Err == nil {
+		pv.detail.detail = ev.Detail
+	}
+}
+
+// CapturingInput reports whether a dialog or the filter prompt is open.
+func (pv *PoolsView) CapturingInput() bool {
+	return pv.act.modal != nil || pv.query.editing
+}
+
+// Status returns the status line text from the last action.
+func (pv *PoolsView) Status() string {
+	return pv.act.status
+}
+
+// ActionDone records the outcome of a finished scrub action.
+func (pv *PoolsView) ActionDone(ev ActionCompleted) {
+	pv.act.done(ev)
+}
+
+// KeyScopes returns the bindings of the Pools tab for the help.
+func (pv *PoolsView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.PoolsScope, keys.QueryScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the Pools tab.
+func (pv *PoolsView) Commands() []PaletteCommand {
+	p := pv.SelectedPool()
+	var scan *internal.PoolScan
+	if p != nil {
+		scan = pv.scanOf(p.ID)
+	}
+	scrubbing := scan.Running() && scan.Function == internal.ScanFunctionScrub
+	noPool := p == nil || pv.poolSvc == nil
+	detail, pause := "Open pool detail", "Pause scrub"
+	if pv.detail != nil {
+		detail = "Close pool detail"
+	}
+	if scrubbing && scan.Paused {
+		pause = "Resume scrub"
+	}
+	return append([]PaletteCommand{
+		{Name: detail, Key: keys.ToggleDetail.Short(), Disabled: p == nil, Run: func() (vxfw.Command, error) {
+			if pv.detail != nil {
+				pv.CloseDetail()
+			} else {
+				pv.OpenDetail()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}},
+		{Name: "Start scrub", Key: keys.StartScrub.Short(), Disabled: noPool || scan.Running(), Run: pv.startScrub},
+		{Name: pause, Key: keys.PauseScrub.Short(), Disabled: noPool || !scrubbing, Run: pv.togglePauseScrub},
+		{Name: "Cancel scrub", Key: keys.CancelScrub.Short(), Disabled: noPool || !scrubbing, Run: pv.cancelScrub},
+	}, pv.query.commands(func() {
+		pv.applyQuery()
+		pv.followCursor()
+	})...)
+}
+
+// HandleEvent handles the detail pane, scrub, filter and sort keys and
+// delegates navigation to the list widget. While the detail pane is open it
+// follows the cursor.
+//
+//	x   start a scrub
+//	p   pause / resume the scrub
+//	X   cancel the scrub
 func (pv *PoolsView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
-	return pv.list.HandleEvent(ev, phase)
+	if cmd, ok, err := pv.act.handleModal(ev, phase); ok {
+		return cmd, err
+	}
+	if key, ok := ev.(vaxis.Key); ok {
+		switch {
+		case pv.query.editing:
+		case keys.CancelScrub.Matches(key):
+			return pv.cancelScrub()
+		case keys.StartScrub.Matches(key):
+			return pv.startScrub()
+		case keys.PauseScrub.Matches(key):
+			return pv.togglePauseScrub()
+		case keys.ToggleDetail.Matches(key):
+			if pv.detail != nil {
+				pv.CloseDetail()
+			} else {
+				pv.OpenDetail()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		case keys.CloseDetail.Matches(key) && pv.detail != nil:
+			pv.CloseDetail()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if handled, changed := pv.query.handleKey(key); handled {
+			if changed {
+				pv.applyQuery()
+				pv.followCursor()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+	}
+	cmd, err := handleListEvent(&pv.list, ev, phase)
+	pv.followCursor()
+	return cmd, err
+}
+
+// rowClicked moves an open detail pane to the clicked pool, or opens it on
+// a double click.
+func (pv *PoolsView) rowClicked(double bool) (vxfw.Command, error) {
+	pv.followCursor()
+	if double {
+		pv.OpenDetail()
+	}
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// followCursor points an open detail pane at the selected pool.
+func (pv *PoolsView) followCursor() {
+	if pv.detail == nil {
+		return
+	}
+	if p := pv.SelectedPool(); p == nil {
+		pv.CloseDetail()
+	} else if p.ID != pv.detail.poolID {
+		pv.OpenDetail()
+	}
 }
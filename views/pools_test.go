@@ -2,12 +2,15 @@ package views_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
 	"github.com/deevus/truenas-tui/views"
 )
 
@@ -198,6 +201,40 @@ func TestPoolsView_Draw_Empty(t *testing.T) {
 	}
 }
 
+func TestPoolsView_Draw_LoadError(t *testing.T) {
+	pv := newPoolsView(&truenas.MockDatasetService{})
+	pv.SetLoadError(fmt.Errorf("pool.query timed out"))
+
+	text := drawText(t, pv)
+	if !strings.Contains(text, "Failed to load: pool.query timed out") || !strings.Contains(text, "press r to retry") {
+		t.Errorf("expected the error state, got %q", text)
+	}
+	if strings.Contains(text, "Loading...") {
+		t.Error("expected the error to replace the loading message")
+	}
+}
+
+func TestPoolsView_Draw_ReloadError(t *testing.T) {
+	pv := newPoolsView(threePools())
+	if err := pv.Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pv.SetLoadError(fmt.Errorf("connection reset"))
+
+	text := drawText(t, pv)
+	if !strings.Contains(text, "Refresh failed at") || !strings.Contains(text, "connection reset") {
+		t.Errorf("expected an error banner, got %q", text)
+	}
+	if !strings.Contains(text, "tank") {
+		t.Error("expected the previously loaded pools to stay visible")
+	}
+
+	pv.SetLoadError(nil)
+	if pv.LoadError() != nil || strings.Contains(drawText(t, pv), "Refresh failed") {
+		t.Error("expected a successful load to clear the banner")
+	}
+}
+
 func TestPoolsView_HandleEvent(t *testing.T) {
 	mock := &truenas.MockDatasetService{
 		ListPoolsFunc: func(ctx context.Context) ([]truenas.Pool, error) {
@@ -216,3 +253,526 @@ func TestPoolsView_HandleEvent(t *testing.T) {
 	}
 	_ = cmd
 }
+
+func threePools() *truenas.MockDatasetService {
+	return &truenas.MockDatasetService{
+		ListPoolsFunc: func(ctx context.Context) ([]truenas.Pool, error) {
+			return []truenas.Pool{
+				{ID: 1, Name: "tank", Status: "ONLINE", Size: 300, Free: 10},
+				{ID: 2, Name: "backup", Status: "ONLINE", Size: 100, Free: 90},
+				{ID: 3, Name: "scratch", Status: "DEGRADED", Size: 200, Free: 50},
+			}, nil
+		},
+	}
+}
+
+func TestPoolsView_Filter(t *testing.T) {
+	pv := newPoolsView(threePools())
+	_ = pv.Load(context.Background())
+
+	sendKey(t, pv, vaxis.Key{Keycode: '/', Text: "/"})
+	if !pv.CapturingInput() {
+		t.Fatal("expected filter prompt to capture input")
+	}
+	typeString(t, pv, "A")
+	// Case-insensitive substring: tank, backup, scratch all contain "a".
+	if pv.RowCount() != 3 {
+		t.Errorf("expected 3 rows, got %d", pv.RowCount())
+	}
+	typeString(t, pv, "n")
+	if pv.RowCount() != 1 || pv.SelectedPool().Name != "tank" {
+		t.Errorf("expected only tank, got %d rows", pv.RowCount())
+	}
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if pv.CapturingInput() {
+		t.Error("expected Enter to close the prompt")
+	}
+	if !strings.Contains(drawText(t, pv), "/An (1 of 3)") {
+		t.Error("expected header to show the filter")
+	}
+
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if pv.RowCount() != 3 {
+		t.Errorf("expected Esc to clear the filter, got %d rows", pv.RowCount())
+	}
+}
+
+func TestPoolsView_Filter_Glob(t *testing.T) {
+	pv := newPoolsView(threePools())
+	_ = pv.Load(context.Background())
+
+	sendKey(t, pv, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, pv, "*k")
+	if pv.RowCount() != 1 || pv.SelectedPool().Name != "tank" {
+		t.Errorf("expected glob to match only tank, got %d rows", pv.RowCount())
+	}
+}
+
+func TestPoolsView_Sort(t *testing.T) {
+	pv := newPoolsView(threePools())
+	_ = pv.Load(context.Background())
+
+	// names walks the list from the top and returns the pool names in order.
+	names := func() []string {
+		for i := 0; i < pv.RowCount(); i++ {
+			sendKey(t, pv, vaxis.Key{Keycode: 'k', Text: "k"})
+		}
+		var out []string
+		for i := 0; i < pv.RowCount(); i++ {
+			out = append(out, pv.SelectedPool().Name)
+			sendKey(t, pv, vaxis.Key{Keycode: 'j', Text: "j"})
+		}
+		return out
+	}
+
+	s := vaxis.Key{Keycode: 's', Text: "s"}
+	sendKey(t, pv, s) // NAME
+	if got := strings.Join(names(), ","); got != "backup,scratch,tank" {
+		t.Errorf("unexpected NAME order %s", got)
+	}
+	sendKey(t, pv, s) // STATUS
+	sendKey(t, pv, s) // SIZE
+	sendKey(t, pv, vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"})
+	if got := strings.Join(names(), ","); got != "tank,scratch,backup" {
+		t.Errorf("unexpected SIZE descending order %s", got)
+	}
+	if !strings.Contains(drawText(t, pv), "SIZE▼") {
+		t.Error("expected header to mark the sort column")
+	}
+
+	sendKey(t, pv, s) // ALLOC
+	sendKey(t, pv, s) // FREE
+	sendKey(t, pv, s) // off: API order
+	if got := strings.Join(names(), ","); got != "tank,backup,scratch" {
+		t.Errorf("expected API order after cycling sort off, got %s", got)
+	}
+}
+
+func scrubbingDetail() *internal.PoolDetail {
+	return &internal.PoolDetail{
+		Pool: truenas.Pool{ID: 1, Name: "tank", Status: "ONLINE"},
+		Topology: internal.PoolTopology{
+			Data: []internal.Vdev{{
+				Name: "raidz1-0", Type: "RAIDZ1", Status: "ONLINE",
+				Children: []internal.Vdev{
+					{Name: "uuid-a", Type: "DISK", Disk: "sda", Status: "ONLINE"},
+					{Name: "uuid-b", Type: "DISK", Disk: "sdb", Status: "ONLINE", ChecksumErrors: 4},
+				},
+			}},
+			Log: []internal.Vdev{{Name: "uuid-c", Type: "DISK", Disk: "nvme0n1", Status: "ONLINE"}},
+		},
+		Scan: &internal.PoolScan{
+			Function:       internal.ScanFunctionScrub,
+			State:          internal.ScanStateScanning,
+			Percentage:     63.2,
+			StartTime:      time.Date(2026, 10, 1, 3, 0, 0, 0, time.Local),
+			BytesToProcess: 2 << 40,
+			BytesProcessed: 1 << 40,
+			SecondsLeft:    4800,
+		},
+	}
+}
+
+func newPoolsViewWithDetail(get func(ctx context.Context, id int64) (*internal.PoolDetail, error)) (*views.PoolsView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 8)
+	pv := views.NewPoolsView(views.PoolsViewParams{
+		Service:   threePools(),
+		Pools:     &internal.MockPoolService{GetPoolFunc: get},
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	_ = pv.Load(context.Background())
+	return pv, events
+}
+
+func waitPoolDetail(t *testing.T, events chan vaxis.Event) views.PoolDetailLoaded {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev.(views.PoolDetailLoaded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for PoolDetailLoaded")
+	}
+	return views.PoolDetailLoaded{}
+}
+
+func TestPoolsView_Detail(t *testing.T) {
+	pv, events := newPoolsViewWithDetail(func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+		return scrubbingDetail(), nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !pv.DetailOpen() {
+		t.Fatal("expected Enter to open the detail pane")
+	}
+	defer pv.CloseDetail()
+	if !strings.Contains(drawText(t, pv), "Loading pool details...") {
+		t.Error("expected loading state before the first poll")
+	}
+
+	ev := waitPoolDetail(t, events)
+	if ev.PoolID != 1 {
+		t.Errorf("expected detail for pool 1, got %d", ev.PoolID)
+	}
+	pv.DetailLoaded(ev)
+
+	text := drawText(t, pv)
+	for _, want := range []string{
+		"raidz1-0", "sda", "sdb", "log", "nvme0n1",
+		"Scrub in progress since 2026-10-01 03:00",
+		"63.2%", "1h20m left",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected detail pane to contain %q", want)
+		}
+	}
+
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if pv.DetailOpen() {
+		t.Error("expected Esc to close the detail pane")
+	}
+}
+
+func TestPoolsView_Detail_FollowsCursor(t *testing.T) {
+	pv, events := newPoolsViewWithDetail(func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+		return &internal.PoolDetail{Pool: truenas.Pool{ID: id}}, nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	defer pv.CloseDetail()
+	first := waitPoolDetail(t, events)
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'j', Text: "j"})
+	second := waitPoolDetail(t, events)
+	if first.PoolID != 1 || second.PoolID != 2 {
+		t.Errorf("expected detail to follow cursor from 1 to 2, got %d then %d", first.PoolID, second.PoolID)
+	}
+
+	// A late result for the previous pool is ignored.
+	pv.DetailLoaded(first)
+	if pv.Detail() != nil {
+		t.Error("expected stale result to be dropped")
+	}
+	pv.DetailLoaded(second)
+	if pv.Detail() == nil || pv.Detail().ID != 2 {
+		t.Error("expected detail for pool 2")
+	}
+}
+
+func TestPoolsView_Mouse(t *testing.T) {
+	pv, events := newPoolsViewWithDetail(func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+		return &internal.PoolDetail{Pool: truenas.Pool{ID: id}}, nil
+	})
+	defer pv.CloseDetail()
+
+	// The header is row 0, so the second pool is on row 2.
+	click(t, pv, 10, 2)
+	if p := pv.SelectedPool(); p == nil || p.ID != 2 {
+		t.Fatalf("expected a click to select pool 2, got %v", p)
+	}
+	if pv.DetailOpen() {
+		t.Fatal("expected a single click not to open the detail pane")
+	}
+
+	click(t, pv, 10, 2)
+	if !pv.DetailOpen() {
+		t.Fatal("expected a double click to open the detail pane")
+	}
+	if ev := waitPoolDetail(t, events); ev.PoolID != 2 {
+		t.Errorf("expected detail for pool 2, got %d", ev.PoolID)
+	}
+}
+
+func TestPoolsView_Detail_Error(t *testing.T) {
+	pv, events := newPoolsViewWithDetail(func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+		return nil, fmt.Errorf("permission denied")
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	defer pv.CloseDetail()
+	pv.DetailLoaded(waitPoolDetail(t, events))
+	if !strings.Contains(drawText(t, pv), "Error loading pool: permission denied") {
+		t.Error("expected error in the detail pane")
+	}
+}
+
+// newPoolsViewWithScrub returns a view whose pool service reports scan for
+// pool 1 and records scrub actions. Events are posted without blocking so
+// scan trackers never stall.
+func newPoolsViewWithScrub(scan *internal.PoolScan, scrub func(id int64, action internal.ScrubAction) error) (*views.PoolsView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 32)
+	detail := func(id int64) internal.PoolDetail {
+		d := internal.PoolDetail{Pool: truenas.Pool{ID: id}}
+		if id == 1 {
+			d.Scan = scan
+		}
+		return d
+	}
+	pv := views.NewPoolsView(views.PoolsViewParams{
+		Service: threePools(),
+		Pools: &internal.MockPoolService{
+			ListPoolsFunc: func(ctx context.Context) ([]internal.PoolDetail, error) {
+				return []internal.PoolDetail{detail(1), detail(2), detail(3)}, nil
+			},
+			GetPoolFunc: func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+				d := detail(id)
+				return &d, nil
+			},
+			ScrubFunc: func(ctx context.Context, id int64, action internal.ScrubAction) error {
+				return scrub(id, action)
+			},
+		},
+		StaleTTL: 30 * time.Second,
+		PostEvent: func(ev vaxis.Event) {
+			select {
+			case events <- ev:
+			default:
+			}
+		},
+	})
+	_ = pv.Load(context.Background())
+	return pv, events
+}
+
+// waitPoolAction waits for an ActionCompleted, skipping scan tracker polls.
+func waitPoolAction(t *testing.T, events chan vaxis.Event) views.ActionCompleted {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case ev := <-events:
+			if ac, ok := ev.(views.ActionCompleted); ok {
+				return ac
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for ActionCompleted")
+			return views.ActionCompleted{}
+		}
+	}
+}
+
+func TestPoolsView_Scrub_Start(t *testing.T) {
+	var gotID int64
+	var gotAction internal.ScrubAction
+	scan := &internal.PoolScan{Function: internal.ScanFunctionScrub, State: internal.ScanStateFinished}
+	pv, events := newPoolsViewWithScrub(scan, func(id int64, action internal.ScrubAction) error {
+		gotID, gotAction = id, action
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'x', Text: "x"})
+	if !pv.CapturingInput() {
+		t.Fatal("expected a confirmation dialog")
+	}
+	if !strings.Contains(drawText(t, pv), "Scrub pool tank?") {
+		t.Error("expected the dialog to name the pool")
+	}
+	sendKey(t, pv, vaxis.Key{Keycode: 'y', Text: "y"})
+	if !strings.Contains(pv.Status(), "Scrubbing tank...") {
+		t.Errorf("expected pending status, got %q", pv.Status())
+	}
+
+	ac := waitPoolAction(t, events)
+	pv.ActionDone(ac)
+	if gotID != 1 || gotAction != internal.ScrubStart {
+		t.Errorf("expected START on pool 1, got %s on %d", gotAction, gotID)
+	}
+	if pv.Status() != "Scrub of tank finished (0 errors)" {
+		t.Errorf("unexpected status %q", pv.Status())
+	}
+}
+
+// The START job also returns when the scrub is paused or cancelled from
+// elsewhere, so the status reports the scan as it is afterwards.
+func TestPoolsView_Scrub_Outcome(t *testing.T) {
+	tests := []struct {
+		name string
+		scan internal.PoolScan
+		key  vaxis.Key
+		want string
+	}{
+		{"finished", internal.PoolScan{State: internal.ScanStateFinished, Errors: 3}, vaxis.Key{Keycode: 'x', Text: "x"}, "Scrub of tank finished (3 errors)"},
+		{"one error", internal.PoolScan{State: internal.ScanStateFinished, Errors: 1}, vaxis.Key{Keycode: 'x', Text: "x"}, "Scrub of tank finished (1 error)"},
+		{"cancelled", internal.PoolScan{State: internal.ScanStateCanceled}, vaxis.Key{Keycode: 'x', Text: "x"}, "Scrub of tank cancelled"},
+		// Resumed, then paused again from the web UI.
+		{"paused", internal.PoolScan{State: internal.ScanStateScanning, Paused: true, Percentage: 41}, vaxis.Key{Keycode: 'p', Text: "p"}, "Scrub of tank paused at 41%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := tt.scan
+			scan.Function = internal.ScanFunctionScrub
+			pv, events := newPoolsViewWithScrub(&scan, func(id int64, action internal.ScrubAction) error { return nil })
+
+			sendKey(t, pv, tt.key)
+			if pv.CapturingInput() {
+				sendKey(t, pv, vaxis.Key{Keycode: 'y', Text: "y"})
+			}
+			pv.ActionDone(waitPoolAction(t, events))
+			if pv.Status() != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, pv.Status())
+			}
+		})
+	}
+}
+
+func TestPoolsView_Scrub_StartCancelled(t *testing.T) {
+	called := false
+	pv, _ := newPoolsViewWithScrub(nil, func(id int64, action internal.ScrubAction) error {
+		called = true
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'x', Text: "x"})
+	sendKey(t, pv, vaxis.Key{Keycode: 'n', Text: "n"})
+	if pv.CapturingInput() || called {
+		t.Error("expected n to close the dialog without scrubbing")
+	}
+}
+
+func TestPoolsView_Scrub_Failure(t *testing.T) {
+	pv, events := newPoolsViewWithScrub(nil, func(id int64, action internal.ScrubAction) error {
+		return fmt.Errorf("pool is busy")
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'x', Text: "x"})
+	sendKey(t, pv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	pv.ActionDone(waitPoolAction(t, events))
+	if pv.Status() != "Error: scrub tank: pool is busy" {
+		t.Errorf("unexpected status %q", pv.Status())
+	}
+	if !strings.Contains(drawText(t, pv), "Error: scrub tank: pool is busy") {
+		t.Error("expected the failure in the status line")
+	}
+}
+
+func TestPoolsView_Scrub_AlreadyRunning(t *testing.T) {
+	pv, _ := newPoolsViewWithScrub(scrubbingDetail().Scan, func(id int64, action internal.ScrubAction) error {
+		t.Error("unexpected scrub call")
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'x', Text: "x"})
+	if pv.CapturingInput() {
+		t.Error("expected no dialog while a scrub runs")
+	}
+	if !strings.Contains(pv.Status(), "already running") {
+		t.Errorf("unexpected status %q", pv.Status())
+	}
+}
+
+func TestPoolsView_Scrub_InlineProgress(t *testing.T) {
+	scan := scrubbingDetail().Scan
+	scan.Paused = true
+	pv, _ := newPoolsViewWithScrub(scan, func(id int64, action internal.ScrubAction) error { return nil })
+
+	text := drawText(t, pv)
+	for _, want := range []string{"scrub [██████░░░░]", "63% paused"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected row to contain %q", want)
+		}
+	}
+}
+
+func TestPoolsView_Scrub_PauseResume(t *testing.T) {
+	scan := scrubbingDetail().Scan
+	actions := make(chan internal.ScrubAction, 2)
+	pv, events := newPoolsViewWithScrub(scan, func(id int64, action internal.ScrubAction) error {
+		actions <- action
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'p', Text: "p"})
+	pv.ActionDone(waitPoolAction(t, events))
+	if got := <-actions; got != internal.ScrubPause {
+		t.Errorf("expected PAUSE, got %s", got)
+	}
+
+	paused := *scan
+	paused.Paused = true
+	pv.DetailLoaded(views.PoolDetailLoaded{PoolID: 1, Detail: &internal.PoolDetail{Pool: truenas.Pool{ID: 1}, Scan: &paused}})
+	sendKey(t, pv, vaxis.Key{Keycode: 'p', Text: "p"})
+	pv.ActionDone(waitPoolAction(t, events))
+	if got := <-actions; got != internal.ScrubStart {
+		t.Errorf("expected START to resume, got %s", got)
+	}
+}
+
+func TestPoolsView_Scrub_Cancel(t *testing.T) {
+	var gotAction internal.ScrubAction
+	pv, events := newPoolsViewWithScrub(scrubbingDetail().Scan, func(id int64, action internal.ScrubAction) error {
+		gotAction = action
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'X', ShiftedCode: 'X', Modifiers: vaxis.ModShift, Text: "X"})
+	if !strings.Contains(drawText(t, pv), "Cancel the scrub of tank at 63%?") {
+		t.Error("expected cancel confirmation")
+	}
+	sendKey(t, pv, vaxis.Key{Keycode: 'y', Text: "y"})
+	pv.ActionDone(waitPoolAction(t, events))
+	if gotAction != internal.ScrubStop {
+		t.Errorf("expected STOP, got %s", gotAction)
+	}
+	if pv.Status() != "Cancelled scrub of tank" {
+		t.Errorf("unexpected status %q", pv.Status())
+	}
+}
+
+func TestPoolsView_Scrub_NothingToCancel(t *testing.T) {
+	pv, _ := newPoolsViewWithScrub(nil, func(id int64, action internal.ScrubAction) error {
+		t.Error("unexpected scrub call")
+		return nil
+	})
+
+	sendKey(t, pv, vaxis.Key{Keycode: 'X', ShiftedCode: 'X', Modifiers: vaxis.ModShift, Text: "X"})
+	if pv.CapturingInput() || !strings.Contains(pv.Status(), "no scrub is running on tank") {
+		t.Errorf("expected an error without a dialog, got %q", pv.Status())
+	}
+}
+
+func TestPoolsView_StopPolling(t *testing.T) {
+	events := make(chan vaxis.Event, 8)
+	polling := make(chan int64, 4)
+	stopped := make(chan int64, 4)
+	pv := views.NewPoolsView(views.PoolsViewParams{
+		Service: threePools(),
+		Pools: &internal.MockPoolService{
+			ListPoolsFunc: func(ctx context.Context) ([]internal.PoolDetail, error) {
+				return []internal.PoolDetail{{Pool: truenas.Pool{ID: 1}, Scan: scrubbingDetail().Scan}}, nil
+			},
+			// Polls hang until they are cancelled, like calls on a dead
+			// connection.
+			GetPoolFunc: func(ctx context.Context, id int64) (*internal.PoolDetail, error) {
+				polling <- id
+				<-ctx.Done()
+				stopped <- id
+				return nil, ctx.Err()
+			},
+		},
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	_ = pv.Load(context.Background())
+	pv.OpenDetail()
+
+	for range 2 {
+		select {
+		case <-polling:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the scan tracker and the detail pane to poll")
+		}
+	}
+	pv.StopPolling()
+	for range 2 {
+		select {
+		case <-stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected StopPolling to cancel every poll")
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("expected no events after StopPolling, got %T", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,206 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+// scanStartPolls is how many polls a tracker waits for a newly started scrub
+// to show up as running before giving up.
+const scanStartPolls = 5
+
+// trackScan polls a pool every poolDetailScanInterval while a scrub or
+// resilver runs so the row and detail pane show live progress. Only one
+// tracker runs per pool. With awaitStart the tracker keeps polling for a few
+// rounds until the scan appears, since pool.scrub takes a moment to begin.
+// Trackers stop with StopPolling.
+func (pv *PoolsView) trackScan(id int64, awaitStart bool) {
+	if pv.poolSvc == nil {
+		return
+	}
+	pv.trackMu.Lock()
+	defer pv.trackMu.Unlock()
+	if pv.trackers[id] {
+		return
+	}
+	pv.trackers[id] = true
+
+	ctx := pv.pollCtx
+	go func() {
+		defer func() {
+			pv.trackMu.Lock()
+			delete(pv.trackers, id)
+			pv.trackMu.Unlock()
+		}()
+		waiting := 0
+		if awaitStart {
+			waiting = scanStartPolls
+		}
+		for {
+			detail, err := pv.poolSvc.GetPool(ctx, id)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil && detail != nil && pv.postEvent != nil {
+				pv.postEvent(PoolDetailLoaded{View: pv, PoolID: id, Detail: detail})
+			}
+			running := err == nil && detail != nil && detail.Scan.Running()
+			if !running {
+				if waiting == 0 {
+					return
+				}
+				waiting--
+			} else {
+				waiting = 0
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(poolDetailScanInterval):
+			}
+		}
+	}()
+}
+
+// scanOf returns the last known scan of the pool, or nil.
+func (pv *PoolsView) scanOf(id int64) *internal.PoolScan {
+	return pv.scans[id]
+}
+
+// scanCell renders the inline progress for a running scan, e.g.
+// "  scrub [████░░░░░░]  42%".
+func scanCell(scan *internal.PoolScan) []vaxis.Segment {
+	if !scan.Running() {
+		return nil
+	}
+	const width = 10
+	filled := min(int(scan.Percentage/100*width), width)
+	kind := strings.ToLower(scan.Function)
+	label := fmt.Sprintf("%4.0f%%", scan.Percentage)
+	if scan.Paused {
+		label += " paused"
+	}
+	return []vaxis.Segment{
+		{Text: "  " + kind + " ", Style: theme.Warning()},
+		{Text: "[" + strings.Repeat("█", filled), Style: theme.Accent()},
+		{Text: strings.Repeat("░", width-filled) + "]", Style: theme.Dim()},
+		{Text: label},
+	}
+}
+
+// startScrub confirms and starts a scrub on the selected pool. The job runs
+// until the scrub finishes, and its outcome is reported in the status line.
+func (pv *PoolsView) startScrub() (vxfw.Command, error) {
+	p := pv.SelectedPool()
+	if p == nil || pv.poolSvc == nil {
+		return nil, nil
+	}
+	if scan := pv.scanOf(p.ID); scan.Running() {
+		pv.act.setStatus(fmt.Sprintf("Error: a %s is already running on %s", strings.ToLower(scan.Function), p.Name), true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id, name := p.ID, p.Name
+	return pv.act.open(&widgets.Confirm{
+		Title: "Start scrub",
+		Lines: []string{"Scrub pool " + name + "?", "This reads every block and can take hours."},
+		OnConfirm: func() (vxfw.Command, error) {
+			pv.trackScan(id, true)
+			return pv.act.run(pv, "Scrubbing "+name+"...", func(ctx context.Context) (string, error) {
+				if err := pv.poolSvc.Scrub(ctx, id, internal.ScrubStart); err != nil {
+					return "", fmt.Errorf("scrub %s: %w", name, err)
+				}
+				return pv.scrubOutcome(ctx, id, name), nil
+			})
+		},
+		OnCancel: pv.act.close,
+	})
+}
+
+// scrubOutcome describes how a scrub ended once its START job returns. The
+// job also returns when the scrub is paused or cancelled from another
+// client, so the pool's scan is read again rather than assuming it
+// finished.
+func (pv *PoolsView) scrubOutcome(ctx context.Context, id int64, name string) string {
+	detail, err := pv.poolSvc.GetPool(ctx, id)
+	if err != nil || detail == nil || detail.Scan == nil {
+		return "Scrub of " + name + " ended"
+	}
+	scan := detail.Scan
+	switch {
+	case scan.Running() && scan.Paused:
+		return fmt.Sprintf("Scrub of %s paused at %.0f%%", name, scan.Percentage)
+	case scan.Running():
+		return "Scrub of " + name + " is still running"
+	case scan.State == internal.ScanStateCanceled:
+		return "Scrub of " + name + " cancelled"
+	case scan.Errors == 1:
+		return "Scrub of " + name + " finished (1 error)"
+	default:
+		return fmt.Sprintf("Scrub of %s finished (%d errors)", name, scan.Errors)
+	}
+}
+
+// togglePauseScrub pauses a running scrub on the selected pool, or resumes
+// a paused one.
+func (pv *PoolsView) togglePauseScrub() (vxfw.Command, error) {
+	p := pv.SelectedPool()
+	if p == nil || pv.poolSvc == nil {
+		return nil, nil
+	}
+	scan := pv.scanOf(p.ID)
+	if !scan.Running() || scan.Function != internal.ScanFunctionScrub {
+		pv.act.setStatus("Error: no scrub is running on "+p.Name, true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id, name := p.ID, p.Name
+	if scan.Paused {
+		pv.trackScan(id, true)
+		return pv.act.run(pv, "Resuming scrub of "+name+"...", func(ctx context.Context) (string, error) {
+			if err := pv.poolSvc.Scrub(ctx, id, internal.ScrubStart); err != nil {
+				return "", fmt.Errorf("resume scrub of %s: %w", name, err)
+			}
+			return pv.scrubOutcome(ctx, id, name), nil
+		})
+	}
+	return pv.act.run(pv, "Pausing scrub of "+name+"...", func(ctx context.Context) (string, error) {
+		if err := pv.poolSvc.Scrub(ctx, id, internal.ScrubPause); err != nil {
+			return "", fmt.Errorf("pause scrub of %s: %w", name, err)
+		}
+		return "Paused scrub of " + name, nil
+	})
+}
+
+// cancelScrub confirms and stops the scrub running on the selected pool.
+func (pv *PoolsView) cancelScrub() (vxfw.Command, error) {
+	p := pv.SelectedPool()
+	if p == nil || pv.poolSvc == nil {
+		return nil, nil
+	}
+	scan := pv.scanOf(p.ID)
+	if !scan.Running() || scan.Function != internal.ScanFunctionScrub {
+		pv.act.setStatus("Error: no scrub is running on "+p.Name, true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id, name := p.ID, p.Name
+	return pv.act.open(&widgets.Confirm{
+		Title: "Cancel scrub",
+		Lines: []string{fmt.Sprintf("Cancel the scrub of %s at %.0f%%?", name, scan.Percentage), "Progress is lost; a new scrub starts from the beginning."},
+		OnConfirm: func() (vxfw.Command, error) {
+			return pv.act.run(pv, "Cancelling scrub of "+name+"...", func(ctx context.Context) (string, error) {
+				if err := pv.poolSvc.Scrub(ctx, id, internal.ScrubStop); err != nil {
+					return "", fmt.Errorf("cancel scrub of %s: %w", name, err)
+				}
+				return "Cancelled scrub of " + name, nil
+			})
+		},
+		OnCancel: pv.act.close,
+	})
+}
@@ -0,0 +1,181 @@
+package views
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+)
+
+// listQuery holds the filter text and sort order for a list view. Views keep
+// the full slice from Load and rebuild their visible rows from it whenever the
+// query changes, so filtering never goes back to the server.
+//
+//	/        start typing a filter (Enter keeps it, Esc clears it)
+//	s        sort by the next column (off → first → ... → last → off)
+//	S        reverse the sort order
+//	Esc      clear the filter
+type listQuery struct {
+	columns []string // sortable column names, as shown in the header
+	filter  string
+	editing bool
+	sortCol int // index into columns, -1 for the default order
+	desc    bool
+}
+
+func newListQuery(columns ...string) listQuery {
+	return listQuery{columns: columns, sortCol: -1}
+}
+
+// handleKey applies key to the query. handled reports whether the key was
+// consumed; changed reports whether the visible rows need rebuilding.
+func (q *listQuery) handleKey(key vaxis.Key) (handled, changed bool) {
+	if q.editing {
+		prev := q.filter
+		switch {
+		case key.Matches(vaxis.KeyEsc):
+			q.filter = ""
+			q.editing = false
+		case key.Matches(vaxis.KeyEnter):
+			q.editing = false
+		case key.Matches(vaxis.KeyBackspace):
+			if q.filter != "" {
+				_, size := utf8.DecodeLastRuneInString(q.filter)
+				q.filter = q.filter[:len(q.filter)-size]
+			}
+		case key.Matches('u', vaxis.ModCtrl):
+			q.filter = ""
+		case key.Text != "":
+			q.filter += key.Text
+		}
+		return true, q.filter != prev
+	}
+
+	switch {
+	case keys.Filter.Matches(key):
+		q.editing = true
+		return true, false
+	case keys.ReverseSort.Matches(key):
+		return true, q.reverse()
+	case keys.Sort.Matches(key):
+		if len(q.columns) == 0 {
+			return false, false
+		}
+		q.nextSort()
+		return true, true
+	case keys.ClearFilter.Matches(key) && q.filter != "":
+		q.filter = ""
+		return true, true
+	}
+	return false, false
+}
+
+// nextSort sorts by the next column, or back to the default order after the
+// last one.
+func (q *listQuery) nextSort() {
+	q.sortCol++
+	if q.sortCol >= len(q.columns) {
+		q.sortCol = -1
+	}
+	q.desc = false
+}
+
+// reverse flips the sort order. It reports false if the list is in its
+// default order, which can't be reversed.
+func (q *listQuery) reverse() bool {
+	if q.sortCol < 0 {
+		return false
+	}
+	q.desc = !q.desc
+	return true
+}
+
+// commands returns the palette commands for filtering and sorting the list.
+// apply rebuilds the visible rows after the query changes.
+func (q *listQuery) commands(apply func()) []PaletteCommand {
+	run := func(fn func()) func() (vxfw.Command, error) {
+		return func() (vxfw.Command, error) {
+			fn()
+			apply()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+	}
+	next := "default order"
+	if q.sortCol+1 < len(q.columns) {
+		next = q.columns[q.sortCol+1]
+	}
+	return []PaletteCommand{
+		{Name: "Filter", Key: keys.Filter.Short(), Run: run(func() { q.editing = true })},
+		{Name: "Clear filter", Key: keys.ClearFilter.Short(), Disabled: q.filter == "", Run: run(func() { q.filter = "" })},
+		{Name: "Sort by " + strings.ToLower(next), Key: keys.Sort.Short(), Disabled: len(q.columns) == 0, Run: run(q.nextSort)},
+		{Name: "Reverse sort order", Key: keys.ReverseSort.Short(), Disabled: q.sortCol < 0, Run: run(func() { q.reverse() })},
+	}
+}
+
+// sortedBy reports whether the list is sorted by the named column.
+func (q *listQuery) sortedBy(column string) bool {
+	return q.sortCol >= 0 && q.columns[q.sortCol] == column
+}
+
+// column returns the header label for a column, with an arrow when the list
+// is sorted by it.
+func (q *listQuery) column(name string) string {
+	if !q.sortedBy(name) {
+		return name
+	}
+	if q.desc {
+		return name + "▼"
+	}
+	return name + "▲"
+}
+
+// ordered turns a three-way comparison of two rows into a less result for
+// the current sort direction.
+func (q *listQuery) ordered(c int) bool {
+	if q.desc {
+		return c > 0
+	}
+	return c < 0
+}
+
+// summary describes the active filter for the header row, e.g.
+// "  /tank* (12 of 340)". It is empty when no filter is set.
+func (q *listQuery) summary(shown, total int) string {
+	if q.editing {
+		return fmt.Sprintf("  /%s█ (%d of %d)", q.filter, shown, total)
+	}
+	if q.filter == "" {
+		return ""
+	}
+	return fmt.Sprintf("  /%s (%d of %d)", q.filter, shown, total)
+}
+
+// matches reports whether any of the fields match the filter.
+func (q *listQuery) matches(fields ...string) bool {
+	if q.filter == "" {
+		return true
+	}
+	for _, f := range fields {
+		if matchFilter(q.filter, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFilter matches s against pattern case-insensitively. Patterns
+// containing glob metacharacters must match the whole string (as with
+// path.Match, * does not cross a /); anything else is a substring match.
+func matchFilter(pattern, s string) bool {
+	pattern = strings.ToLower(pattern)
+	s = strings.ToLower(s)
+	if strings.ContainsAny(pattern, "*?[") {
+		ok, err := path.Match(pattern, s)
+		return err == nil && ok
+	}
+	return strings.Contains(s, pattern)
+}
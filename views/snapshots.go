@@ -1,8 +1,13 @@
 package views
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
@@ -10,23 +15,37 @@ import (
 	"git.sr.ht/~rockorager/vaxis/vxfw/list"
 	"git.sr.ht/~rockorager/vaxis/vxfw/richtext"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+	"github.com/deevus/truenas-tui/widgets"
 	"github.com/dustin/go-humanize"
 )
 
 // SnapshotsViewParams holds configuration for creating a SnapshotsView.
 type SnapshotsViewParams struct {
-	Service  truenas.SnapshotServiceAPI
-	StaleTTL time.Duration
+	Service   internal.SnapshotServiceAPI
+	StaleTTL  time.Duration
+	PostEvent func(vaxis.Event)
+	// Datasets returns the datasets loaded by DatasetsView, used to validate
+	// clone targets.
+	Datasets func() []truenas.Dataset
 }
 
 // SnapshotsView displays a list of TrueNAS ZFS snapshots.
 type SnapshotsView struct {
-	service   truenas.SnapshotServiceAPI
+	service   internal.SnapshotServiceAPI
 	snapshots []truenas.Snapshot
+	rows      []*truenas.Snapshot // snapshots after filtering and sorting
+	query     listQuery
 	list      list.Dynamic
 	loaded    bool
 	loadedAt  time.Time
 	staleTTL  time.Duration
+	datasets  func() []truenas.Dataset
+	act       actions
+
+	loadError
 }
 
 // NewSnapshotsView creates a SnapshotsView backed by the given params.
@@ -34,9 +53,13 @@ func NewSnapshotsView(p SnapshotsViewParams) *SnapshotsView {
 	sv := &SnapshotsView{
 		service:  p.Service,
 		staleTTL: p.StaleTTL,
+		datasets: p.Datasets,
+		query:    newListQuery("DATASET", "SNAPSHOT", "USED", "REFER"),
+		act:      actions{postEvent: p.PostEvent},
 	}
 	sv.list.DrawCursor = true
 	sv.list.Builder = sv.buildItem
+	clickableRows(&sv.list, nil)
 	return sv
 }
 
@@ -47,6 +70,7 @@ func (sv *SnapshotsView) Load(ctx context.Context) error {
 		return err
 	}
 	sv.snapshots = snapshots
+	sv.applyQuery()
 	sv.loaded = true
 	sv.loadedAt = time.Now()
 	return nil
@@ -78,17 +102,66 @@ func (sv *SnapshotsView) ItemCount() int {
 // SelectedSnapshot returns the currently selected snapshot, or nil if empty.
 func (sv *SnapshotsView) SelectedSnapshot() *truenas.Snapshot {
 	idx := int(sv.list.Cursor())
-	if idx >= len(sv.snapshots) {
+	if idx >= len(sv.rows) {
 		return nil
 	}
-	return &sv.snapshots[idx]
+	return sv.rows[idx]
+}
+
+// RowCount returns the number of snapshots shown after filtering.
+func (sv *SnapshotsView) RowCount() int {
+	return len(sv.rows)
+}
+
+// applyQuery rebuilds the visible rows from the loaded snapshots, keeping
+// the selected snapshot under the cursor when it is still shown.
+func (sv *SnapshotsView) applyQuery() {
+	var selected string
+	if snap := sv.SelectedSnapshot(); snap != nil {
+		selected = snap.ID
+	}
+
+	rows := make([]*truenas.Snapshot, 0, len(sv.snapshots))
+	for i := range sv.snapshots {
+		snap := &sv.snapshots[i]
+		if sv.query.matches(snap.ID, snap.Dataset, snap.SnapshotName) {
+			rows = append(rows, snap)
+		}
+	}
+	if sv.query.sortCol >= 0 {
+		col := sv.query.columns[sv.query.sortCol]
+		sort.SliceStable(rows, func(i, j int) bool {
+			a, b := rows[i], rows[j]
+			var c int
+			switch col {
+			case "DATASET":
+				c = strings.Compare(a.Dataset, b.Dataset)
+			case "SNAPSHOT":
+				c = strings.Compare(a.SnapshotName, b.SnapshotName)
+			case "USED":
+				c = cmp.Compare(a.Used, b.Used)
+			case "REFER":
+				c = cmp.Compare(a.Referenced, b.Referenced)
+			}
+			return sv.query.ordered(c)
+		})
+	}
+	sv.rows = rows
+
+	sv.list.SetCursor(0)
+	for i, snap := range rows {
+		if snap.ID == selected {
+			sv.list.SetCursor(uint(i))
+			break
+		}
+	}
 }
 
 func (sv *SnapshotsView) buildItem(i uint, cursor uint) vxfw.Widget {
-	if int(i) >= len(sv.snapshots) {
+	if int(i) >= len(sv.rows) {
 		return nil
 	}
-	snap := sv.snapshots[i]
+	snap := sv.rows[i]
 
 	hold := " "
 	if snap.HasHold {
@@ -104,17 +177,24 @@ func (sv *SnapshotsView) buildItem(i uint, cursor uint) vxfw.Widget {
 	})
 }
 
-// Draw renders the snapshots list, or a loading state if data hasn't arrived.
+// Draw renders the snapshots list, or a loading or error state if data
+// hasn't arrived.
 func (sv *SnapshotsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	if !sv.loaded {
-		return drawLoadingState(ctx, sv)
+		return drawLoadingState(ctx, sv, &sv.loadError)
 	}
+	return drawErrorBanner(ctx, sv, &sv.loadError, sv.draw)
+}
 
+func (sv *SnapshotsView) draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	s := vxfw.NewSurface(ctx.Max.Width, ctx.Max.Height, sv)
 
+	q := &sv.query
 	header := richtext.New([]vaxis.Segment{
-		{Text: fmt.Sprintf("  %-30s%-25s%10s%10s", "DATASET", "SNAPSHOT", "USED", "REFER"),
+		{Text: fmt.Sprintf("  %-30s%-25s%10s%10s",
+			q.column("DATASET"), q.column("SNAPSHOT"), q.column("USED"), q.column("REFER")),
 			Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: q.summary(len(sv.rows), len(sv.snapshots)), Style: theme.Dim()},
 	})
 	headerSurf, err := header.Draw(ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: 1}))
 	if err != nil {
@@ -122,17 +202,335 @@ func (sv *SnapshotsView) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	}
 	s.AddChild(0, 0, headerSurf)
 
-	listCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: ctx.Max.Height - 1})
+	listHeight := ctx.Max.Height - 1 - min(sv.act.statusHeight(), ctx.Max.Height-1)
+	listCtx := ctx.WithMax(vxfw.Size{Width: ctx.Max.Width, Height: listHeight})
 	listSurf, err := sv.list.Draw(listCtx)
 	if err != nil {
 		return vxfw.Surface{}, err
 	}
 	s.AddChild(0, 1, listSurf)
 
+	if err := sv.act.draw(ctx, &s); err != nil {
+		return vxfw.Surface{}, err
+	}
 	return s, nil
 }
 
-// HandleEvent delegates to the list widget for navigation.
+// CapturingInput reports whether a dialog or the filter prompt is open and
+// needs every key.
+func (sv *SnapshotsView) CapturingInput() bool {
+	return sv.act.modal != nil || sv.query.editing
+}
+
+// Status returns the status line text from the last action.
+func (sv *SnapshotsView) Status() string {
+	return sv.act.status
+}
+
+// ActionDone records the outcome of a finished snapshot action.
+func (sv *SnapshotsView) ActionDone(ev ActionCompleted) {
+	sv.act.done(ev)
+}
+
+// KeyScopes returns the bindings of the Snapshots tab for the help.
+func (sv *SnapshotsView) KeyScopes() []*keys.Scope {
+	return []*keys.Scope{keys.SnapshotsScope, keys.QueryScope, keys.ListScope}
+}
+
+// Commands returns the palette commands for the Snapshots tab.
+func (sv *SnapshotsView) Commands() []PaletteCommand {
+	snap := sv.SelectedSnapshot()
+	hold := "Place hold"
+	if snap != nil && snap.HasHold {
+		hold = "Release hold"
+	}
+	return append([]PaletteCommand{
+		{Name: "Create snapshot", Key: keys.CreateSnapshot.Short(), Run: sv.openCreate},
+		{Name: "Destroy snapshot", Key: keys.DestroySnapshot.Short(), Disabled: snap == nil, Run: sv.openDestroy},
+		{Name: hold, Key: keys.HoldSnapshot.Short(), Disabled: snap == nil, Run: sv.toggleHold},
+		{Name: "Roll back to snapshot", Key: keys.Rollback.Short(), Disabled: snap == nil, Run: sv.openRollback},
+		{Name: "Clone snapshot", Key: keys.CloneSnapshot.Short(), Disabled: snap == nil, Run: sv.openClone},
+	}, sv.query.commands(sv.applyQuery)...)
+}
+
+// HandleEvent routes input to an open dialog, handles the filter, sort and
+// snapshot action keys and otherwise delegates to the list widget for
+// navigation.
 func (sv *SnapshotsView) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
-	return sv.list.HandleEvent(ev, phase)
+	if cmd, ok, err := sv.act.handleModal(ev, phase); ok {
+		return cmd, err
+	}
+	if key, ok := ev.(vaxis.Key); ok && sv.loaded {
+		if handled, changed := sv.query.handleKey(key); handled {
+			if changed {
+				sv.applyQuery()
+			}
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		switch {
+		case keys.CreateSnapshot.Matches(key):
+			return sv.openCreate()
+		case keys.DestroySnapshot.Matches(key):
+			return sv.openDestroy()
+		case keys.HoldSnapshot.Matches(key):
+			return sv.toggleHold()
+		case keys.Rollback.Matches(key):
+			return sv.openRollback()
+		case keys.CloneSnapshot.Matches(key):
+			return sv.openClone()
+		}
+	}
+	return handleListEvent(&sv.list, ev, phase)
+}
+
+// openCreate shows the create snapshot form, prefilled with the selected
+// snapshot's dataset and a timestamped name.
+func (sv *SnapshotsView) openCreate() (vxfw.Command, error) {
+	dataset := ""
+	if snap := sv.SelectedSnapshot(); snap != nil {
+		dataset = snap.Dataset
+	}
+	form := &widgets.Form{
+		Title: "Create snapshot",
+		Fields: []*widgets.FormField{
+			widgets.NewTextField("Dataset", dataset),
+			widgets.NewTextField("Name", "manual-"+time.Now().Format("2006-01-02_15-04-05")),
+			widgets.NewCheckbox("Recursive", false),
+		},
+		OnCancel: sv.act.close,
+	}
+	form.OnSubmit = func(f *widgets.Form) (vxfw.Command, error) {
+		opts := truenas.CreateSnapshotOpts{
+			Dataset:   strings.TrimSpace(f.Field("Dataset").Value),
+			Name:      strings.TrimSpace(f.Field("Name").Value),
+			Recursive: f.Field("Recursive").Checked,
+		}
+		if err := validateSnapshotOpts(opts); err != nil {
+			f.Error = err.Error()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		id := opts.Dataset + "@" + opts.Name
+		return sv.act.run(sv, "Creating "+id+"...", func(ctx context.Context) (string, error) {
+			if _, err := sv.service.Create(ctx, opts); err != nil {
+				return "", fmt.Errorf("create %s: %w", id, err)
+			}
+			return "Created " + id, nil
+		})
+	}
+	return sv.act.open(form)
+}
+
+// validateSnapshotOpts checks the create form before it is sent to the server.
+func validateSnapshotOpts(opts truenas.CreateSnapshotOpts) error {
+	switch {
+	case opts.Dataset == "":
+		return errors.New("dataset is required")
+	case opts.Name == "":
+		return errors.New("name is required")
+	case strings.ContainsAny(opts.Name, "@/ "):
+		return errors.New("name must not contain '@', '/' or spaces")
+	}
+	return nil
+}
+
+// openDestroy asks for confirmation before destroying the selected snapshot.
+func (sv *SnapshotsView) openDestroy() (vxfw.Command, error) {
+	snap := sv.SelectedSnapshot()
+	if snap == nil {
+		return nil, nil
+	}
+	if snap.HasHold {
+		sv.act.setStatus("Error: "+snap.ID+" is held; release it"+withKey(keys.HoldSnapshot)+" first", true)
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+	id := snap.ID
+	return sv.act.open(&widgets.Confirm{
+		Title: "Destroy snapshot",
+		Lines: []string{id, "This cannot be undone."},
+		OnConfirm: func() (vxfw.Command, error) {
+			return sv.act.run(sv, "Destroying "+id+"...", func(ctx context.Context) (string, error) {
+				if err := sv.service.Delete(ctx, id); err != nil {
+					return "", fmt.Errorf("destroy %s: %w", id, err)
+				}
+				return "Destroyed " + id, nil
+			})
+		},
+		OnCancel: sv.act.close,
+	})
+}
+
+// toggleHold places or releases a hold on the selected snapshot.
+func (sv *SnapshotsView) toggleHold() (vxfw.Command, error) {
+	snap := sv.SelectedSnapshot()
+	if snap == nil {
+		return nil, nil
+	}
+	id := snap.ID
+	if snap.HasHold {
+		return sv.act.run(sv, "Releasing "+id+"...", func(ctx context.Context) (string, error) {
+			if err := sv.service.Release(ctx, id); err != nil {
+				return "", fmt.Errorf("release %s: %w", id, err)
+			}
+			return "Released hold on " + id, nil
+		})
+	}
+	return sv.act.run(sv, "Holding "+id+"...", func(ctx context.Context) (string, error) {
+		if err := sv.service.Hold(ctx, id); err != nil {
+			return "", fmt.Errorf("hold %s: %w", id, err)
+		}
+		return "Placed hold on " + id, nil
+	})
+}
+
+// maxRollbackListed caps how many doomed snapshots the rollback dialog lists.
+const maxRollbackListed = 8
+
+// newerSnapshots returns the loaded snapshots of the same dataset that were
+// taken after snap, oldest first. Rolling back to snap destroys them.
+func newerSnapshots(all []truenas.Snapshot, snap truenas.Snapshot) []truenas.Snapshot {
+	txg, err := strconv.ParseUint(snap.CreateTXG, 10, 64)
+	if err != nil {
+		return nil
+	}
+	var newer []truenas.Snapshot
+	for _, other := range all {
+		if other.Dataset != snap.Dataset || other.ID == snap.ID {
+			continue
+		}
+		if t, err := strconv.ParseUint(other.CreateTXG, 10, 64); err == nil && t > txg {
+			newer = append(newer, other)
+		}
+	}
+	sort.Slice(newer, func(i, j int) bool {
+		ti, _ := strconv.ParseUint(newer[i].CreateTXG, 10, 64)
+		tj, _ := strconv.ParseUint(newer[j].CreateTXG, 10, 64)
+		return ti < tj
+	})
+	return newer
+}
+
+// openRollback shows what a rollback to the selected snapshot would destroy
+// and asks for the dataset name before proceeding.
+func (sv *SnapshotsView) openRollback() (vxfw.Command, error) {
+	snap := sv.SelectedSnapshot()
+	if snap == nil {
+		return nil, nil
+	}
+	id, dataset := snap.ID, snap.Dataset
+	newer := newerSnapshots(sv.snapshots, *snap)
+
+	lines := []string{"Roll back " + dataset + " to " + snap.SnapshotName + "."}
+	var held []string
+	if len(newer) == 0 {
+		lines = append(lines, "No newer snapshots will be destroyed.")
+	} else {
+		lines = append(lines, fmt.Sprintf("This destroys %d newer snapshot(s):", len(newer)))
+		for i, n := range newer {
+			if n.HasHold {
+				held = append(held, n.SnapshotName)
+			}
+			if i < maxRollbackListed {
+				lines = append(lines, "  "+n.SnapshotName)
+			}
+		}
+		if extra := len(newer) - maxRollbackListed; extra > 0 {
+			lines = append(lines, fmt.Sprintf("  ... and %d more", extra))
+		}
+	}
+	lines = append(lines, "Changes made since the snapshot will be lost.")
+
+	form := &widgets.Form{
+		Title:    "Roll back dataset",
+		Lines:    lines,
+		Fields:   []*widgets.FormField{widgets.NewTextField("Type dataset name to confirm", "")},
+		OnCancel: sv.act.close,
+	}
+	form.OnSubmit = func(f *widgets.Form) (vxfw.Command, error) {
+		if strings.TrimSpace(f.Fields[0].Value) != dataset {
+			f.Error = "dataset name does not match " + dataset
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		if len(held) > 0 {
+			f.Error = "release holds first: " + strings.Join(held, ", ")
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		opts := internal.RollbackSnapshotOpts{DestroyNewer: len(newer) > 0}
+		return sv.act.run(sv, "Rolling back to "+id+"...", func(ctx context.Context) (string, error) {
+			if err := sv.service.Rollback(ctx, id, opts); err != nil {
+				return "", fmt.Errorf("rollback to %s: %w", id, err)
+			}
+			return "Rolled back " + dataset + " to " + id, nil
+		})
+	}
+	return sv.act.open(form)
+}
+
+// openClone prompts for the dataset to clone the selected snapshot into.
+func (sv *SnapshotsView) openClone() (vxfw.Command, error) {
+	snap := sv.SelectedSnapshot()
+	if snap == nil {
+		return nil, nil
+	}
+	id, dataset := snap.ID, snap.Dataset
+	form := &widgets.Form{
+		Title:    "Clone snapshot",
+		Lines:    []string{id},
+		Fields:   []*widgets.FormField{widgets.NewTextField("Target dataset", dataset+"-clone")},
+		OnCancel: sv.act.close,
+	}
+	form.OnSubmit = func(f *widgets.Form) (vxfw.Command, error) {
+		target := strings.TrimSpace(f.Fields[0].Value)
+		var loaded []truenas.Dataset
+		if sv.datasets != nil {
+			loaded = sv.datasets()
+		}
+		if err := validateCloneTarget(target, dataset, loaded); err != nil {
+			f.Error = err.Error()
+			return vxfw.ConsumeAndRedraw(), nil
+		}
+		return sv.act.run(sv, "Cloning "+id+" to "+target+"...", func(ctx context.Context) (string, error) {
+			if err := sv.service.Clone(ctx, id, target); err != nil {
+				return "", fmt.Errorf("clone %s: %w", id, err)
+			}
+			return "Cloned " + id + " to " + target, nil
+		})
+	}
+	return sv.act.open(form)
+}
+
+// validateCloneTarget checks a clone target path against the loaded
+// datasets: it must be new, live in the source's pool and have an existing
+// parent.
+func validateCloneTarget(target, source string, datasets []truenas.Dataset) error {
+	if target == "" {
+		return errors.New("target dataset is required")
+	}
+	if strings.ContainsAny(target, "@ ") || strings.HasPrefix(target, "/") || strings.HasSuffix(target, "/") {
+		return errors.New("target must be a dataset path like pool/name")
+	}
+	if len(datasets) == 0 {
+		return errors.New("datasets not loaded yet; try again shortly")
+	}
+	pool, _, _ := strings.Cut(source, "/")
+	if tpool, _, _ := strings.Cut(target, "/"); tpool != pool {
+		return fmt.Errorf("target must be in pool %s", pool)
+	}
+	i := strings.LastIndex(target, "/")
+	if i < 0 {
+		return errors.New("target must be a child dataset, not a pool")
+	}
+	parent := target[:i]
+	var parentFound bool
+	for _, ds := range datasets {
+		if ds.ID == target {
+			return fmt.Errorf("%s already exists", target)
+		}
+		if ds.ID == parent {
+			parentFound = true
+		}
+	}
+	if !parentFound {
+		return fmt.Errorf("parent dataset %s does not exist", parent)
+	}
+	return nil
 }
@@ -2,16 +2,20 @@ package views_test
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 	"github.com/deevus/truenas-go"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
 	"github.com/deevus/truenas-tui/views"
 )
 
-func newSnapshotsView(mock *truenas.MockSnapshotService) *views.SnapshotsView {
+func newSnapshotsView(mock *internal.MockSnapshotService) *views.SnapshotsView {
 	return views.NewSnapshotsView(views.SnapshotsViewParams{
 		Service:  mock,
 		StaleTTL: 30 * time.Second,
@@ -19,7 +23,7 @@ func newSnapshotsView(mock *truenas.MockSnapshotService) *views.SnapshotsView {
 }
 
 func TestSnapshotsView_Load(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{
 				{ID: "tank/data@auto-2024-01-01", Dataset: "tank/data", SnapshotName: "auto-2024-01-01", Used: 1024, Referenced: 1073741824},
@@ -41,7 +45,7 @@ func TestSnapshotsView_Load(t *testing.T) {
 }
 
 func TestSnapshotsView_Load_Error(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return nil, context.DeadlineExceeded
 		},
@@ -55,7 +59,7 @@ func TestSnapshotsView_Load_Error(t *testing.T) {
 }
 
 func TestSnapshotsView_ItemCount(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{
 				{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"},
@@ -74,7 +78,7 @@ func TestSnapshotsView_ItemCount(t *testing.T) {
 }
 
 func TestSnapshotsView_Loaded(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{}, nil
 		},
@@ -92,7 +96,7 @@ func TestSnapshotsView_Loaded(t *testing.T) {
 }
 
 func TestSnapshotsView_Stale(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{}, nil
 		},
@@ -110,7 +114,7 @@ func TestSnapshotsView_Stale(t *testing.T) {
 }
 
 func TestSnapshotsView_SelectedSnapshot(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{
 				{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"},
@@ -132,7 +136,7 @@ func TestSnapshotsView_SelectedSnapshot(t *testing.T) {
 }
 
 func TestSnapshotsView_SelectedSnapshot_Empty(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{}, nil
 		},
@@ -148,7 +152,7 @@ func TestSnapshotsView_SelectedSnapshot_Empty(t *testing.T) {
 }
 
 func TestSnapshotsView_SelectedSnapshot_BeforeLoad(t *testing.T) {
-	mock := &truenas.MockSnapshotService{}
+	mock := &internal.MockSnapshotService{}
 	sv := newSnapshotsView(mock)
 
 	snap := sv.SelectedSnapshot()
@@ -158,7 +162,7 @@ func TestSnapshotsView_SelectedSnapshot_BeforeLoad(t *testing.T) {
 }
 
 func TestSnapshotsView_Draw_Loading(t *testing.T) {
-	mock := &truenas.MockSnapshotService{}
+	mock := &internal.MockSnapshotService{}
 	sv := newSnapshotsView(mock)
 
 	ctx := testDrawContext(80, 10)
@@ -172,7 +176,7 @@ func TestSnapshotsView_Draw_Loading(t *testing.T) {
 }
 
 func TestSnapshotsView_Draw_WithData(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{
 				{ID: "tank/data@auto-2024-01-01", Dataset: "tank/data", SnapshotName: "auto-2024-01-01", Used: 1024, Referenced: 1073741824},
@@ -198,7 +202,7 @@ func TestSnapshotsView_Draw_WithData(t *testing.T) {
 }
 
 func TestSnapshotsView_Draw_Empty(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{}, nil
 		},
@@ -218,7 +222,7 @@ func TestSnapshotsView_Draw_Empty(t *testing.T) {
 }
 
 func TestSnapshotsView_HandleEvent(t *testing.T) {
-	mock := &truenas.MockSnapshotService{
+	mock := &internal.MockSnapshotService{
 		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
 			return []truenas.Snapshot{
 				{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"},
@@ -235,3 +239,384 @@ func TestSnapshotsView_HandleEvent(t *testing.T) {
 	}
 	_ = cmd
 }
+
+func newSnapshotsViewWithEvents(mock *internal.MockSnapshotService) (*views.SnapshotsView, chan vaxis.Event) {
+	events := make(chan vaxis.Event, 4)
+	sv := views.NewSnapshotsView(views.SnapshotsViewParams{
+		Service:   mock,
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+	})
+	return sv, events
+}
+
+func waitActionCompleted(t *testing.T, events chan vaxis.Event) views.ActionCompleted {
+	t.Helper()
+	select {
+	case ev := <-events:
+		ac, ok := ev.(views.ActionCompleted)
+		if !ok {
+			t.Fatalf("expected ActionCompleted, got %T", ev)
+		}
+		return ac
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ActionCompleted")
+	}
+	return views.ActionCompleted{}
+}
+
+func TestSnapshotsView_Create(t *testing.T) {
+	var got truenas.CreateSnapshotOpts
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"}}, nil
+		},
+		CreateFunc: func(ctx context.Context, opts truenas.CreateSnapshotOpts) (*truenas.Snapshot, error) {
+			got = opts
+			return &truenas.Snapshot{}, nil
+		},
+	}
+	sv, events := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'c', Text: "c"})
+	if !sv.CapturingInput() {
+		t.Fatal("expected create form to capture input")
+	}
+	// Replace the generated name, then tick the recursive box.
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyTab})
+	sendKey(t, sv, vaxis.Key{Keycode: 'u', Modifiers: vaxis.ModCtrl})
+	for _, r := range "nightly" {
+		sendKey(t, sv, vaxis.Key{Keycode: r, Text: string(r)})
+	}
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyTab})
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeySpace, Text: " "})
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+
+	if sv.CapturingInput() {
+		t.Error("expected form closed after submit")
+	}
+	ac := waitActionCompleted(t, events)
+	if ac.Err != nil {
+		t.Fatalf("unexpected error: %v", ac.Err)
+	}
+	want := truenas.CreateSnapshotOpts{Dataset: "tank/data", Name: "nightly", Recursive: true}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	sv.ActionDone(ac)
+	if sv.Status() != "Created tank/data@nightly" {
+		t.Errorf("unexpected status %q", sv.Status())
+	}
+}
+
+func TestSnapshotsView_Create_Invalid(t *testing.T) {
+	called := false
+	mock := &internal.MockSnapshotService{
+		CreateFunc: func(ctx context.Context, opts truenas.CreateSnapshotOpts) (*truenas.Snapshot, error) {
+			called = true
+			return nil, nil
+		},
+	}
+	sv, _ := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	// No snapshots loaded, so the dataset field starts empty.
+	sendKey(t, sv, vaxis.Key{Keycode: 'c', Text: "c"})
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !sv.CapturingInput() {
+		t.Error("expected form to stay open on validation error")
+	}
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if sv.CapturingInput() {
+		t.Error("expected Esc to close the form")
+	}
+	if called {
+		t.Error("expected Create not to be called")
+	}
+}
+
+func TestSnapshotsView_Destroy(t *testing.T) {
+	var deleted string
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"}}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id string) error {
+			deleted = id
+			return fmt.Errorf("busy")
+		},
+	}
+	sv, events := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'd', Text: "d"})
+	if !sv.CapturingInput() {
+		t.Fatal("expected confirmation dialog")
+	}
+	sendKey(t, sv, vaxis.Key{Keycode: 'y', Text: "y"})
+
+	ac := waitActionCompleted(t, events)
+	if deleted != "tank/data@snap1" {
+		t.Errorf("expected tank/data@snap1 deleted, got %q", deleted)
+	}
+	if ac.Err == nil {
+		t.Fatal("expected error to be reported")
+	}
+	sv.ActionDone(ac)
+	if !strings.Contains(sv.Status(), "busy") {
+		t.Errorf("expected status to contain error, got %q", sv.Status())
+	}
+}
+
+func TestSnapshotsView_Destroy_Cancel(t *testing.T) {
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{{ID: "tank/data@snap1", Dataset: "tank/data", SnapshotName: "snap1"}}, nil
+		},
+		DeleteFunc: func(ctx context.Context, id string) error {
+			t.Error("unexpected Delete")
+			return nil
+		},
+	}
+	sv, _ := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'd', Text: "d"})
+	sendKey(t, sv, vaxis.Key{Keycode: 'n', Text: "n"})
+	if sv.CapturingInput() {
+		t.Error("expected dialog closed")
+	}
+}
+
+func TestSnapshotsView_ToggleHold(t *testing.T) {
+	var held, released string
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{
+				{ID: "tank/data@free", Dataset: "tank/data", SnapshotName: "free"},
+				{ID: "tank/data@held", Dataset: "tank/data", SnapshotName: "held", HasHold: true},
+			}, nil
+		},
+		HoldFunc:    func(ctx context.Context, id string) error { held = id; return nil },
+		ReleaseFunc: func(ctx context.Context, id string) error { released = id; return nil },
+	}
+	sv, events := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'h', ShiftedCode: 'H', Modifiers: vaxis.ModShift, Text: "H"})
+	waitActionCompleted(t, events)
+	if held != "tank/data@free" {
+		t.Errorf("expected hold on tank/data@free, got %q", held)
+	}
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'j', Text: "j"})
+	sendKey(t, sv, vaxis.Key{Keycode: 'h', ShiftedCode: 'H', Modifiers: vaxis.ModShift, Text: "H"})
+	waitActionCompleted(t, events)
+	if released != "tank/data@held" {
+		t.Errorf("expected release of tank/data@held, got %q", released)
+	}
+}
+
+func TestSnapshotsView_Destroy_Held(t *testing.T) {
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{{ID: "tank/data@held", HasHold: true}}, nil
+		},
+	}
+	sv, _ := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'd', Text: "d"})
+	if sv.CapturingInput() {
+		t.Error("expected no dialog for a held snapshot")
+	}
+	if !strings.Contains(sv.Status(), "held; release it with H first") {
+		t.Errorf("expected held error in status, got %q", sv.Status())
+	}
+
+	// The hint names the hold key as remapped.
+	km, err := keys.Resolve(map[string][]string{"hold_snapshot": {"ctrl+h"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Apply(km)
+	t.Cleanup(func() { keys.Apply(nil) })
+	sendKey(t, sv, vaxis.Key{Keycode: 'd', Text: "d"})
+	if !strings.Contains(sv.Status(), "release it with Ctrl+H first") {
+		t.Errorf("expected the remapped key in the hint, got %q", sv.Status())
+	}
+}
+
+func rollbackFixture() []truenas.Snapshot {
+	return []truenas.Snapshot{
+		{ID: "tank/data@a", Dataset: "tank/data", SnapshotName: "a", CreateTXG: "100"},
+		{ID: "tank/data@b", Dataset: "tank/data", SnapshotName: "b", CreateTXG: "200"},
+		{ID: "tank/other@x", Dataset: "tank/other", SnapshotName: "x", CreateTXG: "300"},
+		{ID: "tank/data@c", Dataset: "tank/data", SnapshotName: "c", CreateTXG: "1000"},
+	}
+}
+
+func TestSnapshotsView_Rollback(t *testing.T) {
+	var gotID string
+	var gotOpts internal.RollbackSnapshotOpts
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) { return rollbackFixture(), nil },
+		RollbackFunc: func(ctx context.Context, id string, opts internal.RollbackSnapshotOpts) error {
+			gotID, gotOpts = id, opts
+			return nil
+		},
+	}
+	sv, events := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'r', ShiftedCode: 'R', Modifiers: vaxis.ModShift, Text: "R"})
+	if !sv.CapturingInput() {
+		t.Fatal("expected rollback dialog")
+	}
+	// tank/other@x is newer by txg but belongs to another dataset.
+	text := drawText(t, sv)
+	if !strings.Contains(text, "This destroys 2 newer snapshot(s):") {
+		t.Error("expected two newer snapshots to be listed")
+	}
+
+	// Wrong name keeps the dialog open.
+	typeString(t, sv, "tank/dat")
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !sv.CapturingInput() {
+		t.Fatal("expected dialog to stay open on mismatch")
+	}
+
+	typeString(t, sv, "a")
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	waitActionCompleted(t, events)
+	if gotID != "tank/data@a" || !gotOpts.DestroyNewer {
+		t.Errorf("expected rollback to tank/data@a destroying newer, got %q %+v", gotID, gotOpts)
+	}
+}
+
+func TestSnapshotsView_Rollback_Latest(t *testing.T) {
+	var gotOpts internal.RollbackSnapshotOpts
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{{ID: "tank/data@c", Dataset: "tank/data", SnapshotName: "c", CreateTXG: "1000"}}, nil
+		},
+		RollbackFunc: func(ctx context.Context, id string, opts internal.RollbackSnapshotOpts) error {
+			gotOpts = opts
+			return nil
+		},
+	}
+	sv, events := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'r', ShiftedCode: 'R', Modifiers: vaxis.ModShift, Text: "R"})
+	typeString(t, sv, "tank/data")
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	waitActionCompleted(t, events)
+	if gotOpts.DestroyNewer {
+		t.Error("expected no newer snapshots to be destroyed")
+	}
+}
+
+func TestSnapshotsView_Rollback_BlockedByHold(t *testing.T) {
+	snaps := rollbackFixture()
+	snaps[1].HasHold = true
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) { return snaps, nil },
+		RollbackFunc: func(ctx context.Context, id string, opts internal.RollbackSnapshotOpts) error {
+			t.Error("unexpected Rollback")
+			return nil
+		},
+	}
+	sv, _ := newSnapshotsViewWithEvents(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: 'r', ShiftedCode: 'R', Modifiers: vaxis.ModShift, Text: "R"})
+	typeString(t, sv, "tank/data")
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !sv.CapturingInput() {
+		t.Error("expected dialog to stay open while a newer snapshot is held")
+	}
+}
+
+func TestSnapshotsView_Clone(t *testing.T) {
+	datasets := []truenas.Dataset{{ID: "tank"}, {ID: "tank/data"}, {ID: "tank/other"}, {ID: "backup"}}
+	var gotSnap, gotDst string
+	events := make(chan vaxis.Event, 4)
+	sv := views.NewSnapshotsView(views.SnapshotsViewParams{
+		Service: &internal.MockSnapshotService{
+			ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) { return rollbackFixture(), nil },
+			CloneFunc: func(ctx context.Context, snapshot, datasetDst string) error {
+				gotSnap, gotDst = snapshot, datasetDst
+				return nil
+			},
+		},
+		StaleTTL:  30 * time.Second,
+		PostEvent: func(ev vaxis.Event) { events <- ev },
+		Datasets:  func() []truenas.Dataset { return datasets },
+	})
+	_ = sv.Load(context.Background())
+
+	cloneKey := vaxis.Key{Keycode: 'c', ShiftedCode: 'C', Modifiers: vaxis.ModShift, Text: "C"}
+	ctrlU := vaxis.Key{Keycode: 'u', Modifiers: vaxis.ModCtrl}
+	enter := vaxis.Key{Keycode: vaxis.KeyEnter}
+
+	sendKey(t, sv, cloneKey)
+	for _, bad := range []string{"tank/data", "backup/copy", "tank/missing/copy", "tank"} {
+		sendKey(t, sv, ctrlU)
+		typeString(t, sv, bad)
+		sendKey(t, sv, enter)
+		if !sv.CapturingInput() {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	sendKey(t, sv, ctrlU)
+	typeString(t, sv, "tank/other/restore")
+	sendKey(t, sv, enter)
+	waitActionCompleted(t, events)
+	if gotSnap != "tank/data@a" || gotDst != "tank/other/restore" {
+		t.Errorf("unexpected clone %q -> %q", gotSnap, gotDst)
+	}
+}
+
+func TestSnapshotsView_FilterAndSort(t *testing.T) {
+	mock := &internal.MockSnapshotService{
+		ListFunc: func(ctx context.Context) ([]truenas.Snapshot, error) {
+			return []truenas.Snapshot{
+				{ID: "tank/data@daily-1", Dataset: "tank/data", SnapshotName: "daily-1", Used: 10, Referenced: 300},
+				{ID: "tank/data@weekly-1", Dataset: "tank/data", SnapshotName: "weekly-1", Used: 30, Referenced: 100},
+				{ID: "tank/apps@daily-1", Dataset: "tank/apps", SnapshotName: "daily-1", Used: 20, Referenced: 200},
+			}, nil
+		},
+	}
+	sv := newSnapshotsView(mock)
+	_ = sv.Load(context.Background())
+
+	sendKey(t, sv, vaxis.Key{Keycode: '/', Text: "/"})
+	typeString(t, sv, "daily-*")
+	sendKey(t, sv, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if sv.RowCount() != 2 {
+		t.Fatalf("expected 2 daily snapshots, got %d", sv.RowCount())
+	}
+	if sv.ItemCount() != 3 {
+		t.Errorf("expected filter to leave loaded snapshots alone, got %d", sv.ItemCount())
+	}
+
+	// Sort by USED (third column) descending.
+	for i := 0; i < 3; i++ {
+		sendKey(t, sv, vaxis.Key{Keycode: 's', Text: "s"})
+	}
+	sendKey(t, sv, vaxis.Key{Keycode: 's', ShiftedCode: 'S', Modifiers: vaxis.ModShift, Text: "S"})
+	sendKey(t, sv, vaxis.Key{Keycode: 'k', Text: "k"})
+	if got := sv.SelectedSnapshot().ID; got != "tank/apps@daily-1" {
+		t.Errorf("expected largest daily snapshot first, got %s", got)
+	}
+
+	// The filter prompt takes keys that are otherwise actions.
+	sendKey(t, sv, vaxis.Key{Keycode: '/', Text: "/"})
+	sendKey(t, sv, vaxis.Key{Keycode: 'd', Text: "d"})
+	if strings.Contains(drawText(t, sv), "Destroy snapshot") {
+		t.Error("expected d to be typed into the filter, not open destroy")
+	}
+}
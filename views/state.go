@@ -1,12 +1,94 @@
 package views
 
+import (
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/internal"
+	"github.com/deevus/truenas-tui/keys"
+)
+
 // ViewLoaded is a custom vaxis event posted when a view finishes loading data.
 // It is sent from background goroutines via PostEvent to notify the UI.
+// Server names the server profile the view belongs to; empty means the one
+// on screen.
 type ViewLoaded struct {
-	Tab int
-	Err error
+	Tab    int
+	Err    error
+	Server string
 }
 
 // DashboardUpdated is posted by subscription goroutines when new realtime
 // or app stats data arrives, triggering a redraw.
 type DashboardUpdated struct{}
+
+// AlertsChanged is posted by the alert subscription when the server reports
+// a change to the alert list. The App reloads the Alerts tab in response.
+// View is the posting view, so the App can tell which server changed.
+type AlertsChanged struct {
+	View *AlertsView
+}
+
+// ActionCompleted is posted when a background action started from a view
+// (create, destroy, hold, ...) finishes. The App hands it back to the view
+// via ActionDone and then reloads the view's data.
+type ActionCompleted struct {
+	View    ActionView
+	Message string
+	Err     error
+}
+
+// ActionView is implemented by views that run background actions.
+type ActionView interface {
+	vxfw.Widget
+	ActionDone(ev ActionCompleted)
+}
+
+// InputCapturer is implemented by views that can take over the keyboard,
+// e.g. while a modal form is open. While CapturingInput returns true the App
+// skips its global keybindings so every key reaches the view.
+type InputCapturer interface {
+	CapturingInput() bool
+}
+
+// PaletteCommand is an action offered by the command palette.
+type PaletteCommand struct {
+	Name string
+	Key  string // keybinding shown in the palette, e.g. "x" or "Ctrl+S"
+	// Disabled commands are listed but can't be run, e.g. when they don't
+	// apply to the current selection.
+	Disabled bool
+	Run      func() (vxfw.Command, error)
+}
+
+// CommandProvider is implemented by views that offer commands for the
+// palette. Commands is called each time the palette opens, so it can
+// depend on the current selection.
+type CommandProvider interface {
+	Commands() []PaletteCommand
+}
+
+// KeyScoper is implemented by views with their own keybindings. The help
+// lists the scopes KeyScopes returns after the global bindings.
+type KeyScoper interface {
+	KeyScopes() []*keys.Scope
+}
+
+// PoolDetailLoaded is posted by the pool detail poller with fresh topology
+// and scan status for the pool shown in the detail pane. View is the view
+// that polled it.
+type PoolDetailLoaded struct {
+	View   *PoolsView
+	PoolID int64
+	Detail *internal.PoolDetail
+	Err    error
+}
+
+// DiskDetailLoaded is posted by the disk detail poller with the SMART
+// attributes and self-test log of the disk shown in the detail pane. View
+// is the view that polled it.
+type DiskDetailLoaded struct {
+	View       *DisksView
+	Disk       string
+	Attributes []internal.SmartAttribute
+	Tests      []internal.SmartTest
+	Err        error
+}
@@ -5,6 +5,7 @@ import (
 
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 // BarGauge is a horizontal bar gauge widget.
@@ -15,6 +16,10 @@ type BarGauge struct {
 	Value    float64 // 0.0–100.0
 	Suffix   string  // text after %, e.g. "65°C" or "13.1/16.0 GiB"
 	BarWidth int     // character width of the [████░░░░] portion (excluding brackets)
+	// Color fixes the fill color. When unset the fill follows the usage
+	// thresholds (green, yellow from 60%, red from 85%), which suit
+	// utilization but not progress.
+	Color vaxis.Color
 }
 
 const (
@@ -22,15 +27,34 @@ const (
 	barEmpty  = '░' // U+2591
 )
 
-// barColor returns the appropriate color for the given percentage.
-func barColor(pct float64) vaxis.Color {
+// Usage thresholds for the gauge fill, in percent.
+const (
+	barWarn     = 60
+	barCritical = 85
+)
+
+// barStyle returns the fill style for the given percentage.
+func barStyle(pct float64) vaxis.Style {
+	return ThresholdStyle(pct, barWarn, barCritical)
+}
+
+// ThresholdColor returns the theme's ok color below warn, its warning color
+// from warn and its critical color from critical: the gauge's usage colors,
+// for other readings such as disk temperatures.
+func ThresholdColor(v, warn, critical float64) vaxis.Color {
+	return ThresholdStyle(v, warn, critical).Foreground
+}
+
+// ThresholdStyle is ThresholdColor as a full style, which keeps the theme's
+// attributes, e.g. bold critical text without colors.
+func ThresholdStyle(v, warn, critical float64) vaxis.Style {
 	switch {
-	case pct >= 85:
-		return vaxis.IndexColor(1) // red
-	case pct >= 60:
-		return vaxis.IndexColor(3) // yellow
+	case v >= critical:
+		return theme.Critical()
+	case v >= warn:
+		return theme.Warning()
 	default:
-		return vaxis.IndexColor(2) // green
+		return theme.OK()
 	}
 }
 
@@ -65,14 +89,17 @@ func (bg *BarGauge) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 		v = 100
 	}
 	filled := int(v / 100 * float64(bg.BarWidth))
-	color := barColor(v)
+	fill := vaxis.Style{Foreground: bg.Color}
+	if bg.Color == 0 {
+		fill = barStyle(v)
+	}
 
 	for i := 0; i < bg.BarWidth; i++ {
 		ch := barEmpty
-		style := vaxis.Style{Foreground: vaxis.IndexColor(8)} // dim for empty
+		style := theme.Dim()
 		if i < filled {
 			ch = barFilled
-			style = vaxis.Style{Foreground: color}
+			style = fill
 		}
 		for _, c := range ctx.Characters(string(ch)) {
 			s.WriteCell(col, 0, vaxis.Cell{Character: c, Style: style})
@@ -90,7 +117,7 @@ func (bg *BarGauge) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	// Suffix
 	if bg.Suffix != "" {
 		suffix := "  " + bg.Suffix
-		dimStyle := vaxis.Style{Attribute: vaxis.AttrDim}
+		dimStyle := theme.Dim()
 		for _, ch := range ctx.Characters(suffix) {
 			s.WriteCell(col, 0, vaxis.Cell{Character: ch, Style: dimStyle})
 			col += uint16(ch.Width)
@@ -3,6 +3,8 @@ package widgets_test
 import (
 	"testing"
 
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/deevus/truenas-tui/theme"
 	"github.com/deevus/truenas-tui/widgets"
 )
 
@@ -108,3 +110,60 @@ func TestBarGauge_Draw_NoSuffix(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestBarGauge_Draw_FixedColor(t *testing.T) {
+	bg := &widgets.BarGauge{
+		Label:    "SCAN",
+		Value:    95,
+		BarWidth: 10,
+		Color:    vaxis.IndexColor(4),
+	}
+
+	s, err := bg.Draw(testDrawContext(80, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// "SCAN [" puts the first bar cell at column 6.
+	if got := s.Buffer[6].Style.Foreground; got != vaxis.IndexColor(4) {
+		t.Errorf("expected fixed fill color, got %v", got)
+	}
+}
+
+func TestThresholdColor(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want vaxis.Color
+	}{
+		{30, vaxis.IndexColor(2)},
+		{45, vaxis.IndexColor(3)},
+		{54.9, vaxis.IndexColor(3)},
+		{55, vaxis.IndexColor(1)},
+	}
+	for _, tt := range tests {
+		if got := widgets.ThresholdColor(tt.v, 45, 55); got != tt.want {
+			t.Errorf("ThresholdColor(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestBarGauge_Draw_Theme(t *testing.T) {
+	hc, err := theme.Resolve("high-contrast", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme.Set(hc)
+	t.Cleanup(func() { theme.Set(theme.Default) })
+
+	bg := &widgets.BarGauge{Label: "CPU", Value: 90, BarWidth: 10}
+	s, err := bg.Draw(testDrawContext(80, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// "CPU  [" puts the first bar cell at column 6.
+	if got := s.Buffer[6].Style; got != hc.Critical {
+		t.Errorf("expected the theme's critical style, got %+v", got)
+	}
+	if got := s.Buffer[15].Style; got != hc.Dim {
+		t.Errorf("expected the theme's dim style for empty cells, got %+v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package widgets
+
+import (
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// Confirm is a modal yes/no dialog. The confirm binding (y or Enter by
+// default) confirms, the cancel binding (n or Esc) cancels.
+//
+//	┌ Destroy snapshot ──────────────────────┐
+//	│ tank/data@daily-2026-01-01             │
+//	│                                        │
+//	│ y confirm · n cancel                   │
+//	└────────────────────────────────────────┘
+type Confirm struct {
+	Title     string
+	Lines     []string
+	OnConfirm func() (vxfw.Command, error)
+	OnCancel  func() (vxfw.Command, error)
+}
+
+// HandleEvent processes the confirm and cancel keys.
+func (c *Confirm) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	switch {
+	case keys.Confirm.Matches(key):
+		if c.OnConfirm != nil {
+			return c.OnConfirm()
+		}
+	case keys.Cancel.Matches(key):
+		if c.OnCancel != nil {
+			return c.OnCancel()
+		}
+	}
+	// Swallow everything else so keys don't leak to the view underneath.
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders the dialog as a bordered box sized to its content.
+func (c *Confirm) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	var rows []frameRow
+	for _, l := range c.Lines {
+		rows = append(rows, frameRow{text: l})
+	}
+	rows = append(rows, frameRow{}, frameRow{text: keys.Confirm.Short() + " confirm · " + keys.Cancel.Short() + " cancel", style: theme.Dim()})
+	s, _ := drawFrame(ctx, c, c.Title, rows, 40)
+	return s, nil
+}
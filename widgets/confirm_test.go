@@ -0,0 +1,56 @@
+package widgets_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+func TestConfirm_Keys(t *testing.T) {
+	var confirmed, cancelled int
+	c := &widgets.Confirm{
+		OnConfirm: func() (vxfw.Command, error) { confirmed++; return nil, nil },
+		OnCancel:  func() (vxfw.Command, error) { cancelled++; return nil, nil },
+	}
+	press(t, c, vaxis.Key{Keycode: 'y', Text: "y"})
+	press(t, c, vaxis.Key{Keycode: vaxis.KeyEnter})
+	press(t, c, vaxis.Key{Keycode: 'n', Text: "n"})
+	press(t, c, vaxis.Key{Keycode: vaxis.KeyEsc})
+	press(t, c, vaxis.Key{Keycode: 'q', Text: "q"})
+	if confirmed != 2 || cancelled != 2 {
+		t.Errorf("expected 2 confirms and 2 cancels, got %d/%d", confirmed, cancelled)
+	}
+}
+
+func TestConfirm_RemappedKeys(t *testing.T) {
+	km, err := keys.Resolve(map[string][]string{"confirm": {"Y"}, "cancel": {"N", "esc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Apply(km)
+	t.Cleanup(func() { keys.Apply(nil) })
+
+	var confirmed, cancelled int
+	c := &widgets.Confirm{
+		OnConfirm: func() (vxfw.Command, error) { confirmed++; return nil, nil },
+		OnCancel:  func() (vxfw.Command, error) { cancelled++; return nil, nil },
+	}
+	press(t, c, vaxis.Key{Keycode: 'y', Text: "y"})
+	press(t, c, vaxis.Key{Keycode: vaxis.KeyEnter})
+	press(t, c, vaxis.Key{Keycode: 'n', Text: "n"})
+	if confirmed != 0 || cancelled != 0 {
+		t.Fatalf("expected the default keys to do nothing, got %d/%d", confirmed, cancelled)
+	}
+	press(t, c, vaxis.Key{Keycode: 'y', ShiftedCode: 'Y', Modifiers: vaxis.ModShift, Text: "Y"})
+	press(t, c, vaxis.Key{Keycode: 'n', ShiftedCode: 'N', Modifiers: vaxis.ModShift, Text: "N"})
+	if confirmed != 1 || cancelled != 1 {
+		t.Errorf("expected 1 confirm and 1 cancel, got %d/%d", confirmed, cancelled)
+	}
+	if text := drawText(t, c); !strings.Contains(text, "Y confirm · N cancel") {
+		t.Errorf("expected the remapped keys in the hint, got %q", text)
+	}
+}
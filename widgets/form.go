@@ -0,0 +1,191 @@
+package widgets
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// FormField is a single labelled input in a Form: either a one-line text
+// input or a checkbox.
+type FormField struct {
+	Label    string
+	Value    string
+	Checkbox bool
+	Checked  bool
+	Secret   bool // render text input as asterisks
+}
+
+// NewTextField creates a text input with an initial value.
+func NewTextField(label, value string) *FormField {
+	return &FormField{Label: label, Value: value}
+}
+
+// NewCheckbox creates a checkbox field.
+func NewCheckbox(label string, checked bool) *FormField {
+	return &FormField{Label: label, Checkbox: true, Checked: checked}
+}
+
+// Form is a modal dialog with text inputs and checkboxes.
+//
+//	┌ Create snapshot ──────────────────────┐
+//	│ Dataset:   tank/data█                  │
+//	│ Name:      manual-2026-01-01           │
+//	│ Recursive: [ ]                         │
+//	│                                        │
+//	│ Enter submit · Esc cancel · Tab next   │
+//	└────────────────────────────────────────┘
+//
+// Tab/Down and Shift+Tab/Up move between fields, Space toggles a checkbox,
+// Enter submits and Esc cancels.
+type Form struct {
+	Title    string
+	Fields   []*FormField
+	Lines    []string // optional text shown above the fields
+	Error    string   // validation message shown below the fields
+	OnSubmit func(f *Form) (vxfw.Command, error)
+	OnCancel func() (vxfw.Command, error)
+
+	focus int
+}
+
+// Focused returns the index of the focused field.
+func (f *Form) Focused() int {
+	return f.focus
+}
+
+// Field returns the field with the given label, or nil.
+func (f *Form) Field(label string) *FormField {
+	for _, fld := range f.Fields {
+		if fld.Label == label {
+			return fld
+		}
+	}
+	return nil
+}
+
+// HandleEvent processes key input for the focused field.
+func (f *Form) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+
+	switch {
+	case key.Matches(vaxis.KeyEsc):
+		if f.OnCancel != nil {
+			return f.OnCancel()
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	case key.Matches(vaxis.KeyEnter):
+		if f.OnSubmit != nil {
+			return f.OnSubmit(f)
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	case key.Matches(vaxis.KeyTab), key.Matches(vaxis.KeyDown):
+		if len(f.Fields) > 0 {
+			f.focus = (f.focus + 1) % len(f.Fields)
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	case key.Matches(vaxis.KeyTab, vaxis.ModShift), key.Matches(vaxis.KeyUp):
+		if len(f.Fields) > 0 {
+			f.focus = (f.focus - 1 + len(f.Fields)) % len(f.Fields)
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+
+	if f.focus >= len(f.Fields) {
+		return nil, nil
+	}
+	fld := f.Fields[f.focus]
+
+	if fld.Checkbox {
+		if key.Matches(vaxis.KeySpace) || key.Matches('x') {
+			fld.Checked = !fld.Checked
+		}
+		return vxfw.ConsumeAndRedraw(), nil
+	}
+
+	switch {
+	case key.Matches(vaxis.KeyBackspace), key.Matches('h', vaxis.ModCtrl):
+		if fld.Value != "" {
+			_, size := utf8.DecodeLastRuneInString(fld.Value)
+			fld.Value = fld.Value[:len(fld.Value)-size]
+		}
+	case key.Matches('u', vaxis.ModCtrl):
+		fld.Value = ""
+	case key.Text != "":
+		fld.Value += key.Text
+	}
+	f.Error = ""
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders the form as a bordered box sized to its content.
+func (f *Form) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	labelWidth := 0
+	for _, fld := range f.Fields {
+		labelWidth = max(labelWidth, len(fld.Label)+1)
+	}
+
+	hint := "Enter submit · Esc cancel"
+	if len(f.Fields) > 1 {
+		hint += " · Tab next"
+	}
+
+	var rows []frameRow
+	for _, l := range f.Lines {
+		rows = append(rows, frameRow{text: l})
+	}
+	if len(f.Lines) > 0 {
+		rows = append(rows, frameRow{})
+	}
+	inputRow := len(rows)
+	for range f.Fields {
+		rows = append(rows, frameRow{})
+	}
+	if f.Error != "" {
+		rows = append(rows, frameRow{text: f.Error, style: theme.Critical()})
+	}
+	rows = append(rows, frameRow{}, frameRow{text: hint, style: theme.Dim()})
+
+	s, right := drawFrame(ctx, f, f.Title, rows, 50)
+	for i, fld := range f.Fields {
+		row := uint16(inputRow + i + 1)
+		col := writeString(ctx, &s, 2, row, right, fmt.Sprintf("%-*s", labelWidth+1, fld.Label+":"), vaxis.Style{Attribute: vaxis.AttrBold})
+		focused := i == f.focus
+		if fld.Checkbox {
+			box := "[ ]"
+			if fld.Checked {
+				box = "[x]"
+			}
+			style := vaxis.Style{}
+			if focused {
+				style = theme.Selection()
+			}
+			writeString(ctx, &s, col, row, right, box, style)
+			continue
+		}
+		text := fld.Value
+		if fld.Secret {
+			text = strings.Repeat("*", utf8.RuneCountInString(text))
+		}
+		// Keep the end of long values (where typing happens) in view.
+		avail := int(right) - int(col) - 1
+		if n := utf8.RuneCountInString(text); avail > 0 && n > avail {
+			text = "…" + string([]rune(text)[n-avail+1:])
+		}
+		col = writeString(ctx, &s, col, row, right, text, vaxis.Style{})
+		if focused && col < right {
+			s.WriteCell(col, row, vaxis.Cell{
+				Character: vaxis.Character{Grapheme: " ", Width: 1},
+				Style:     vaxis.Style{Attribute: vaxis.AttrReverse},
+			})
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,104 @@
+package widgets_test
+
+import (
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+func typeText(t *testing.T, w vxfw.EventHandler, text string) {
+	t.Helper()
+	for _, r := range text {
+		if _, err := w.HandleEvent(vaxis.Key{Keycode: r, Text: string(r)}, vxfw.TargetPhase); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func press(t *testing.T, w vxfw.EventHandler, key vaxis.Key) {
+	t.Helper()
+	if _, err := w.HandleEvent(key, vxfw.TargetPhase); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestForm_TypingAndBackspace(t *testing.T) {
+	f := &widgets.Form{Fields: []*widgets.FormField{widgets.NewTextField("Name", "ab")}}
+	typeText(t, f, "cd")
+	press(t, f, vaxis.Key{Keycode: vaxis.KeyBackspace})
+	if got := f.Fields[0].Value; got != "abc" {
+		t.Errorf("expected abc, got %q", got)
+	}
+	press(t, f, vaxis.Key{Keycode: 'u', Modifiers: vaxis.ModCtrl})
+	if got := f.Fields[0].Value; got != "" {
+		t.Errorf("expected empty after Ctrl+U, got %q", got)
+	}
+}
+
+func TestForm_FocusAndCheckbox(t *testing.T) {
+	f := &widgets.Form{Fields: []*widgets.FormField{
+		widgets.NewTextField("Name", ""),
+		widgets.NewCheckbox("Recursive", false),
+	}}
+	press(t, f, vaxis.Key{Keycode: vaxis.KeyTab})
+	if f.Focused() != 1 {
+		t.Fatalf("expected focus=1, got %d", f.Focused())
+	}
+	press(t, f, vaxis.Key{Keycode: vaxis.KeySpace, Text: " "})
+	if !f.Field("Recursive").Checked {
+		t.Error("expected checkbox toggled on")
+	}
+	// Text keys don't edit a checkbox.
+	typeText(t, f, "a")
+	if f.Fields[0].Value != "" {
+		t.Errorf("expected text field untouched, got %q", f.Fields[0].Value)
+	}
+	press(t, f, vaxis.Key{Keycode: vaxis.KeyTab})
+	if f.Focused() != 0 {
+		t.Errorf("expected focus to wrap to 0, got %d", f.Focused())
+	}
+}
+
+func TestForm_SubmitAndCancel(t *testing.T) {
+	var submitted, cancelled bool
+	f := &widgets.Form{
+		Fields: []*widgets.FormField{widgets.NewTextField("Name", "snap")},
+		OnSubmit: func(f *widgets.Form) (vxfw.Command, error) {
+			submitted = f.Field("Name").Value == "snap"
+			return nil, nil
+		},
+		OnCancel: func() (vxfw.Command, error) {
+			cancelled = true
+			return nil, nil
+		},
+	}
+	press(t, f, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if !submitted {
+		t.Error("expected OnSubmit with field value")
+	}
+	press(t, f, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if !cancelled {
+		t.Error("expected OnCancel")
+	}
+}
+
+func TestForm_Draw(t *testing.T) {
+	f := &widgets.Form{
+		Title:  "Create",
+		Fields: []*widgets.FormField{widgets.NewTextField("Name", "x")},
+		Error:  "bad name",
+	}
+	s, err := f.Draw(testDrawContext(80, 24))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Size.Width != 50 {
+		t.Errorf("expected width 50, got %d", s.Size.Width)
+	}
+	// border + field + error + blank + hint + border
+	if s.Size.Height != 6 {
+		t.Errorf("expected height 6, got %d", s.Size.Height)
+	}
+}
@@ -0,0 +1,78 @@
+package widgets
+
+import (
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+)
+
+// frameRow is one line of content inside a bordered dialog.
+type frameRow struct {
+	text  string
+	style vaxis.Style
+}
+
+// drawFrame renders a single-line border around rows with the title set into
+// the top edge. The box is at least minWidth columns wide, grows to fit the
+// widest row and is clamped to the available space. It returns the surface and
+// the exclusive right-hand column of the content area; content starts at
+// column 2.
+func drawFrame(ctx vxfw.DrawContext, w vxfw.Widget, title string, rows []frameRow, minWidth int) (vxfw.Surface, uint16) {
+	width := max(minWidth, utf8.RuneCountInString(title)+6)
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r.text)+4)
+	}
+	if ctx.Max.Width > 0 && width > int(ctx.Max.Width) {
+		width = int(ctx.Max.Width)
+	}
+	height := len(rows) + 2
+	if ctx.Max.Height > 0 && height > int(ctx.Max.Height) {
+		height = int(ctx.Max.Height)
+	}
+
+	s := vxfw.NewSurface(uint16(width), uint16(height), w)
+	right := uint16(width - 2)
+	border := func(col, row uint16, g string) {
+		s.WriteCell(col, row, vaxis.Cell{Character: vaxis.Character{Grapheme: g, Width: 1}})
+	}
+
+	last := uint16(height - 1)
+	for col := uint16(1); col < uint16(width-1); col++ {
+		border(col, 0, "─")
+		border(col, last, "─")
+	}
+	for row := uint16(1); row < last; row++ {
+		border(0, row, "│")
+		border(uint16(width-1), row, "│")
+	}
+	border(0, 0, "┌")
+	border(uint16(width-1), 0, "┐")
+	border(0, last, "└")
+	border(uint16(width-1), last, "┘")
+
+	if title != "" {
+		writeString(ctx, &s, 2, 0, right, " "+title+" ", vaxis.Style{Attribute: vaxis.AttrBold})
+	}
+	for i, r := range rows {
+		row := uint16(i + 1)
+		if row >= last {
+			break
+		}
+		writeString(ctx, &s, 2, row, right, r.text, r.style)
+	}
+	return s, right
+}
+
+// writeString writes text starting at col, stopping before the limit column.
+// It returns the column after the last written cell.
+func writeString(ctx vxfw.DrawContext, s *vxfw.Surface, col, row, limit uint16, text string, style vaxis.Style) uint16 {
+	for _, ch := range ctx.Characters(text) {
+		if col+uint16(ch.Width) > limit {
+			break
+		}
+		s.WriteCell(col, row, vaxis.Cell{Character: ch, Style: style})
+		col += uint16(ch.Width)
+	}
+	return col
+}
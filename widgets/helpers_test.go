@@ -1,6 +1,9 @@
 package widgets_test
 
 import (
+	"strings"
+	"testing"
+
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
 )
@@ -18,3 +21,17 @@ func testDrawContext(w, h uint16) vxfw.DrawContext {
 		},
 	}
 }
+
+// drawText draws w at 80x20 and returns the surface's text.
+func drawText(t *testing.T, w vxfw.Widget) string {
+	t.Helper()
+	s, err := w.Draw(testDrawContext(80, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, c := range s.Buffer {
+		b.WriteString(c.Grapheme)
+	}
+	return b.String()
+}
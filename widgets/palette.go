@@ -0,0 +1,208 @@
+package widgets
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// PaletteItem is one entry of a Palette.
+type PaletteItem struct {
+	Name string
+	Key  string // keybinding shown next to the name, if any
+	// Disabled items are listed, dimmed, but can't be picked, e.g. a
+	// command that doesn't apply to the current selection.
+	Disabled bool
+}
+
+// paletteRows is the number of items shown at once.
+const paletteRows = 12
+
+// Palette is a modal command palette. Typing narrows the items by fuzzy
+// match, Up/Down (or Ctrl+N/Ctrl+P) move, Enter picks and Esc cancels.
+//
+//	┌ Commands ──────────────────────────────────────────┐
+//	│ › scr█                                             │
+//	│                                                    │
+//	│   Start scrub                                    x │
+//	│   Cancel scrub                                   X │
+//	│                                                    │
+//	│ Enter run · Esc close                              │
+//	└────────────────────────────────────────────────────┘
+type Palette struct {
+	Title    string
+	Items    []PaletteItem
+	OnSelect func(i int) (vxfw.Command, error) // i indexes Items
+	OnCancel func() (vxfw.Command, error)
+
+	query   string
+	matches []int // indexes into Items, best match first; nil until filtered
+	cursor  int   // index into matches
+}
+
+// Query returns the text typed so far.
+func (p *Palette) Query() string {
+	return p.query
+}
+
+// Matches returns the names of the items matching the query, best first.
+func (p *Palette) Matches() []string {
+	p.filter()
+	names := make([]string, len(p.matches))
+	for i, m := range p.matches {
+		names[i] = p.Items[m].Name
+	}
+	return names
+}
+
+// filter recomputes the matches if the query or items changed since.
+func (p *Palette) filter() {
+	if p.matches != nil {
+		return
+	}
+	type scored struct{ i, score int }
+	var hits []scored
+	for i, item := range p.Items {
+		if score, ok := FuzzyScore(p.query, item.Name); ok {
+			hits = append(hits, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(hits, func(a, b scored) int { return b.score - a.score })
+	p.matches = make([]int, len(hits))
+	for i, h := range hits {
+		p.matches[i] = h.i
+	}
+	p.cursor = min(p.cursor, max(len(p.matches)-1, 0))
+}
+
+func (p *Palette) setQuery(q string) {
+	p.query = q
+	p.matches = nil
+	p.cursor = 0
+}
+
+// HandleEvent edits the query, moves the cursor and processes Enter/Esc.
+func (p *Palette) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	p.filter()
+	switch {
+	case key.Matches(vaxis.KeyDown), key.Matches('n', vaxis.ModCtrl):
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case key.Matches(vaxis.KeyUp), key.Matches('p', vaxis.ModCtrl):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case key.Matches(vaxis.KeyEnter):
+		if p.cursor < len(p.matches) && !p.Items[p.matches[p.cursor]].Disabled && p.OnSelect != nil {
+			return p.OnSelect(p.matches[p.cursor])
+		}
+	case key.Matches(vaxis.KeyEsc):
+		if p.OnCancel != nil {
+			return p.OnCancel()
+		}
+	case key.Matches(vaxis.KeyBackspace):
+		if p.query != "" {
+			_, size := utf8.DecodeLastRuneInString(p.query)
+			p.setQuery(p.query[:len(p.query)-size])
+		}
+	case key.Matches('u', vaxis.ModCtrl):
+		p.setQuery("")
+	case key.Text != "":
+		p.setQuery(p.query + key.Text)
+	}
+	// Swallow everything else so keys don't leak to the view underneath.
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders the palette as a bordered box with the query, the matching
+// items around the cursor and the key hints.
+func (p *Palette) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	p.filter()
+	const width = 52 // content width inside the border
+	dim := theme.Dim()
+	rows := []frameRow{{text: "› " + p.query + "█"}, {}}
+
+	start := max(min(p.cursor-paletteRows/2, len(p.matches)-paletteRows), 0)
+	end := min(start+paletteRows, len(p.matches))
+	for i := start; i < end; i++ {
+		item := p.Items[p.matches[i]]
+		name := item.Name
+		if item.Disabled {
+			name += " (n/a)"
+		}
+		text := fmt.Sprintf("  %-*s%*s", width-len(item.Key)-3, name, len(item.Key)+1, item.Key)
+		var style vaxis.Style
+		switch {
+		case i == p.cursor:
+			text = "›" + text[1:]
+			style = theme.Selection()
+		case item.Disabled:
+			style = dim
+		}
+		rows = append(rows, frameRow{text: text, style: style})
+	}
+	if len(p.matches) == 0 {
+		rows = append(rows, frameRow{text: "  No matching commands", style: dim})
+	}
+	rows = append(rows, frameRow{}, frameRow{text: "Enter run · Esc close", style: dim})
+	s, _ := drawFrame(ctx, p, p.Title, rows, width+4)
+	return s, nil
+}
+
+// FuzzyScore reports whether every character of pattern appears in s in
+// order, ignoring case and spaces, and scores the best such match:
+// consecutive characters and characters at the start of a word score
+// higher. An empty pattern matches everything with a score of zero.
+func FuzzyScore(pattern, s string) (int, bool) {
+	p := []rune(strings.ReplaceAll(strings.ToLower(pattern), " ", ""))
+	target := []rune(strings.ToLower(s))
+	if len(p) == 0 {
+		return 0, true
+	}
+	best, ok := 0, false
+	// Try every place the first character occurs; greedy matching from the
+	// leftmost one alone misses better matches later in the string.
+	for start, r := range target {
+		if r != p[0] {
+			continue
+		}
+		if score, found := fuzzyFrom(p, target, start); found && (!ok || score > best) {
+			best, ok = score, true
+		}
+	}
+	return best, ok
+}
+
+// fuzzyFrom greedily matches p against target from start and scores it.
+func fuzzyFrom(p, target []rune, start int) (int, bool) {
+	score, last, ti := 0, -2, start
+	for _, pr := range p {
+		for ti < len(target) && target[ti] != pr {
+			ti++
+		}
+		if ti == len(target) {
+			return 0, false
+		}
+		score++
+		if ti == last+1 {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(target[ti-1]) && !unicode.IsDigit(target[ti-1]) {
+			score += 2
+		}
+		last = ti
+		ti++
+	}
+	return score, true
+}
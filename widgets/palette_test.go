@@ -0,0 +1,110 @@
+package widgets_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+func paletteItems() []widgets.PaletteItem {
+	return []widgets.PaletteItem{
+		{Name: "Go to Pools", Key: "2"},
+		{Name: "Start scrub", Key: "x"},
+		{Name: "Cancel scrub", Key: "X", Disabled: true},
+		{Name: "Switch server", Key: "Ctrl+S"},
+	}
+}
+
+func typeQuery(t *testing.T, p *widgets.Palette, text string) {
+	t.Helper()
+	for _, r := range text {
+		press(t, p, vaxis.Key{Keycode: r, Text: string(r)})
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := widgets.FuzzyScore("scb", "Start scrub"); !ok {
+		t.Error("expected a subsequence to match")
+	}
+	if _, ok := widgets.FuzzyScore("bcs", "Start scrub"); ok {
+		t.Error("expected out-of-order characters not to match")
+	}
+	if score, ok := widgets.FuzzyScore("", "anything"); !ok || score != 0 {
+		t.Errorf("expected an empty pattern to match with score 0, got %d, %v", score, ok)
+	}
+	word, _ := widgets.FuzzyScore("ss", "Start scrub")
+	inner, _ := widgets.FuzzyScore("ss", "Pass sets")
+	if word <= inner {
+		t.Errorf("expected word starts to score higher, got %d <= %d", word, inner)
+	}
+}
+
+func TestPalette_Filter(t *testing.T) {
+	p := &widgets.Palette{Items: paletteItems()}
+	if got := p.Matches(); len(got) != 4 {
+		t.Fatalf("expected every item with no query, got %v", got)
+	}
+	typeQuery(t, p, "scrub")
+	if got := p.Matches(); !slices.Equal(got, []string{"Start scrub", "Cancel scrub"}) {
+		t.Errorf("unexpected matches %v", got)
+	}
+	press(t, p, vaxis.Key{Keycode: 'u', Modifiers: vaxis.ModCtrl})
+	typeQuery(t, p, "swsv")
+	if got := p.Matches(); !slices.Equal(got, []string{"Switch server"}) {
+		t.Errorf("unexpected matches %v", got)
+	}
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyBackspace})
+	if p.Query() != "sws" {
+		t.Errorf("expected backspace to drop a character, got %q", p.Query())
+	}
+}
+
+func TestPalette_Select(t *testing.T) {
+	selected, cancelled := -1, 0
+	p := &widgets.Palette{
+		Items:    paletteItems(),
+		OnSelect: func(i int) (vxfw.Command, error) { selected = i; return nil, nil },
+		OnCancel: func() (vxfw.Command, error) { cancelled++; return nil, nil },
+	}
+	typeQuery(t, p, "scrub")
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyDown})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if selected != -1 {
+		t.Errorf("expected a disabled item not to run, got %d", selected)
+	}
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyUp})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if selected != 1 {
+		t.Errorf("expected Start scrub (item 1), got %d", selected)
+	}
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if cancelled != 1 {
+		t.Errorf("expected 1 cancel, got %d", cancelled)
+	}
+}
+
+func TestPalette_Draw(t *testing.T) {
+	p := &widgets.Palette{Title: "Commands", Items: paletteItems()}
+	typeQuery(t, p, "scrub")
+	s, err := p.Draw(testDrawContext(80, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, c := range s.Buffer {
+		b.WriteString(c.Grapheme)
+	}
+	text := b.String()
+	for _, want := range []string{"Commands", "› scrub", "› Start scrub", "Cancel scrub (n/a)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %q", want, text)
+		}
+	}
+	if strings.Contains(text, "Switch server") {
+		t.Error("expected non-matching items to be hidden")
+	}
+}
@@ -0,0 +1,69 @@
+package widgets
+
+import (
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/theme"
+)
+
+// Picker is a modal dialog for choosing one item from a short list. The
+// list bindings (j/k or the arrow keys by default) move, Enter picks, n or
+// Esc cancels.
+//
+//	┌ Container ─────────────────────────────┐
+//	│ › plex                                 │
+//	│   plex-db                              │
+//	│                                        │
+//	│ Enter select · n cancel                │
+//	└────────────────────────────────────────┘
+type Picker struct {
+	Title    string
+	Items    []string
+	Cursor   int
+	OnSelect func(i int) (vxfw.Command, error)
+	OnCancel func() (vxfw.Command, error)
+}
+
+// HandleEvent moves the cursor and processes the select and cancel keys.
+func (p *Picker) HandleEvent(ev vaxis.Event, phase vxfw.EventPhase) (vxfw.Command, error) {
+	key, ok := ev.(vaxis.Key)
+	if !ok || key.EventType == vaxis.EventRelease {
+		return nil, nil
+	}
+	switch {
+	case keys.Down.Matches(key):
+		if p.Cursor < len(p.Items)-1 {
+			p.Cursor++
+		}
+	case keys.Up.Matches(key):
+		if p.Cursor > 0 {
+			p.Cursor--
+		}
+	case keys.Select.Matches(key):
+		if p.OnSelect != nil && p.Cursor < len(p.Items) {
+			return p.OnSelect(p.Cursor)
+		}
+	case keys.Cancel.Matches(key):
+		if p.OnCancel != nil {
+			return p.OnCancel()
+		}
+	}
+	// Swallow everything else so keys don't leak to the view underneath.
+	return vxfw.ConsumeAndRedraw(), nil
+}
+
+// Draw renders the dialog as a bordered box sized to its content.
+func (p *Picker) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
+	var rows []frameRow
+	for i, item := range p.Items {
+		if i == p.Cursor {
+			rows = append(rows, frameRow{text: "› " + item, style: theme.Selection()})
+			continue
+		}
+		rows = append(rows, frameRow{text: "  " + item})
+	}
+	rows = append(rows, frameRow{}, frameRow{text: keys.Select.Short() + " select · " + keys.Cancel.Short() + " cancel", style: theme.Dim()})
+	s, _ := drawFrame(ctx, p, p.Title, rows, 40)
+	return s, nil
+}
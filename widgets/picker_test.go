@@ -0,0 +1,79 @@
+package widgets_test
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/keys"
+	"github.com/deevus/truenas-tui/widgets"
+)
+
+func TestPicker_Keys(t *testing.T) {
+	selected, cancelled := -1, 0
+	p := &widgets.Picker{
+		Items:    []string{"plex", "plex-db", "redis"},
+		OnSelect: func(i int) (vxfw.Command, error) { selected = i; return nil, nil },
+		OnCancel: func() (vxfw.Command, error) { cancelled++; return nil, nil },
+	}
+	press(t, p, vaxis.Key{Keycode: 'j', Text: "j"})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyDown})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyDown}) // clamped at the last item
+	press(t, p, vaxis.Key{Keycode: 'k', Text: "k"})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if selected != 1 {
+		t.Errorf("expected item 1 selected, got %d", selected)
+	}
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEsc})
+	if cancelled != 1 {
+		t.Errorf("expected 1 cancel, got %d", cancelled)
+	}
+}
+
+func TestPicker_RemappedKeys(t *testing.T) {
+	km, err := keys.Resolve(map[string][]string{"down": {"ctrl+n"}, "up": {"ctrl+k"}, "cancel": {"q"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.Apply(km)
+	t.Cleanup(func() { keys.Apply(nil) })
+
+	selected, cancelled := -1, 0
+	p := &widgets.Picker{
+		Items:    []string{"plex", "plex-db", "redis"},
+		OnSelect: func(i int) (vxfw.Command, error) { selected = i; return nil, nil },
+		OnCancel: func() (vxfw.Command, error) { cancelled++; return nil, nil },
+	}
+	press(t, p, vaxis.Key{Keycode: 'j', Text: "j"})
+	press(t, p, vaxis.Key{Keycode: 'n', Modifiers: vaxis.ModCtrl})
+	press(t, p, vaxis.Key{Keycode: 'n', Modifiers: vaxis.ModCtrl})
+	press(t, p, vaxis.Key{Keycode: 'k', Modifiers: vaxis.ModCtrl})
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEnter})
+	if selected != 1 {
+		t.Errorf("expected item 1 selected, got %d", selected)
+	}
+	press(t, p, vaxis.Key{Keycode: vaxis.KeyEsc})
+	press(t, p, vaxis.Key{Keycode: 'q', Text: "q"})
+	if cancelled != 1 {
+		t.Errorf("expected only q to cancel, got %d cancels", cancelled)
+	}
+	if text := drawText(t, p); !strings.Contains(text, "q cancel") {
+		t.Errorf("expected the remapped key in the hint, got %q", text)
+	}
+}
+
+func TestPicker_Draw(t *testing.T) {
+	p := &widgets.Picker{Title: "Container", Items: []string{"plex", "plex-db"}, Cursor: 1}
+	s, err := p.Draw(testDrawContext(80, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, c := range s.Buffer {
+		b.WriteString(c.Grapheme)
+	}
+	if text := b.String(); !strings.Contains(text, "› plex-db") || !strings.Contains(text, "Container") {
+		t.Errorf("expected title and cursor on plex-db, got %q", text)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 // Block characters for sparkline rendering (8 levels).
@@ -92,7 +93,7 @@ func (sl *Sparkline) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 		for _, c := range ctx.Characters(string(ch)) {
 			s.WriteCell(uint16(i), 0, vaxis.Cell{
 				Character: c,
-				Style:     vaxis.Style{Foreground: vaxis.IndexColor(6)}, // cyan
+				Style:     theme.Accent(),
 			})
 		}
 	}
@@ -1,19 +1,60 @@
 package widgets
 
 import (
+	"strconv"
+
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 // TabBar is a horizontal tab navigation widget.
 type TabBar struct {
 	labels []string
+	badges []int
+	errors []bool
 	active int
+	// hits are the column ranges of the labels from the last draw.
+	hits []tabHit
+}
+
+// tabHit is the columns a tab's label, markers included, was drawn over.
+type tabHit struct {
+	start, end uint16
 }
 
 // NewTabBar creates a TabBar with the given labels. Active defaults to 0.
 func NewTabBar(labels []string) *TabBar {
-	return &TabBar{labels: labels}
+	return &TabBar{labels: labels, badges: make([]int, len(labels)), errors: make([]bool, len(labels))}
+}
+
+// SetBadge sets the count shown after a tab's label, e.g. unread alerts.
+// Zero hides the badge. Out-of-range indexes are ignored.
+func (tb *TabBar) SetBadge(i, n int) {
+	if i >= 0 && i < len(tb.badges) {
+		tb.badges[i] = n
+	}
+}
+
+// Badge returns the count shown after a tab's label.
+func (tb *TabBar) Badge(i int) int {
+	if i >= 0 && i < len(tb.badges) {
+		return tb.badges[i]
+	}
+	return 0
+}
+
+// SetError marks a tab whose view failed to load. Out-of-range indexes are
+// ignored.
+func (tb *TabBar) SetError(i int, failed bool) {
+	if i >= 0 && i < len(tb.errors) {
+		tb.errors[i] = failed
+	}
+}
+
+// Error reports whether a tab is marked as failed.
+func (tb *TabBar) Error(i int) bool {
+	return i >= 0 && i < len(tb.errors) && tb.errors[i]
 }
 
 // Active returns the currently active tab index.
@@ -38,12 +79,25 @@ func (tb *TabBar) Prev() {
 	tb.active = (tb.active - 1 + len(tb.labels)) % len(tb.labels)
 }
 
-// Draw renders the tab bar as a single row: " Pools | Datasets | Snapshots "
-// Active tab is rendered with reverse video.
+// TabAt returns the tab whose label was drawn at col in the last draw, for
+// mouse clicks.
+func (tb *TabBar) TabAt(col uint16) (int, bool) {
+	for i, h := range tb.hits {
+		if col >= h.start && col < h.end {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Draw renders the tab bar as a single row: " Pools ! | Datasets | Alerts 3 "
+// Active tab is rendered with reverse video; badges and error markers in
+// bold red. Where each label lands is remembered for TabAt.
 func (tb *TabBar) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 	s := vxfw.NewSurface(ctx.Max.Width, 1, tb)
 
 	col := uint16(0)
+	tb.hits = tb.hits[:0]
 	for i, label := range tb.labels {
 		if i > 0 {
 			// Separator
@@ -56,14 +110,32 @@ func (tb *TabBar) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 
 		style := vaxis.Style{}
 		if i == tb.active {
-			style.Attribute |= vaxis.AttrReverse
+			style = theme.Selection()
 		}
 
+		start := col
 		text := " " + label + " "
 		for _, ch := range ctx.Characters(text) {
 			s.WriteCell(col, 0, vaxis.Cell{Character: ch, Style: style})
 			col += uint16(ch.Width)
 		}
+		marker := ""
+		if tb.errors[i] {
+			marker = "! "
+		}
+		if tb.badges[i] > 0 {
+			marker += strconv.Itoa(tb.badges[i]) + " "
+		}
+		if marker != "" {
+			badge := theme.Bold(theme.Critical())
+			badge.Background = style.Background
+			badge.Attribute |= style.Attribute
+			for _, ch := range ctx.Characters(marker) {
+				s.WriteCell(col, 0, vaxis.Cell{Character: ch, Style: badge})
+				col += uint16(ch.Width)
+			}
+		}
+		tb.hits = append(tb.hits, tabHit{start: start, end: col})
 	}
 
 	return s, nil
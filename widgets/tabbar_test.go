@@ -1,8 +1,10 @@
 package widgets_test
 
 import (
+	"strings"
 	"testing"
 
+	"git.sr.ht/~rockorager/vaxis"
 	"github.com/deevus/truenas-tui/widgets"
 )
 
@@ -110,3 +112,95 @@ func TestTabBar_Draw_ActiveTabChanges(t *testing.T) {
 		t.Errorf("expected height=1, got %d", s3.Size.Height)
 	}
 }
+
+func TestTabBar_Badge(t *testing.T) {
+	tb := widgets.NewTabBar([]string{"A", "Alerts"})
+	tb.SetBadge(1, 3)
+	tb.SetBadge(7, 1) // out of range is ignored
+	if tb.Badge(1) != 3 || tb.Badge(7) != 0 {
+		t.Fatalf("unexpected badges %d, %d", tb.Badge(1), tb.Badge(7))
+	}
+
+	s, err := tb.Draw(testDrawContext(40, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var text string
+	for _, c := range s.Buffer {
+		text += c.Grapheme
+	}
+	if !strings.HasPrefix(text, " A  |  Alerts 3 ") {
+		t.Errorf("unexpected tab bar %q", text)
+	}
+	// " A " + " | " + " Alerts " puts the badge at column 14.
+	if got := s.Buffer[14].Style.Foreground; got != vaxis.IndexColor(1) {
+		t.Errorf("expected red badge, got %v", got)
+	}
+
+	tb.SetBadge(1, 0)
+	if s, _ = tb.Draw(testDrawContext(40, 1)); s.Buffer[14].Grapheme == "3" {
+		t.Error("expected zero to hide the badge")
+	}
+}
+
+func TestTabBar_Error(t *testing.T) {
+	tb := widgets.NewTabBar([]string{"A", "Pools"})
+	tb.SetError(1, true)
+	tb.SetError(7, true) // out of range is ignored
+	if !tb.Error(1) || tb.Error(0) || tb.Error(7) {
+		t.Fatalf("unexpected error markers %v, %v, %v", tb.Error(0), tb.Error(1), tb.Error(7))
+	}
+
+	s, err := tb.Draw(testDrawContext(40, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var text string
+	for _, c := range s.Buffer {
+		text += c.Grapheme
+	}
+	if !strings.HasPrefix(text, " A  |  Pools ! ") {
+		t.Errorf("unexpected tab bar %q", text)
+	}
+	if got := s.Buffer[13].Style.Foreground; got != vaxis.IndexColor(1) {
+		t.Errorf("expected red marker, got %v", got)
+	}
+
+	tb.SetError(1, false)
+	if s, _ = tb.Draw(testDrawContext(40, 1)); s.Buffer[13].Grapheme == "!" {
+		t.Error("expected the marker cleared")
+	}
+}
+
+func TestTabBar_TabAt(t *testing.T) {
+	tb := widgets.NewTabBar([]string{"Pools", "Datasets", "Snapshots"})
+	if _, ok := tb.TabAt(0); ok {
+		t.Error("expected no tab before the first draw")
+	}
+	tb.SetBadge(1, 3)
+	if _, err := tb.Draw(testDrawContext(80, 1)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// " Pools  |  Datasets 3  |  Snapshots "
+	tests := []struct {
+		col  uint16
+		tab  int
+		want bool
+	}{
+		{0, 0, true},
+		{6, 0, true},
+		{8, 0, false}, // separator
+		{10, 1, true},
+		{21, 1, true}, // badge
+		{25, 2, true},
+		{35, 2, true},
+		{36, 0, false},
+	}
+	for _, tt := range tests {
+		tab, ok := tb.TabAt(tt.col)
+		if ok != tt.want || (ok && tab != tt.tab) {
+			t.Errorf("TabAt(%d) = %d, %v, want %d, %v", tt.col, tab, ok, tt.tab, tt.want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package widgets
 import (
 	"git.sr.ht/~rockorager/vaxis"
 	"git.sr.ht/~rockorager/vaxis/vxfw"
+	"github.com/deevus/truenas-tui/theme"
 )
 
 // TableColumn defines a column in a Table.
@@ -17,7 +18,7 @@ type TableColumn struct {
 type Table struct {
 	Columns []TableColumn
 	Rows    [][]string
-	Header  []string // optional header row rendered with AttrDim
+	Header  []string // optional header row rendered in the theme's dim style
 	Gap     int      // spaces between columns (default 1)
 }
 
@@ -82,7 +83,7 @@ func (t *Table) Draw(ctx vxfw.DrawContext) (vxfw.Surface, error) {
 			if i < len(t.Header) {
 				text = t.Header[i]
 			}
-			style := vaxis.Style{Attribute: vaxis.AttrDim}
+			style := theme.Dim()
 			writeText(&s, col, row, c.Width, text, style, c.AlignRight)
 			col += uint16(c.Width + gap)
 		}